@@ -56,8 +56,16 @@ func main() {
 		}
 	}()
 
+	// "migrate" reconciles indexes and exits, so it can run as a pre-deploy job
+	if len(os.Args) > 1 && (os.Args[1] == "migrate" || os.Args[1] == "indexes") {
+		runMigrate(db, logger)
+		return
+	}
+
 	// Create indexes
-	if err := db.CreateIndexes(context.Background()); err != nil {
+	if cfg.MongoDB.SkipIndexCreation {
+		logger.Info(logging.General, logging.Startup, "Skipping index creation", nil)
+	} else if err := db.CreateIndexes(context.Background()); err != nil {
 		logger.Error(logging.General, logging.Startup, "Failed to create indexes", map[logging.ExtraKey]interface{}{
 			"error": err.Error(),
 		})
@@ -97,3 +105,75 @@ func main() {
 
 	logger.Info(logging.General, logging.Startup, "Server exited", nil)
 }
+
+// runMigrate reconciles MongoDB indexes, backfills data and reports what changed
+func runMigrate(db *database.MongoDB, logger logging.Logger) {
+	report, err := db.ReconcileIndexes(context.Background())
+	if report != nil {
+		for _, name := range report.Created {
+			fmt.Printf("created  %s\n", name)
+		}
+		for _, name := range report.Updated {
+			fmt.Printf("updated  %s\n", name)
+		}
+		for _, name := range report.Skipped {
+			fmt.Printf("skipped  %s\n", name)
+		}
+	}
+	if err != nil {
+		_ = db.Close(context.Background())
+		logger.Fatal(logging.General, logging.Startup, "Failed to reconcile indexes", map[logging.ExtraKey]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	logger.Info(logging.General, logging.Startup, "Indexes reconciled", map[logging.ExtraKey]interface{}{
+		"created": len(report.Created),
+		"updated": len(report.Updated),
+		"skipped": len(report.Skipped),
+	})
+
+	backfilled, err := db.BackfillReactionCounts(context.Background())
+	if err != nil {
+		_ = db.Close(context.Background())
+		logger.Fatal(logging.General, logging.Startup, "Failed to backfill reaction counts", map[logging.ExtraKey]interface{}{
+			"error": err.Error(),
+		})
+	}
+	logger.Info(logging.General, logging.Startup, "Backfilled reaction counts", map[logging.ExtraKey]interface{}{
+		"count": backfilled,
+	})
+
+	backfilled, err = db.BackfillTotalReactions(context.Background())
+	if err != nil {
+		_ = db.Close(context.Background())
+		logger.Fatal(logging.General, logging.Startup, "Failed to backfill total reactions", map[logging.ExtraKey]interface{}{
+			"error": err.Error(),
+		})
+	}
+	logger.Info(logging.General, logging.Startup, "Backfilled total reactions", map[logging.ExtraKey]interface{}{
+		"count": backfilled,
+	})
+
+	backfilled, err = db.BackfillRenderHTML(context.Background())
+	if err != nil {
+		_ = db.Close(context.Background())
+		logger.Fatal(logging.General, logging.Startup, "Failed to backfill render_html", map[logging.ExtraKey]interface{}{
+			"error": err.Error(),
+		})
+	}
+	logger.Info(logging.General, logging.Startup, "Backfilled render_html", map[logging.ExtraKey]interface{}{
+		"count": backfilled,
+	})
+
+	backfilled, err = db.BackfillAllowSelfReaction(context.Background())
+	if err != nil {
+		_ = db.Close(context.Background())
+		logger.Fatal(logging.General, logging.Startup, "Failed to backfill allow_self_reaction", map[logging.ExtraKey]interface{}{
+			"error": err.Error(),
+		})
+	}
+	logger.Info(logging.General, logging.Startup, "Backfilled allow_self_reaction", map[logging.ExtraKey]interface{}{
+		"count": backfilled,
+	})
+}
@@ -16,8 +16,12 @@ type Config struct {
 	Redis      RedisConfig
 	Auth       AuthConfig
 	Notifier   NotifierConfig
+	Webhook    WebhookConfig
+	Geo        GeoConfig
 	Moderation ModerationConfig
+	Reactions  ReactionsConfig
 	Logging    LoggingConfig
+	Export     ExportConfig
 }
 
 // ServerConfig holds server configuration
@@ -27,6 +31,8 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+	// Compression enables gzip/deflate/brotli response compression
+	Compression bool
 }
 
 // MongoDBConfig holds MongoDB configuration
@@ -36,6 +42,12 @@ type MongoDBConfig struct {
 	MaxPoolSize     uint64
 	MinPoolSize     uint64
 	MaxConnIdleTime time.Duration
+	// SkipIndexCreation disables index reconciliation on server startup;
+	// run the migrate command instead so replicas don't race to build indexes
+	SkipIndexCreation bool
+	// SoftDeleteRetentionDays is how long soft-deleted comments are kept
+	// before the TTL index purges them
+	SoftDeleteRetentionDays int
 }
 
 // RedisConfig holds Redis configuration for caching
@@ -44,6 +56,11 @@ type RedisConfig struct {
 	Port     int
 	Password string
 	DB       int
+	// CacheEnabled caches comment reads in Redis; the service falls back to
+	// MongoDB reads when Redis is unreachable at startup
+	CacheEnabled bool
+	// CacheTTL bounds how long a cached read can trail the database
+	CacheTTL time.Duration
 }
 
 // AuthConfig holds auth service configuration
@@ -54,6 +71,12 @@ type AuthConfig struct {
 	ClientSecret      string
 	CacheSeconds      int
 	SkipPaths         []string
+	// PublicReadPaths are GET routes (":param" segments allowed) that may be
+	// read without a token; anonymous readers only see approved comments
+	PublicReadPaths []string
+	// OperatorUserIDs may flip the global kill switch; tenant admins can
+	// only disable their own tenant
+	OperatorUserIDs []string
 }
 
 // NotifierConfig holds notifier service configuration
@@ -62,6 +85,43 @@ type NotifierConfig struct {
 	ClientID     string
 	ClientSecret string
 	Enabled      bool
+	// ReportAlertWindow suppresses repeat moderator alerts for the same reported comment
+	ReportAlertWindow time.Duration
+	// MetadataKeys lists comment metadata keys copied into notification data
+	// and kept in webhook events
+	MetadataKeys []string
+	// DeepLinkTemplate builds the deep_link sent with reply and mention
+	// notifications; {comment_id}, {resource_type}, {resource_id} and
+	// {tenant_id} are substituted. Empty sends no link.
+	DeepLinkTemplate string
+	// SuppressSelfNotifications drops notifications whose only recipient is
+	// the user whose action caused them
+	SuppressSelfNotifications bool
+}
+
+// WebhookConfig holds outgoing webhook configuration
+type WebhookConfig struct {
+	// URL receives comment events as signed JSON POSTs; empty disables webhooks
+	URL string
+	// Secret signs each payload with HMAC-SHA256, sent in the X-Signature header
+	Secret string
+	// Timeout bounds a single delivery attempt
+	Timeout time.Duration
+	// MaxRetries is how many times a failed delivery is retried
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubling for each one after
+	RetryBackoff time.Duration
+}
+
+// GeoConfig holds the IP geolocation lookup used for geoblocking
+type GeoConfig struct {
+	// LookupURL is queried with GET for each client IP, with {ip} replaced by
+	// the address; empty disables geoblocking
+	LookupURL string
+	// CountryField is the JSON response field holding the ISO country code
+	CountryField string
+	// Timeout bounds a single lookup
+	Timeout time.Duration
 }
 
 // ModerationConfig holds content moderation settings
@@ -73,6 +133,42 @@ type ModerationConfig struct {
 	MaxReplyDepth      int
 	AllowAnonymous     bool
 	RateLimitPerMinute int
+	// RateLimitBackend keeps rate limit counters in "memory" (per replica) or "redis" (shared)
+	RateLimitBackend string
+	// ContentPipeline lists content processor stages in the order they run
+	ContentPipeline []string
+	// BlockedPatterns are regular expressions that reject a comment outright
+	BlockedPatterns []string
+	// StoreRawContent keeps the exact user input alongside the processed content
+	StoreRawContent bool
+	// ReportedDeleteAction decides what happens when an author deletes a comment
+	// with unresolved reports: "block" refuses, "tombstone" deletes and alerts moderators
+	ReportedDeleteAction string
+	// MaxEditHistory is how many past versions of a comment are kept; 0 keeps all
+	MaxEditHistory int
+	// PendingSweepInterval is how often stale pending comments are auto-closed; 0 disables the sweep
+	PendingSweepInterval time.Duration
+	// RequireHTTPSURLs only accepts absolute https:// attachment URLs; when off, http:// is allowed too
+	RequireHTTPSURLs bool
+	// SpamScoreThreshold marks new comments as spam once this many spam
+	// heuristics match; 0 disables spam scoring
+	SpamScoreThreshold int
+	// SpamMaxURLs is how many URLs a comment may contain before it looks like spam
+	SpamMaxURLs int
+	// SpamMaxRepeatedChars is the longest run of one character allowed before it looks like spam
+	SpamMaxRepeatedChars int
+	// SpamCapsMinLength is how many letters all-caps content needs before it looks like spam
+	SpamCapsMinLength int
+	// DisposableEmailDomains are throwaway email domains (subdomains included)
+	// rejected where settings enable blockDisposableEmails
+	DisposableEmailDomains []string
+}
+
+// ReactionsConfig holds reaction configuration
+type ReactionsConfig struct {
+	// CountRefreshInterval, when set, refreshes comment reaction counts in the
+	// background on this interval instead of on every reaction
+	CountRefreshInterval time.Duration
 }
 
 // LoggingConfig holds logging configuration
@@ -81,6 +177,17 @@ type LoggingConfig struct {
 	Format string
 }
 
+// ExportConfig holds admin export configuration
+type ExportConfig struct {
+	// PIIMode controls author emails and IPs in exports: full, masked or none
+	PIIMode string
+	// StreamListMax caps how many comments one streamed admin listing returns
+	StreamListMax int
+	// MaxComments caps how many comments one export call returns; callers
+	// page through larger exports with afterId
+	MaxComments int
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	_ = godotenv.Load()
@@ -92,19 +199,24 @@ func Load() (*Config, error) {
 			ReadTimeout:     getDuration("SERVER_READ_TIMEOUT", 30*time.Second),
 			WriteTimeout:    getDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
 			ShutdownTimeout: getDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			Compression:     getEnvAsBool("SERVER_COMPRESSION", true),
 		},
 		MongoDB: MongoDBConfig{
-			URI:             getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-			Database:        getEnv("MONGODB_DATABASE", "minisource_comments"),
-			MaxPoolSize:     uint64(getEnvAsInt("MONGODB_MAX_POOL_SIZE", 100)),
-			MinPoolSize:     uint64(getEnvAsInt("MONGODB_MIN_POOL_SIZE", 10)),
-			MaxConnIdleTime: getDuration("MONGODB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+			URI:                     getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+			Database:                getEnv("MONGODB_DATABASE", "minisource_comments"),
+			MaxPoolSize:             uint64(getEnvAsInt("MONGODB_MAX_POOL_SIZE", 100)),
+			MinPoolSize:             uint64(getEnvAsInt("MONGODB_MIN_POOL_SIZE", 10)),
+			MaxConnIdleTime:         getDuration("MONGODB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+			SkipIndexCreation:       getEnvAsBool("MONGODB_SKIP_INDEX_CREATION", false),
+			SoftDeleteRetentionDays: getEnvAsInt("MONGODB_SOFT_DELETE_RETENTION_DAYS", 30),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnvAsInt("REDIS_PORT", 6379),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 2),
+			Host:         getEnv("REDIS_HOST", "localhost"),
+			Port:         getEnvAsInt("REDIS_PORT", 6379),
+			Password:     getEnv("REDIS_PASSWORD", ""),
+			DB:           getEnvAsInt("REDIS_DB", 2),
+			CacheEnabled: getEnvAsBool("REDIS_CACHE_ENABLED", true),
+			CacheTTL:     getDuration("REDIS_CACHE_TTL", time.Minute),
 		},
 		Auth: AuthConfig{
 			ServiceURL:        getEnv("AUTH_SERVICE_URL", "http://localhost:5001"),
@@ -113,26 +225,65 @@ func Load() (*Config, error) {
 			ClientSecret:      getEnv("AUTH_CLIENT_SECRET", "comment-service-secret-key"),
 			CacheSeconds:      getEnvAsInt("AUTH_CACHE_SECONDS", 300),
 			SkipPaths:         getEnvAsSlice("AUTH_SKIP_PATHS", []string{"/health", "/ready", "/metrics"}),
+			PublicReadPaths:   getEnvAsSlice("AUTH_PUBLIC_READ_PATHS", nil),
+			OperatorUserIDs:   getEnvAsSlice("AUTH_OPERATOR_USER_IDS", nil),
 		},
 		Notifier: NotifierConfig{
-			ServiceURL:   getEnv("NOTIFIER_SERVICE_URL", "http://localhost:5003"),
-			ClientID:     getEnv("NOTIFIER_CLIENT_ID", "comment-service"),
-			ClientSecret: getEnv("NOTIFIER_CLIENT_SECRET", "comment-service-secret-key"),
-			Enabled:      getEnvAsBool("NOTIFIER_ENABLED", true),
+			ServiceURL:                getEnv("NOTIFIER_SERVICE_URL", "http://localhost:5003"),
+			ClientID:                  getEnv("NOTIFIER_CLIENT_ID", "comment-service"),
+			ClientSecret:              getEnv("NOTIFIER_CLIENT_SECRET", "comment-service-secret-key"),
+			Enabled:                   getEnvAsBool("NOTIFIER_ENABLED", true),
+			ReportAlertWindow:         getDuration("NOTIFIER_REPORT_ALERT_WINDOW", 15*time.Minute),
+			MetadataKeys:              getEnvAsSlice("NOTIFIER_METADATA_KEYS", nil),
+			DeepLinkTemplate:          getEnv("NOTIFIER_DEEP_LINK_TEMPLATE", ""),
+			SuppressSelfNotifications: getEnvAsBool("NOTIFIER_SUPPRESS_SELF_NOTIFICATIONS", true),
+		},
+		Webhook: WebhookConfig{
+			URL:          getEnv("WEBHOOK_URL", ""),
+			Secret:       getEnv("WEBHOOK_SECRET", ""),
+			Timeout:      getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxRetries:   getEnvAsInt("WEBHOOK_MAX_RETRIES", 3),
+			RetryBackoff: getDuration("WEBHOOK_RETRY_BACKOFF", time.Second),
+		},
+		Geo: GeoConfig{
+			LookupURL:    getEnv("GEO_LOOKUP_URL", ""),
+			CountryField: getEnv("GEO_COUNTRY_FIELD", "country_code"),
+			Timeout:      getDuration("GEO_TIMEOUT", 2*time.Second),
 		},
 		Moderation: ModerationConfig{
-			RequireApproval:    getEnvAsBool("MODERATION_REQUIRE_APPROVAL", true),
-			BadWordsEnabled:    getEnvAsBool("MODERATION_BAD_WORDS_ENABLED", true),
-			BadWordsList:       getEnvAsSlice("MODERATION_BAD_WORDS", getDefaultBadWords()),
-			MaxCommentLength:   getEnvAsInt("MODERATION_MAX_COMMENT_LENGTH", 5000),
-			MaxReplyDepth:      getEnvAsInt("MODERATION_MAX_REPLY_DEPTH", 5),
-			AllowAnonymous:     getEnvAsBool("MODERATION_ALLOW_ANONYMOUS", false),
-			RateLimitPerMinute: getEnvAsInt("MODERATION_RATE_LIMIT_PER_MINUTE", 10),
+			RequireApproval:        getEnvAsBool("MODERATION_REQUIRE_APPROVAL", true),
+			BadWordsEnabled:        getEnvAsBool("MODERATION_BAD_WORDS_ENABLED", true),
+			BadWordsList:           getEnvAsSlice("MODERATION_BAD_WORDS", getDefaultBadWords()),
+			MaxCommentLength:       getEnvAsInt("MODERATION_MAX_COMMENT_LENGTH", 5000),
+			MaxReplyDepth:          getEnvAsInt("MODERATION_MAX_REPLY_DEPTH", 5),
+			AllowAnonymous:         getEnvAsBool("MODERATION_ALLOW_ANONYMOUS", false),
+			RateLimitPerMinute:     getEnvAsInt("MODERATION_RATE_LIMIT_PER_MINUTE", 10),
+			RateLimitBackend:       getEnv("MODERATION_RATE_LIMIT_BACKEND", "memory"),
+			ContentPipeline:        getEnvAsSlice("MODERATION_CONTENT_PIPELINE", nil),
+			BlockedPatterns:        getEnvAsSlice("MODERATION_BLOCKED_PATTERNS", nil),
+			StoreRawContent:        getEnvAsBool("MODERATION_STORE_RAW_CONTENT", false),
+			ReportedDeleteAction:   getEnv("MODERATION_REPORTED_DELETE_ACTION", "tombstone"),
+			MaxEditHistory:         getEnvAsInt("MODERATION_MAX_EDIT_HISTORY", 20),
+			PendingSweepInterval:   getDuration("MODERATION_PENDING_SWEEP_INTERVAL", time.Hour),
+			RequireHTTPSURLs:       getEnvAsBool("MODERATION_REQUIRE_HTTPS_URLS", true),
+			SpamScoreThreshold:     getEnvAsInt("MODERATION_SPAM_SCORE_THRESHOLD", 2),
+			SpamMaxURLs:            getEnvAsInt("MODERATION_SPAM_MAX_URLS", 3),
+			SpamMaxRepeatedChars:   getEnvAsInt("MODERATION_SPAM_MAX_REPEATED_CHARS", 10),
+			SpamCapsMinLength:      getEnvAsInt("MODERATION_SPAM_CAPS_MIN_LENGTH", 20),
+			DisposableEmailDomains: getEnvAsSlice("MODERATION_DISPOSABLE_EMAIL_DOMAINS", getDefaultDisposableEmailDomains()),
+		},
+		Reactions: ReactionsConfig{
+			CountRefreshInterval: getDuration("REACTIONS_COUNT_REFRESH_INTERVAL", 0),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		Export: ExportConfig{
+			PIIMode:       getEnv("EXPORT_PII_MODE", "masked"),
+			StreamListMax: getEnvAsInt("EXPORT_STREAM_LIST_MAX", 10000),
+			MaxComments:   getEnvAsInt("EXPORT_MAX_COMMENTS", 10000),
+		},
 	}, nil
 }
 
@@ -179,6 +330,14 @@ func getDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+func getDefaultDisposableEmailDomains() []string {
+	// A few well-known providers - extend via MODERATION_DISPOSABLE_EMAIL_DOMAINS
+	return []string{
+		"mailinator.com", "guerrillamail.com", "sharklasers.com", "10minutemail.com",
+		"temp-mail.org", "yopmail.com", "trashmail.com", "getnada.com", "dispostable.com",
+	}
+}
+
 func getDefaultBadWords() []string {
 	// This is a minimal list - in production, load from file or database
 	return []string{
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache implements the usecase Cache interface on Redis
+type RedisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisCache creates a cache that keeps entries for ttl
+func NewRedisCache(client *redis.Client, ttl time.Duration) *RedisCache {
+	return &RedisCache{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// Get returns the value stored under key. Errors are logged and reported as misses.
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Failed to read cache key %s: %v", key, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// Set stores value under key for the configured TTL
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte) {
+	if err := c.client.Set(ctx, key, value, c.ttl).Err(); err != nil {
+		log.Printf("Failed to write cache key %s: %v", key, err)
+	}
+}
+
+// Delete removes keys from the cache
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("Failed to delete cache keys: %v", err)
+	}
+}
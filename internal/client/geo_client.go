@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GeoClient resolves client IPs to ISO country codes through an HTTP lookup service
+type GeoClient struct {
+	lookupURL    string
+	countryField string
+	httpClient   *http.Client
+}
+
+// NewGeoClient creates a new geo client. lookupURL is requested with {ip}
+// replaced by the address, and countryField names the JSON response field
+// holding the country code.
+func NewGeoClient(lookupURL, countryField string, timeout time.Duration) *GeoClient {
+	return &GeoClient{
+		lookupURL:    lookupURL,
+		countryField: countryField,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// CountryForIP returns the upper-cased country code for ip, or "" when the
+// lookup service doesn't know it
+func (c *GeoClient) CountryForIP(ctx context.Context, ip string) (string, error) {
+	if ip == "" {
+		return "", nil
+	}
+
+	lookupURL := strings.ReplaceAll(c.lookupURL, "{ip}", url.PathEscape(ip))
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", lookupURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up country: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geo lookup returned status %d", resp.StatusCode)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode geo lookup: %w", err)
+	}
+
+	country, _ := body[c.countryField].(string)
+	return strings.ToUpper(strings.TrimSpace(country)), nil
+}
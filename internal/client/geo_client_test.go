@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountryForIP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lookup/203.0.113.7":
+			w.Write([]byte(`{"ip": "203.0.113.7", "country_code": "de"}`))
+		case "/lookup/198.51.100.1":
+			w.Write([]byte(`{"ip": "198.51.100.1"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewGeoClient(server.URL+"/lookup/{ip}", "country_code", time.Second)
+
+	t.Run("Known IP", func(t *testing.T) {
+		country, err := client.CountryForIP(context.Background(), "203.0.113.7")
+		require.NoError(t, err)
+		assert.Equal(t, "DE", country)
+	})
+
+	t.Run("Unknown IP", func(t *testing.T) {
+		country, err := client.CountryForIP(context.Background(), "198.51.100.1")
+		require.NoError(t, err)
+		assert.Empty(t, country)
+	})
+
+	t.Run("Lookup Failure", func(t *testing.T) {
+		_, err := client.CountryForIP(context.Background(), "192.0.2.1")
+		assert.Error(t, err)
+	})
+
+	t.Run("No IP", func(t *testing.T) {
+		country, err := client.CountryForIP(context.Background(), "")
+		require.NoError(t, err)
+		assert.Empty(t, country)
+	})
+}
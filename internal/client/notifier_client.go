@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +16,8 @@ type NotifierClient struct {
 	baseURL    string
 	httpClient *http.Client
 	enabled    bool
+	// batchUnsupported is set once the service rejects the batch endpoint
+	batchUnsupported atomic.Bool
 }
 
 // NewNotifierClient creates a new notifier client
@@ -43,37 +47,85 @@ func (c *NotifierClient) SendNotification(ctx context.Context, notificationType,
 		return nil
 	}
 
-	req := NotificationRequest{
+	return c.send(ctx, NotificationRequest{
 		Type:     notificationType,
 		Title:    title,
 		Message:  message,
 		Data:     data,
 		Channels: []string{"push", "email"},
+	})
+}
+
+// BatchNotificationRequest represents a batch of notifications sent in one call
+type BatchNotificationRequest struct {
+	Notifications []NotificationRequest `json:"notifications"`
+}
+
+// SendNotifications sends several notifications in one batch call. If the
+// notifier service doesn't support batches, they are sent one at a time and
+// later calls skip the batch endpoint.
+func (c *NotifierClient) SendNotifications(ctx context.Context, notifications []NotificationRequest) error {
+	if !c.enabled || len(notifications) == 0 {
+		return nil
 	}
 
-	body, err := json.Marshal(req)
+	if !c.batchUnsupported.Load() {
+		status, err := c.post(ctx, "/api/v1/notifications/batch", BatchNotificationRequest{Notifications: notifications})
+		if err != nil {
+			return fmt.Errorf("failed to send notifications: %w", err)
+		}
+		switch {
+		case status == http.StatusNotFound || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented:
+			c.batchUnsupported.Store(true)
+		case status >= 400:
+			return fmt.Errorf("notification service returned status %d", status)
+		default:
+			return nil
+		}
+	}
+
+	var errs []error
+	for _, notification := range notifications {
+		if err := c.send(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send posts a single notification
+func (c *NotifierClient) send(ctx context.Context, notification NotificationRequest) error {
+	status, err := c.post(ctx, "/api/v1/notifications", notification)
 	if err != nil {
-		return fmt.Errorf("failed to marshal notification: %w", err)
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	if status >= 400 {
+		return fmt.Errorf("notification service returned status %d", status)
+	}
+	return nil
+}
+
+// post sends a JSON body to the notifier service and returns the response status
+func (c *NotifierClient) post(ctx context.Context, path string, payload any) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/notifications", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to send notification: %w", err)
+		return 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("notification service returned status %d", resp.StatusCode)
-	}
-
-	return nil
+	return resp.StatusCode, nil
 }
 
 // SendNewCommentNotification sends notification for new comment
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendNotifications(t *testing.T) {
+	notifications := []NotificationRequest{
+		{Type: "comment.moderated", Recipients: []string{"alice"}},
+		{Type: "comment.moderated", Recipients: []string{"bob"}},
+	}
+
+	newServer := func(batchStatus int) (*httptest.Server, *[]string) {
+		var (
+			mu    sync.Mutex
+			paths []string
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			paths = append(paths, r.URL.Path)
+			mu.Unlock()
+			if r.URL.Path == "/api/v1/notifications/batch" {
+				w.WriteHeader(batchStatus)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		t.Cleanup(server.Close)
+		return server, &paths
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		server, paths := newServer(http.StatusAccepted)
+		client := NewNotifierClient(server.URL, true)
+
+		require.NoError(t, client.SendNotifications(context.Background(), notifications))
+		assert.Equal(t, []string{"/api/v1/notifications/batch"}, *paths)
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		server, paths := newServer(http.StatusNotFound)
+		client := NewNotifierClient(server.URL, true)
+
+		require.NoError(t, client.SendNotifications(context.Background(), notifications))
+		assert.Equal(t, []string{
+			"/api/v1/notifications/batch",
+			"/api/v1/notifications",
+			"/api/v1/notifications",
+		}, *paths)
+
+		// The unsupported batch endpoint isn't retried
+		*paths = nil
+		require.NoError(t, client.SendNotifications(context.Background(), notifications[:1]))
+		assert.Equal(t, []string{"/api/v1/notifications"}, *paths)
+	})
+
+	t.Run("Server Error", func(t *testing.T) {
+		server, _ := newServer(http.StatusInternalServerError)
+		client := NewNotifierClient(server.URL, true)
+
+		assert.EqualError(t, client.SendNotifications(context.Background(), notifications), "notification service returned status 500")
+	})
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of a webhook body
+const SignatureHeader = "X-Signature"
+
+// WebhookClient posts signed comment events to an integrator's endpoint
+type WebhookClient struct {
+	url          string
+	secret       string
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// NewWebhookClient creates a new webhook client. An empty url disables it.
+func NewWebhookClient(url, secret string, timeout time.Duration, maxRetries int, retryBackoff time.Duration) *WebhookClient {
+	return &WebhookClient{
+		url:    url,
+		secret: secret,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		maxRetries:   maxRetries,
+		retryBackoff: retryBackoff,
+	}
+}
+
+// WebhookPayload is the body posted for every event
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// SendWebhook posts an event, retrying network errors, 429 and 5xx responses
+// with exponential backoff until the retries or ctx run out
+func (c *WebhookClient) SendWebhook(ctx context.Context, event string, data any) error {
+	if c.url == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(WebhookPayload{Event: event, Timestamp: time.Now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+	signature := Sign(c.secret, body)
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := c.post(ctx, body, signature)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= c.maxRetries {
+			return fmt.Errorf("failed to deliver %s webhook: %w", event, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to deliver %s webhook: %w", event, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (c *WebhookClient) post(ctx context.Context, body []byte, signature string) (bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(SignatureHeader, signature)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the X-Signature value for a webhook body: "sha256=" followed
+// by the hex HMAC-SHA256 of the body keyed with the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
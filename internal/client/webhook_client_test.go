@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendWebhook(t *testing.T) {
+	// newServer answers with the given statuses in turn, then 204
+	newServer := func(statuses ...int) (*httptest.Server, *[]*http.Request, *[][]byte) {
+		var (
+			mu       sync.Mutex
+			requests []*http.Request
+			bodies   [][]byte
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, r)
+			bodies = append(bodies, body)
+			if len(requests) <= len(statuses) {
+				w.WriteHeader(statuses[len(requests)-1])
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests, &bodies
+	}
+
+	t.Run("Signed Payload", func(t *testing.T) {
+		server, requests, bodies := newServer()
+		client := NewWebhookClient(server.URL, "s3cret", time.Second, 3, time.Millisecond)
+
+		require.NoError(t, client.SendWebhook(context.Background(), "comment.created", map[string]string{"id": "c1"}))
+		require.Len(t, *requests, 1)
+
+		body := (*bodies)[0]
+		assert.Equal(t, Sign("s3cret", body), (*requests)[0].Header.Get(SignatureHeader))
+		assert.NotEqual(t, Sign("other", body), (*requests)[0].Header.Get(SignatureHeader))
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, "comment.created", payload["event"])
+		assert.Equal(t, map[string]any{"id": "c1"}, payload["data"])
+		assert.NotEmpty(t, payload["timestamp"])
+	})
+
+	t.Run("Retries Server Errors", func(t *testing.T) {
+		server, requests, _ := newServer(http.StatusBadGateway, http.StatusTooManyRequests)
+		client := NewWebhookClient(server.URL, "s3cret", time.Second, 3, time.Millisecond)
+
+		require.NoError(t, client.SendWebhook(context.Background(), "comment.deleted", nil))
+		assert.Len(t, *requests, 3)
+	})
+
+	t.Run("Gives Up After Max Retries", func(t *testing.T) {
+		server, requests, _ := newServer(500, 500, 500, 500)
+		client := NewWebhookClient(server.URL, "s3cret", time.Second, 2, time.Millisecond)
+
+		err := client.SendWebhook(context.Background(), "comment.deleted", nil)
+		assert.EqualError(t, err, "failed to deliver comment.deleted webhook: webhook endpoint returned status 500")
+		assert.Len(t, *requests, 3)
+	})
+
+	t.Run("Client Errors Are Not Retried", func(t *testing.T) {
+		server, requests, _ := newServer(http.StatusBadRequest)
+		client := NewWebhookClient(server.URL, "s3cret", time.Second, 3, time.Millisecond)
+
+		assert.Error(t, client.SendWebhook(context.Background(), "comment.updated", nil))
+		assert.Len(t, *requests, 1)
+	})
+
+	t.Run("Disabled Without URL", func(t *testing.T) {
+		client := NewWebhookClient("", "s3cret", time.Second, 3, time.Millisecond)
+		assert.NoError(t, client.SendWebhook(context.Background(), "comment.created", nil))
+	})
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BackfillReactionCounts sets an empty reaction_counts object on comments
+// created before it was initialized at creation, returning how many changed
+func (m *MongoDB) BackfillReactionCounts(ctx context.Context) (int64, error) {
+	result, err := m.Collection("comments").UpdateMany(
+		ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"reaction_counts": bson.M{"$exists": false}},
+			bson.M{"reaction_counts": nil},
+		}},
+		bson.M{"$set": bson.M{"reaction_counts": bson.M{}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// BackfillTotalReactions sums reaction_counts into total_reactions on
+// comments stored before the total was kept, returning how many changed
+func (m *MongoDB) BackfillTotalReactions(ctx context.Context) (int64, error) {
+	result, err := m.Collection("comments").UpdateMany(
+		ctx,
+		bson.M{"total_reactions": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{
+			"total_reactions": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$reaction_counts", bson.M{}}}},
+				"in":    "$$this.v",
+			}}},
+		}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// BackfillAllowSelfReaction lets authors keep reacting to their own comments
+// under settings saved before it became optional, returning how many changed
+func (m *MongoDB) BackfillAllowSelfReaction(ctx context.Context) (int64, error) {
+	result, err := m.Collection("settings").UpdateMany(
+		ctx,
+		bson.M{"allow_self_reaction": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"allow_self_reaction": true}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// BackfillRenderHTML turns on HTML rendering for settings saved before it
+// became optional, so they keep getting contentHtml, returning how many changed
+func (m *MongoDB) BackfillRenderHTML(ctx context.Context) (int64, error) {
+	result, err := m.Collection("settings").UpdateMany(
+		ctx,
+		bson.M{"render_html": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"render_html": true}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,518 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes groups the desired indexes of a single collection
+type collectionIndexes struct {
+	Collection string
+	Indexes    []mongo.IndexModel
+}
+
+// IndexReport lists what a reconcile run did, as "collection.index" entries
+type IndexReport struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
+}
+
+// indexPlan is the set of changes needed to bring one collection in line
+type indexPlan struct {
+	create []mongo.IndexModel
+	update []mongo.IndexModel
+	retime []mongo.IndexModel // TTL indexes whose expiry alone changed, updated in place
+	skip   []string
+}
+
+// defaultSoftDeleteRetentionDays is used when no positive retention is configured
+const defaultSoftDeleteRetentionDays = 30
+
+// retentionDays resolves the configured soft-delete retention
+func retentionDays(days int) int {
+	if days <= 0 {
+		return defaultSoftDeleteRetentionDays
+	}
+	return days
+}
+
+// ReconcileIndexes creates missing indexes and rebuilds ones whose definition changed
+func (m *MongoDB) ReconcileIndexes(ctx context.Context) (*IndexReport, error) {
+	report := &IndexReport{}
+
+	for _, def := range indexDefinitions(retentionDays(m.softDeleteRetentionDays)) {
+		view := m.Collection(def.Collection).Indexes()
+
+		existing, err := view.ListSpecifications(ctx)
+		if err != nil {
+			return report, fmt.Errorf("failed to list %s indexes: %w", def.Collection, err)
+		}
+
+		plan := planIndexes(def.Indexes, existing)
+
+		for _, model := range plan.update {
+			name := indexName(model)
+			if _, err := view.DropOne(ctx, name); err != nil {
+				return report, fmt.Errorf("failed to drop index %s.%s: %w", def.Collection, name, err)
+			}
+			if _, err := view.CreateOne(ctx, model); err != nil {
+				return report, fmt.Errorf("failed to recreate index %s.%s: %w", def.Collection, name, err)
+			}
+			report.Updated = append(report.Updated, def.Collection+"."+name)
+		}
+
+		// MongoDB refuses to recreate an index under the same name with a
+		// different expiry, and rebuilding a TTL index is needlessly slow
+		for _, model := range plan.retime {
+			name := indexName(model)
+			command := bson.D{
+				{Key: "collMod", Value: def.Collection},
+				{Key: "index", Value: bson.D{
+					{Key: "name", Value: name},
+					{Key: "expireAfterSeconds", Value: *model.Options.ExpireAfterSeconds},
+				}},
+			}
+			if err := m.Database.RunCommand(ctx, command).Err(); err != nil {
+				return report, fmt.Errorf("failed to update expiry of index %s.%s: %w", def.Collection, name, err)
+			}
+			report.Updated = append(report.Updated, def.Collection+"."+name)
+		}
+
+		if len(plan.create) > 0 {
+			if _, err := view.CreateMany(ctx, plan.create); err != nil {
+				return report, fmt.Errorf("failed to create %s indexes: %w", def.Collection, err)
+			}
+			for _, model := range plan.create {
+				report.Created = append(report.Created, def.Collection+"."+indexName(model))
+			}
+		}
+
+		for _, name := range plan.skip {
+			report.Skipped = append(report.Skipped, def.Collection+"."+name)
+		}
+	}
+
+	return report, nil
+}
+
+// planIndexes compares desired indexes with the ones already on the collection
+func planIndexes(desired []mongo.IndexModel, existing []*mongo.IndexSpecification) indexPlan {
+	byName := make(map[string]*mongo.IndexSpecification, len(existing))
+	for _, spec := range existing {
+		byName[spec.Name] = spec
+	}
+
+	var plan indexPlan
+	for _, model := range desired {
+		name := indexName(model)
+		spec, ok := byName[name]
+		switch {
+		case !ok:
+			plan.create = append(plan.create, model)
+		case indexMatches(model, spec):
+			plan.skip = append(plan.skip, name)
+		case onlyExpiryDiffers(model, spec):
+			plan.retime = append(plan.retime, model)
+		default:
+			plan.update = append(plan.update, model)
+		}
+	}
+
+	return plan
+}
+
+// indexMatches reports whether an existing index satisfies the desired definition
+func indexMatches(model mongo.IndexModel, spec *mongo.IndexSpecification) bool {
+	return int32Value(indexOptions(model).ExpireAfterSeconds) == int32Value(spec.ExpireAfterSeconds) &&
+		indexShapeMatches(model, spec)
+}
+
+// onlyExpiryDiffers reports whether an existing TTL index differs from the
+// desired one in its expiry alone
+func onlyExpiryDiffers(model mongo.IndexModel, spec *mongo.IndexSpecification) bool {
+	return indexOptions(model).ExpireAfterSeconds != nil && spec.ExpireAfterSeconds != nil &&
+		indexShapeMatches(model, spec)
+}
+
+// indexShapeMatches compares everything but the expiry of two indexes
+func indexShapeMatches(model mongo.IndexModel, spec *mongo.IndexSpecification) bool {
+	if boolValue(indexOptions(model).Unique) != boolValue(spec.Unique) {
+		return false
+	}
+
+	keys, err := bson.Marshal(model.Keys)
+	if err != nil {
+		return false
+	}
+
+	// Text indexes are stored as internal _fts/_ftsx keys, so only the name can be compared
+	if isTextIndex(keys) {
+		return true
+	}
+
+	return keysEqual(keys, spec.KeysDocument)
+}
+
+// keysEqual compares two key documents field by field, ignoring numeric width
+func keysEqual(a, b bson.Raw) bool {
+	aElems, err := a.Elements()
+	if err != nil {
+		return false
+	}
+	bElems, err := b.Elements()
+	if err != nil {
+		return false
+	}
+	if len(aElems) != len(bElems) {
+		return false
+	}
+
+	for i := range aElems {
+		if aElems[i].Key() != bElems[i].Key() {
+			return false
+		}
+		if keyValue(aElems[i].Value()) != keyValue(bElems[i].Value()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keyValue normalizes an index key direction/type so 1, int64(1) and 1.0 compare equal
+func keyValue(v bson.RawValue) string {
+	if i, ok := v.AsInt64OK(); ok {
+		return fmt.Sprint(i)
+	}
+	if s, ok := v.StringValueOK(); ok {
+		return s
+	}
+	return v.String()
+}
+
+func isTextIndex(keys bson.Raw) bool {
+	elems, err := keys.Elements()
+	if err != nil {
+		return false
+	}
+	for _, elem := range elems {
+		if s, ok := elem.Value().StringValueOK(); ok && s == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOptions(model mongo.IndexModel) *options.IndexOptions {
+	if model.Options == nil {
+		return options.Index()
+	}
+	return model.Options
+}
+
+func indexName(model mongo.IndexModel) string {
+	if model.Options != nil && model.Options.Name != nil {
+		return *model.Options.Name
+	}
+	return ""
+}
+
+func boolValue(b *bool) bool {
+	return b != nil && *b
+}
+
+func int32Value(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// indexDefinitions returns the desired indexes for every collection, purging
+// soft-deleted comments after retentionDays
+func indexDefinitions(retentionDays int) []collectionIndexes {
+	return []collectionIndexes{
+		// Comments collection indexes
+		{
+			Collection: "comments",
+			Indexes: []mongo.IndexModel{
+				// Compound index for listing comments by resource; its
+				// tenant/resource/is_deleted prefix also serves the stats
+				// and reaction breakdown aggregations
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "resource_type", Value: 1},
+						{Key: "resource_id", Value: 1},
+						{Key: "is_deleted", Value: 1},
+						{Key: "status", Value: 1},
+					},
+					Options: options.Index().SetName("idx_resource_comments"),
+				},
+				// Index for replies
+				{
+					Keys: bson.D{
+						{Key: "parent_id", Value: 1},
+						{Key: "is_deleted", Value: 1},
+					},
+					Options: options.Index().SetName("idx_parent_comments"),
+				},
+				// Index for whole-thread reads
+				{
+					Keys: bson.D{
+						{Key: "root_id", Value: 1},
+						{Key: "depth", Value: 1},
+						{Key: "created_at", Value: 1},
+					},
+					Options: options.Index().SetName("idx_thread"),
+				},
+				// Index for author's comments
+				{
+					Keys: bson.D{
+						{Key: "author_id", Value: 1},
+						{Key: "is_deleted", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("idx_author_comments"),
+				},
+				// Index for an author's pending comments
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "author_id", Value: 1},
+						{Key: "status", Value: 1},
+					},
+					Options: options.Index().SetName("idx_author_pending"),
+				},
+				// Index for moderation queue
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "status", Value: 1},
+						{Key: "created_at", Value: 1},
+					},
+					Options: options.Index().SetName("idx_moderation_queue"),
+				},
+				// Index for pinned comments
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "resource_type", Value: 1},
+						{Key: "resource_id", Value: 1},
+						{Key: "is_pinned", Value: 1},
+					},
+					Options: options.Index().SetName("idx_pinned_comments"),
+				},
+				// Text index for content search
+				{
+					Keys: bson.D{
+						{Key: "content", Value: "text"},
+						{Key: "author_name", Value: "text"},
+					},
+					Options: options.Index().SetName("idx_content_search"),
+				},
+				// Index for sorting by popularity
+				{
+					Keys: bson.D{
+						{Key: "like_count", Value: -1},
+					},
+					Options: options.Index().SetName("idx_like_count"),
+				},
+				// Index for sorting by reactions of every type
+				{
+					Keys: bson.D{
+						{Key: "total_reactions", Value: -1},
+					},
+					Options: options.Index().SetName("idx_total_reactions"),
+				},
+				// Index for sorting by helpfulness
+				{
+					Keys: bson.D{
+						{Key: "helpful_count", Value: -1},
+					},
+					Options: options.Index().SetName("idx_helpful_count"),
+				},
+				// TTL index for soft-deleted comments (auto-delete after the retention)
+				{
+					Keys: bson.D{
+						{Key: "deleted_at", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_deleted_ttl").
+						SetExpireAfterSeconds(int32(retentionDays * 24 * 60 * 60)),
+				},
+			},
+		},
+
+		// Reactions collection indexes
+		{
+			Collection: "reactions",
+			Indexes: []mongo.IndexModel{
+				// Unique index for user reaction per comment
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "user_id", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_user_reaction").
+						SetUnique(true),
+				},
+				// Index for counting reactions by type
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "type", Value: 1},
+					},
+					Options: options.Index().SetName("idx_comment_reaction_type"),
+				},
+			},
+		},
+
+		// Helpful votes collection indexes
+		{
+			Collection: "helpful_votes",
+			Indexes: []mongo.IndexModel{
+				// Unique index for user vote per comment
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "user_id", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_user_helpful_vote").
+						SetUnique(true),
+				},
+				// Index for counting votes by type
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "type", Value: 1},
+					},
+					Options: options.Index().SetName("idx_comment_vote_type"),
+				},
+			},
+		},
+
+		// Reports collection indexes
+		{
+			Collection: "reports",
+			Indexes: []mongo.IndexModel{
+				// Index for comment reports
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "status", Value: 1},
+					},
+					Options: options.Index().SetName("idx_comment_reports"),
+				},
+				// Prevent duplicate reports from same user
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "reporter_id", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_unique_report").
+						SetUnique(true),
+				},
+			},
+		},
+
+		// Settings collection indexes
+		{
+			Collection: "settings",
+			Indexes: []mongo.IndexModel{
+				// Unique index for tenant + resource type settings
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "resource_type", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_tenant_settings").
+						SetUnique(true),
+				},
+			},
+		},
+
+		// Resource views collection indexes
+		{
+			Collection: "resource_views",
+			Indexes: []mongo.IndexModel{
+				// Unique index for a user's last-seen marker per resource
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "resource_type", Value: 1},
+						{Key: "resource_id", Value: 1},
+						{Key: "user_id", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_user_resource_view").
+						SetUnique(true),
+				},
+			},
+		},
+		// Resource counter collection indexes
+		{
+			Collection: "resource_counters",
+			Indexes: []mongo.IndexModel{
+				// Unique index for one counter per resource
+				{
+					Keys: bson.D{
+						{Key: "tenant_id", Value: 1},
+						{Key: "resource_type", Value: 1},
+						{Key: "resource_id", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_resource_counter").
+						SetUnique(true),
+				},
+			},
+		},
+		// Kill switch collection indexes
+		{
+			Collection: "kill_switches",
+			Indexes: []mongo.IndexModel{
+				// One switch per scope and tenant
+				{
+					Keys: bson.D{
+						{Key: "scope", Value: 1},
+						{Key: "tenant_id", Value: 1},
+					},
+					Options: options.Index().
+						SetName("idx_kill_switch_scope").
+						SetUnique(true),
+				},
+			},
+		},
+		// Audit log collection indexes
+		{
+			Collection: "audit_log",
+			Indexes: []mongo.IndexModel{
+				// Index for a comment's audit trail
+				{
+					Keys: bson.D{
+						{Key: "comment_id", Value: 1},
+						{Key: "created_at", Value: 1},
+					},
+					Options: options.Index().SetName("idx_audit_comment"),
+				},
+				// Index for an actor's actions
+				{
+					Keys: bson.D{
+						{Key: "actor_id", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("idx_audit_actor"),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func spec(t *testing.T, name string, keys bson.D, unique bool) *mongo.IndexSpecification {
+	raw, err := bson.Marshal(keys)
+	require.NoError(t, err)
+	return &mongo.IndexSpecification{Name: name, KeysDocument: raw, Unique: &unique}
+}
+
+func TestPlanIndexes(t *testing.T) {
+	desired := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "parent_id", Value: 1}},
+			Options: options.Index().SetName("idx_unchanged"),
+		},
+		{
+			Keys:    bson.D{{Key: "comment_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_changed").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "author_id", Value: 1}},
+			Options: options.Index().SetName("idx_missing"),
+		},
+		{
+			Keys:    bson.D{{Key: "content", Value: "text"}},
+			Options: options.Index().SetName("idx_text"),
+		},
+	}
+
+	existing := []*mongo.IndexSpecification{
+		spec(t, "_id_", bson.D{{Key: "_id", Value: 1}}, false),
+		// Stored as a double by the server, still the same index
+		spec(t, "idx_unchanged", bson.D{{Key: "parent_id", Value: 1.0}}, false),
+		spec(t, "idx_changed", bson.D{{Key: "comment_id", Value: 1}}, true),
+		spec(t, "idx_text", bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: 1}}, false),
+	}
+
+	plan := planIndexes(desired, existing)
+
+	require.Len(t, plan.create, 1)
+	assert.Equal(t, "idx_missing", indexName(plan.create[0]))
+	require.Len(t, plan.update, 1)
+	assert.Equal(t, "idx_changed", indexName(plan.update[0]))
+	assert.ElementsMatch(t, []string{"idx_unchanged", "idx_text"}, plan.skip)
+}
+
+func TestPlanIndexesTTL(t *testing.T) {
+	ttl := func(name string, days int32) *mongo.IndexSpecification {
+		s := spec(t, name, bson.D{{Key: "deleted_at", Value: 1}}, false)
+		seconds := days * 24 * 60 * 60
+		s.ExpireAfterSeconds = &seconds
+		return s
+	}
+
+	desired := indexDefinitions(7)[0].Indexes
+	var deletedTTL mongo.IndexModel
+	for _, model := range desired {
+		if indexName(model) == "idx_deleted_ttl" {
+			deletedTTL = model
+		}
+	}
+	require.NotNil(t, deletedTTL.Options)
+	assert.Equal(t, int32(7*24*60*60), *deletedTTL.Options.ExpireAfterSeconds)
+
+	plan := planIndexes([]mongo.IndexModel{deletedTTL}, []*mongo.IndexSpecification{ttl("idx_deleted_ttl", 30)})
+	require.Len(t, plan.retime, 1, "a changed retention is applied in place")
+	assert.Empty(t, plan.update)
+
+	plan = planIndexes([]mongo.IndexModel{deletedTTL}, []*mongo.IndexSpecification{ttl("idx_deleted_ttl", 7)})
+	assert.Equal(t, []string{"idx_deleted_ttl"}, plan.skip)
+
+	// Without a TTL on the existing index there is nothing to modify
+	plan = planIndexes([]mongo.IndexModel{deletedTTL}, []*mongo.IndexSpecification{spec(t, "idx_deleted_ttl", bson.D{{Key: "deleted_at", Value: 1}}, false)})
+	assert.Len(t, plan.update, 1)
+	assert.Empty(t, plan.retime)
+}
+
+func TestRetentionDays(t *testing.T) {
+	assert.Equal(t, 7, retentionDays(7))
+	assert.Equal(t, 30, retentionDays(0))
+	assert.Equal(t, 30, retentionDays(-1))
+}
+
+func TestIndexDefinitionsAreNamed(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, def := range indexDefinitions(30) {
+		for _, model := range def.Indexes {
+			name := indexName(model)
+			assert.NotEmpty(t, name, "index on %s must be named", def.Collection)
+			assert.False(t, seen[def.Collection+"."+name], "duplicate index %s", name)
+			seen[def.Collection+"."+name] = true
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"log"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/minisource/comment/internal/models"
@@ -12,15 +16,72 @@ import (
 // AdminHandler handles admin HTTP requests
 type AdminHandler struct {
 	commentUsecase *usecase.CommentUsecase
+	reportUsecase  *usecase.ReportUsecase
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(commentUsecase *usecase.CommentUsecase) *AdminHandler {
+func NewAdminHandler(commentUsecase *usecase.CommentUsecase, reportUsecase *usecase.ReportUsecase) *AdminHandler {
 	return &AdminHandler{
 		commentUsecase: commentUsecase,
+		reportUsecase:  reportUsecase,
 	}
 }
 
+// ListComments lists comments with any status, optionally across several authors
+// @Summary List comments, e.g. by a team of authors
+// @Tags admin
+// @Produce json
+// @Param resource_type query string false "Resource type"
+// @Param resource_id query string false "Resource ID"
+// @Param status query string false "Status filter"
+// @Param author_id query string false "Author ID"
+// @Param author_ids query string false "Comma-separated author IDs (at most 100)"
+// @Param label query string false "Only comments with this moderation label"
+// @Param view query string false "Set to 'flat' to include replies"
+// @Param created_after query string false "Only comments created at or after this RFC3339 time"
+// @Param created_before query string false "Only comments created before this RFC3339 time"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param sort_by query string false "Sort field: created_at, like_count, reply_count, helpful_count or total_reactions"
+// @Param sort_order query string false "Sort order"
+// @Success 200 {object} models.ListCommentsResponse
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments [get]
+func (h *AdminHandler) ListComments(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	userID, _ := c.Locals("user_id").(string)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size", "20"))
+
+	req := models.ListCommentsRequest{
+		TenantID:     tenantID,
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		Status:       models.CommentStatus(c.Query("status")),
+		AuthorID:     c.Query("author_id"),
+		Label:        c.Query("label"),
+		View:         c.Query("view"),
+		Page:         page,
+		PageSize:     pageSize,
+		SortBy:       c.Query("sort_by", "created_at"),
+		SortOrder:    c.Query("sort_order", "desc"),
+	}
+	if authorIDs := c.Query("author_ids"); authorIDs != "" {
+		req.AuthorIDs = strings.Split(authorIDs, ",")
+	}
+	if err := parseDateRange(c, &req); err != nil {
+		return response.BadRequest(c, "invalid_date", err.Error())
+	}
+
+	resp, err := h.commentUsecase.ListComments(c.Context(), req, userID, true)
+	if err != nil {
+		return response.BadRequest(c, "list_failed", err.Error())
+	}
+
+	return response.OK(c, resp)
+}
+
 // GetPendingComments gets pending comments for moderation
 // @Summary Get pending comments for moderation
 // @Tags admin
@@ -39,10 +100,90 @@ func (h *AdminHandler) GetPendingComments(c *fiber.Ctx) error {
 		return response.InternalError(c, err.Error())
 	}
 
-	return response.OK(c, fiber.Map{
-		"comments": comments,
-		"total":    total,
-	})
+	return response.OK(c, pageResponse("comments", comments, total, page, pageSize))
+}
+
+// GetStatusCounts gets a resource's comment counts by status
+// @Summary Get comment counts by status for a resource
+// @Tags admin
+// @Produce json
+// @Param resourceType query string true "Resource type"
+// @Param resourceId query string true "Resource ID"
+// @Success 200 {object} models.StatusCounts
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments/status-counts [get]
+func (h *AdminHandler) GetStatusCounts(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	counts, err := h.commentUsecase.GetStatusCounts(c.Context(), tenantID, c.Query("resourceType"), c.Query("resourceId"))
+	if err != nil {
+		return response.BadRequest(c, "status_counts_failed", err.Error())
+	}
+
+	return response.OK(c, counts)
+}
+
+// GetModerationHistory gets a comment's moderation decision trail
+// @Summary Get a comment's moderation history
+// @Tags admin
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {array} models.ModerationTransition
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/moderation-history [get]
+func (h *AdminHandler) GetModerationHistory(c *fiber.Ctx) error {
+	history, err := h.commentUsecase.GetModerationHistory(c.Context(), c.Params("id"))
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, "Comment not found")
+		}
+		return response.BadRequest(c, "moderation_history_failed", err.Error())
+	}
+
+	return response.OK(c, history)
+}
+
+// GetAuthorSummary gets an author's moderation record
+// @Summary Get an author's comment and report summary
+// @Tags admin
+// @Produce json
+// @Param id path string true "Author ID"
+// @Param resourceType query string false "Resource type"
+// @Success 200 {object} models.AuthorSummary
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/authors/{id}/summary [get]
+func (h *AdminHandler) GetAuthorSummary(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	summary, err := h.commentUsecase.GetAuthorSummary(c.Context(), tenantID, c.Params("id"), c.Query("resourceType"))
+	if err != nil {
+		return response.BadRequest(c, "author_summary_failed", err.Error())
+	}
+
+	return response.OK(c, summary)
+}
+
+// GetComment gets a comment including admin-only fields
+// @Summary Get a comment with moderation details
+// @Tags admin
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {object} models.AdminComment
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/comments/{id} [get]
+func (h *AdminHandler) GetComment(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	comment, err := h.commentUsecase.GetCommentForAdmin(c.Context(), id)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, "Comment not found")
+		}
+		return response.BadRequest(c, "get_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
 }
 
 // ModerateComment approves or rejects a comment
@@ -69,7 +210,7 @@ func (h *AdminHandler) ModerateComment(c *fiber.Ctx) error {
 		return response.BadRequest(c, "invalid_status", "Status must be 'approved', 'rejected', or 'spam'")
 	}
 
-	comment, err := h.commentUsecase.ModerateComment(c.Context(), id, req, moderatorID)
+	comment, err := h.commentUsecase.ModerateComment(auditContext(c), id, req, moderatorID)
 	if err != nil {
 		return response.BadRequest(c, "moderate_failed", err.Error())
 	}
@@ -104,6 +245,353 @@ func (h *AdminHandler) PinComment(c *fiber.Ctx) error {
 	return response.OK(c, comment)
 }
 
+// LockReactions freezes or unfreezes reactions on a comment
+// @Summary Lock or unlock reactions on a comment
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.LockReactionsRequest true "Lock data"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/lock-reactions [post]
+func (h *AdminHandler) LockReactions(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.LockReactionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	comment, err := h.commentUsecase.LockReactions(c.Context(), id, req.IsLocked)
+	if err != nil {
+		return response.BadRequest(c, "lock_reactions_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
+}
+
+// SetSortWeight sets a comment's manual sort weight
+// @Summary Set a comment's manual sort weight
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.SortWeightRequest true "Sort weight data"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/sort-weight [post]
+func (h *AdminHandler) SetSortWeight(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.SortWeightRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	comment, err := h.commentUsecase.SetSortWeight(c.Context(), id, req.SortWeight)
+	if err != nil {
+		return response.BadRequest(c, "sort_weight_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
+}
+
+// AddLabels adds moderation labels to a comment
+// @Summary Add moderation labels to a comment
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.LabelsRequest true "Labels to add"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/labels [post]
+func (h *AdminHandler) AddLabels(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.LabelsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	comment, err := h.commentUsecase.AddLabels(c.Context(), id, req.Labels)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, "Comment not found")
+		}
+		return response.BadRequest(c, "add_labels_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
+}
+
+// RemoveLabels removes moderation labels from a comment
+// @Summary Remove moderation labels from a comment
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.LabelsRequest true "Labels to remove"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/labels [delete]
+func (h *AdminHandler) RemoveLabels(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.LabelsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	comment, err := h.commentUsecase.RemoveLabels(c.Context(), id, req.Labels)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, "Comment not found")
+		}
+		return response.BadRequest(c, "remove_labels_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
+}
+
+// RestoreComment restores a soft-deleted comment
+// @Summary Restore a deleted comment
+// @Tags admin
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/restore [post]
+func (h *AdminHandler) RestoreComment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	adminID, _ := c.Locals("user_id").(string)
+
+	comment, err := h.commentUsecase.RestoreComment(auditContext(c), id, adminID)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, "Comment not found")
+		}
+		return response.BadRequest(c, "restore_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
+}
+
+// RecountResource rebuilds a resource's comment counter
+// @Summary Rebuild a resource's comment counter
+// @Description Recounts the resource's live comments and overwrites the counter used for stats and list totals
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.RecountResourceRequest true "Resource to recount"
+// @Success 200 {object} models.ResourceCounter
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments/recount [post]
+func (h *AdminHandler) RecountResource(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	var req models.RecountResourceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	counter, err := h.commentUsecase.RecountResource(c.Context(), tenantID, req)
+	if err != nil {
+		return response.BadRequest(c, "recount_failed", err.Error())
+	}
+
+	return response.OK(c, counter)
+}
+
+// MergeComments merges one comment thread into another
+// @Summary Merge a duplicate comment thread into another
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.MergeCommentsRequest true "Merge data"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments/merge [post]
+func (h *AdminHandler) MergeComments(c *fiber.Ctx) error {
+	moderatorID, _ := c.Locals("user_id").(string)
+
+	var req models.MergeCommentsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	if req.SourceID == "" || req.TargetID == "" {
+		return response.BadRequest(c, "invalid_request", "Both sourceId and targetId are required")
+	}
+
+	comment, err := h.commentUsecase.MergeThreads(c.Context(), req, moderatorID)
+	if err != nil {
+		return response.BadRequest(c, "merge_failed", err.Error())
+	}
+
+	return response.OK(c, comment)
+}
+
+// ExportComments streams comments as newline-delimited JSON
+// @Summary Export comments
+// @Description Oldest first, up to limit comments (EXPORT_MAX_COMMENTS at most); pass the last exported ID as afterId for the next part
+// @Tags admin
+// @Produce json
+// @Param resource_type query string false "Resource type"
+// @Param resource_id query string false "Resource ID"
+// @Param status query string false "Status filter"
+// @Param afterId query string false "ID of the last comment of the previous export call"
+// @Param limit query int false "Maximum number of comments"
+// @Success 200 {array} models.ExportedComment
+// @Router /api/v1/admin/comments/export [get]
+func (h *AdminHandler) ExportComments(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	req := models.ExportCommentsRequest{
+		TenantID:     tenantID,
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		Status:       models.CommentStatus(c.Query("status")),
+		AfterID:      c.Query("afterId"),
+		Limit:        c.QueryInt("limit"),
+	}
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="comments.ndjson"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, stream, cancel := streamContext(w)
+		defer cancel()
+
+		if err := h.commentUsecase.ExportComments(ctx, req, stream); err != nil {
+			log.Printf("Failed to export comments: %v", err)
+		}
+	})
+
+	return nil
+}
+
+// StreamComments streams every comment matching a listing as newline-delimited JSON
+// @Summary Stream comments without pagination
+// @Description Same filters as the admin listing, in the standard comment shape, up to limit comments (EXPORT_STREAM_LIST_MAX at most)
+// @Tags admin
+// @Produce json
+// @Param resource_type query string false "Resource type"
+// @Param resource_id query string false "Resource ID"
+// @Param status query string false "Status filter"
+// @Param author_id query string false "Author ID"
+// @Param author_ids query string false "Comma-separated author IDs (at most 100)"
+// @Param label query string false "Only comments with this moderation label"
+// @Param view query string false "Set to 'flat' to include replies"
+// @Param created_after query string false "Only comments created at or after this RFC3339 time"
+// @Param created_before query string false "Only comments created before this RFC3339 time"
+// @Param sort_by query string false "Sort field: created_at, like_count, reply_count, helpful_count or total_reactions"
+// @Param sort_order query string false "Sort order"
+// @Param limit query int false "Maximum number of comments"
+// @Success 200 {array} models.Comment
+// @Router /api/v1/admin/comments/stream-list [get]
+func (h *AdminHandler) StreamComments(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	limit := c.QueryInt("limit")
+
+	req := models.ListCommentsRequest{
+		TenantID:     tenantID,
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		Status:       models.CommentStatus(c.Query("status")),
+		AuthorID:     c.Query("author_id"),
+		Label:        c.Query("label"),
+		View:         c.Query("view"),
+		SortBy:       c.Query("sort_by", "created_at"),
+		SortOrder:    c.Query("sort_order", "desc"),
+	}
+	if authorIDs := c.Query("author_ids"); authorIDs != "" {
+		req.AuthorIDs = strings.Split(authorIDs, ",")
+	}
+	if err := parseDateRange(c, &req); err != nil {
+		return response.BadRequest(c, "invalid_date", err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, stream, cancel := streamContext(w)
+		defer cancel()
+
+		written, err := h.commentUsecase.StreamComments(ctx, req, limit, stream)
+		if err != nil {
+			log.Printf("Comment stream stopped after %d comments: %v", written, err)
+		}
+	})
+
+	return nil
+}
+
+// streamContext returns the context and writer for a body stream writer. The
+// stream writer runs after the handler returns, so it can't use the request
+// context; this one is cancelled as soon as a write or flush fails, which
+// means the client disconnected, stopping the stream and its database cursor.
+func streamContext(w *bufio.Writer) (context.Context, *disconnectWriter, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return ctx, &disconnectWriter{w: w, cancel: cancel}, cancel
+}
+
+// disconnectWriter cancels its stream's context once writing to the client fails
+type disconnectWriter struct {
+	w      *bufio.Writer
+	cancel context.CancelFunc
+}
+
+func (d *disconnectWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	if err != nil {
+		d.cancel()
+	}
+	return n, err
+}
+
+// Flush pushes buffered output to the client
+func (d *disconnectWriter) Flush() error {
+	err := d.w.Flush()
+	if err != nil {
+		d.cancel()
+	}
+	return err
+}
+
+// GetCommentReports lists the reports filed against a comment
+// @Summary Get a comment's reports
+// @Tags admin
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param sort_order query string false "Sort order by report time (asc, desc)"
+// @Success 200 {object} models.CommentReportsResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/comments/{id}/reports [get]
+func (h *AdminHandler) GetCommentReports(c *fiber.Ctx) error {
+	id := c.Params("id")
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size", "20"))
+
+	// Admin routes are guarded by the auth middleware, so reporters are always shown here
+	resp, err := h.reportUsecase.GetCommentReports(c.Context(), id, page, pageSize, c.Query("sort_order", "desc"), true)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, "get_reports_failed", err.Error())
+	}
+
+	return response.OK(c, resp)
+}
+
 // HardDelete permanently deletes a comment
 // @Summary Permanently delete a comment
 // @Tags admin
@@ -124,6 +612,45 @@ func (h *AdminHandler) HardDelete(c *fiber.Ctx) error {
 	return response.NoContent(c)
 }
 
+// ReopenReport reopens a reviewed or dismissed report
+// @Summary Reopen a resolved report
+// @Description Sets the report back to pending, audits the reopen and alerts moderators; requeueComment also sends an approved comment back to the queue
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Report ID"
+// @Param request body models.ReopenReportRequest false "Reopen options"
+// @Success 200 {object} models.Report
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/admin/reports/{id}/reopen [post]
+func (h *AdminHandler) ReopenReport(c *fiber.Ctx) error {
+	id := c.Params("id")
+	moderatorID, _ := c.Locals("user_id").(string)
+
+	var req models.ReopenReportRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.BadRequest(c, "invalid_request", "Invalid request body")
+		}
+	}
+
+	report, err := h.reportUsecase.ReopenReport(c.Context(), id, moderatorID, req.RequeueComment)
+	if err != nil {
+		switch err.Error() {
+		case "report not found":
+			return response.NotFound(c, err.Error())
+		case "invalid report ID":
+			return response.BadRequest(c, "invalid_request", err.Error())
+		case "only reviewed or dismissed reports can be reopened":
+			return response.BadRequest(c, "report_not_resolved", err.Error())
+		}
+		return response.InternalError(c, err.Error())
+	}
+
+	return response.OK(c, report)
+}
+
 // BulkModerate moderates multiple comments at once
 // @Summary Bulk moderate comments
 // @Tags admin
@@ -145,23 +672,44 @@ func (h *AdminHandler) BulkModerate(c *fiber.Ctx) error {
 		return response.BadRequest(c, "invalid_request", "No comment IDs provided")
 	}
 
-	successCount := 0
-	failedIDs := []string{}
+	failedIDs := h.commentUsecase.BulkModerateComments(auditContext(c), req.CommentIDs, models.ModerateCommentRequest{
+		Status:          req.Status,
+		RejectionReason: req.RejectionReason,
+	}, moderatorID)
 
-	for _, commentID := range req.CommentIDs {
-		_, err := h.commentUsecase.ModerateComment(c.Context(), commentID, models.ModerateCommentRequest{
-			Status:          req.Status,
-			RejectionReason: req.RejectionReason,
-		}, moderatorID)
-		if err != nil {
-			failedIDs = append(failedIDs, commentID)
-		} else {
-			successCount++
-		}
+	return response.OK(c, BulkModerateResponse{
+		SuccessCount: len(req.CommentIDs) - len(failedIDs),
+		FailedCount:  len(failedIDs),
+		FailedIDs:    failedIDs,
+	})
+}
+
+// BulkDelete deletes multiple comments at once
+// @Summary Bulk delete comments
+// @Description Soft deletes the comments, or with hard permanently deletes them; comments with live replies can't be hard deleted
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body BulkDeleteRequest true "Bulk delete data"
+// @Success 200 {object} BulkDeleteResponse
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/comments/bulk-delete [post]
+func (h *AdminHandler) BulkDelete(c *fiber.Ctx) error {
+	moderatorID, _ := c.Locals("user_id").(string)
+
+	var req BulkDeleteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
 	}
 
-	return response.OK(c, BulkModerateResponse{
-		SuccessCount: successCount,
+	if len(req.CommentIDs) == 0 {
+		return response.BadRequest(c, "invalid_request", "No comment IDs provided")
+	}
+
+	failedIDs := h.commentUsecase.BulkDeleteComments(c.Context(), req.CommentIDs, req.Hard, moderatorID)
+
+	return response.OK(c, BulkDeleteResponse{
+		SuccessCount: len(req.CommentIDs) - len(failedIDs),
 		FailedCount:  len(failedIDs),
 		FailedIDs:    failedIDs,
 	})
@@ -180,3 +728,16 @@ type BulkModerateResponse struct {
 	FailedCount  int      `json:"failed_count"`
 	FailedIDs    []string `json:"failed_ids,omitempty"`
 }
+
+// BulkDeleteRequest represents bulk delete request
+type BulkDeleteRequest struct {
+	CommentIDs []string `json:"comment_ids"`
+	Hard       bool     `json:"hard"`
+}
+
+// BulkDeleteResponse represents bulk delete response
+type BulkDeleteResponse struct {
+	SuccessCount int      `json:"success_count"`
+	FailedCount  int      `json:"failed_count"`
+	FailedIDs    []string `json:"failed_ids,omitempty"`
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/minisource/comment/internal/models"
@@ -27,9 +30,11 @@ func NewCommentHandler(commentUsecase *usecase.CommentUsecase) *CommentHandler {
 // @Accept json
 // @Produce json
 // @Param request body models.CreateCommentRequest true "Comment data"
+// @Param withPosition query bool false "Include approximatePosition, where the comment lands in the default sort"
 // @Success 201 {object} models.Comment
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
 // @Router /api/v1/comments [post]
 func (h *CommentHandler) Create(c *fiber.Ctx) error {
 	var req models.CreateCommentRequest
@@ -42,17 +47,44 @@ func (h *CommentHandler) Create(c *fiber.Ctx) error {
 	userID, _ := c.Locals("user_id").(string)
 	userName, _ := c.Locals("user_name").(string)
 	userEmail, _ := c.Locals("user_email").(string)
+	userAvatar, _ := c.Locals("user_avatar").(string)
+	// Set by the auth middleware for tokens with the "verified" or
+	// "comments:verified" scope; lets settings with autoApproveVerified
+	// skip the moderation queue
+	isVerified, _ := c.Locals("is_verified").(bool)
+	// Admins skip the origin, root comment and pending limits
+	isAdmin, _ := c.Locals("is_admin").(bool)
 
 	// Set tenant from context if not in request
 	if req.TenantID == "" {
 		req.TenantID = tenantID
 	}
 
-	comment, err := h.commentUsecase.CreateComment(c.Context(), req, userID, userName, userEmail, c.IP(), c.Get("User-Agent"))
+	// Checked against the settings' allowed origins
+	req.Origin = c.Get(fiber.HeaderOrigin)
+	if req.Origin == "" {
+		req.Origin = c.Get(fiber.HeaderReferer)
+	}
+
+	comment, err := h.commentUsecase.CreateComment(auditContext(c), req, userID, userName, userEmail, userAvatar, c.IP(), c.Get("User-Agent"), isVerified, isAdmin)
 	if err != nil {
+		if err.Error() == "commenting temporarily disabled" {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error":   "commenting_disabled",
+				"message": err.Error(),
+			})
+		}
+		if err.Error() == "comments are not allowed from this origin" {
+			return response.Forbidden(c, err.Error())
+		}
 		return response.BadRequest(c, "create_failed", err.Error())
 	}
 
+	// Costs an extra count, so only on request
+	if c.QueryBool("withPosition") {
+		h.commentUsecase.AnnotatePosition(c.Context(), comment)
+	}
+
 	return response.Created(c, comment)
 }
 
@@ -61,13 +93,26 @@ func (h *CommentHandler) Create(c *fiber.Ctx) error {
 // @Tags comments
 // @Produce json
 // @Param id path string true "Comment ID"
+// @Param withReplies query int false "Inline up to this many direct replies (max 20)"
 // @Success 200 {object} models.Comment
 // @Failure 404 {object} response.Response
 // @Router /api/v1/comments/{id} [get]
 func (h *CommentHandler) Get(c *fiber.Ctx) error {
 	id := c.Params("id")
+	userID, _ := c.Locals("user_id").(string)
 
-	comment, err := h.commentUsecase.GetComment(c.Context(), id)
+	if withReplies := c.QueryInt("withReplies"); withReplies > 0 {
+		thread, err := h.commentUsecase.GetCommentWithReplies(c.Context(), id, userID, withReplies)
+		if err != nil {
+			if err.Error() == "comment not found" {
+				return response.NotFound(c, "Comment not found")
+			}
+			return response.InternalError(c, err.Error())
+		}
+		return response.OK(c, thread)
+	}
+
+	comment, err := h.commentUsecase.GetComment(c.Context(), id, userID)
 	if err != nil {
 		if err.Error() == "comment not found" {
 			return response.NotFound(c, "Comment not found")
@@ -78,6 +123,36 @@ func (h *CommentHandler) Get(c *fiber.Ctx) error {
 	return response.OK(c, comment)
 }
 
+// GetHistory gets a comment's edit history
+// @Summary Get a comment's edit history
+// @Tags comments
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {array} models.EditRecord
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/comments/{id}/history [get]
+func (h *CommentHandler) GetHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID, _ := c.Locals("user_id").(string)
+	isAdmin, _ := c.Locals("is_admin").(bool)
+
+	history, err := h.commentUsecase.GetEditHistory(c.Context(), id, userID, isAdmin)
+	if err != nil {
+		switch err.Error() {
+		case "comment not found":
+			return response.NotFound(c, "Comment not found")
+		case "you can only view the history of your own comments":
+			return response.Forbidden(c, err.Error())
+		case "invalid comment ID":
+			return response.BadRequest(c, "invalid_id", err.Error())
+		}
+		return response.InternalError(c, err.Error())
+	}
+
+	return response.OK(c, history)
+}
+
 // Update updates a comment
 // @Summary Update a comment
 // @Tags comments
@@ -93,18 +168,22 @@ func (h *CommentHandler) Get(c *fiber.Ctx) error {
 func (h *CommentHandler) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID, _ := c.Locals("user_id").(string)
+	isAdmin, _ := c.Locals("is_admin").(bool)
 
 	var req models.UpdateCommentRequest
 	if err := c.BodyParser(&req); err != nil {
 		return response.BadRequest(c, "invalid_request", "Invalid request body")
 	}
 
-	comment, err := h.commentUsecase.UpdateComment(c.Context(), id, req, userID, false)
+	comment, err := h.commentUsecase.UpdateComment(c.Context(), id, req, userID, isAdmin)
 	if err != nil {
-		if err.Error() == "comment not found" {
+		switch err.Error() {
+		case "comment not found":
 			return response.NotFound(c, err.Error())
-		}
-		if err.Error() == "you can only edit your own comments" {
+		case "you can only edit your own comments",
+			"the edit window for this comment has expired",
+			"comments with replies can no longer be edited",
+			"comments awaiting moderation can't be edited":
 			return response.Forbidden(c, err.Error())
 		}
 		return response.BadRequest(c, "update_failed", err.Error())
@@ -125,14 +204,18 @@ func (h *CommentHandler) Update(c *fiber.Ctx) error {
 func (h *CommentHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID, _ := c.Locals("user_id").(string)
+	isAdmin, _ := c.Locals("is_admin").(bool)
 
-	if err := h.commentUsecase.DeleteComment(c.Context(), id, userID, false); err != nil {
+	if err := h.commentUsecase.DeleteComment(c.Context(), id, userID, isAdmin); err != nil {
 		if err.Error() == "comment not found" {
 			return response.NotFound(c, err.Error())
 		}
 		if err.Error() == "you can only delete your own comments" {
 			return response.Forbidden(c, err.Error())
 		}
+		if err.Error() == "comment has unresolved reports and cannot be deleted yet" {
+			return response.BadRequest(c, "delete_blocked", err.Error())
+		}
 		return response.InternalError(c, err.Error())
 	}
 
@@ -148,9 +231,16 @@ func (h *CommentHandler) Delete(c *fiber.Ctx) error {
 // @Param status query string false "Status filter"
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
-// @Param sort_by query string false "Sort field"
+// @Param sort_by query string false "Sort field: created_at, like_count, reply_count, helpful_count or total_reactions"
 // @Param sort_order query string false "Sort order"
+// @Param view query string false "Set to 'flat' for roots and replies in one chronological stream"
+// @Param track_unread query bool false "Flag comments newer than the caller's last visit as unread"
+// @Param label query string false "Only comments with this moderation label"
+// @Param cursor query string false "'start' or a previous nextCursor, for cursor pagination"
+// @Param created_after query string false "Only comments created at or after this RFC3339 time"
+// @Param created_before query string false "Only comments created before this RFC3339 time"
 // @Success 200 {object} models.ListCommentsResponse
+// @Failure 400 {object} response.Response
 // @Router /api/v1/comments [get]
 func (h *CommentHandler) List(c *fiber.Ctx) error {
 	tenantID, _ := c.Locals("tenant_id").(string)
@@ -169,16 +259,61 @@ func (h *CommentHandler) List(c *fiber.Ctx) error {
 		PageSize:     pageSize,
 		SortBy:       c.Query("sort_by", "created_at"),
 		SortOrder:    c.Query("sort_order", "desc"),
+		View:         c.Query("view"),
+		Label:        c.Query("label"),
+		Cursor:       c.Query("cursor"),
+	}
+
+	if err := parseDateRange(c, &req); err != nil {
+		return response.BadRequest(c, "invalid_date", err.Error())
+	}
+
+	if c.QueryBool("track_unread") && userID != "" {
+		req.UnreadFor = userID
 	}
 
+	// Admins get the reader's view here too; moderation listings live under /admin
 	resp, err := h.commentUsecase.ListComments(c.Context(), req, userID, false)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return response.BadRequest(c, "invalid_cursor", err.Error())
+		}
 		return response.InternalError(c, err.Error())
 	}
 
 	return response.OK(c, resp)
 }
 
+// GetNewer gets comments posted after a known comment
+// @Summary Get root comments newer than a given comment
+// @Tags comments
+// @Produce json
+// @Param resourceType query string true "Resource type"
+// @Param resourceId query string true "Resource ID"
+// @Param afterId query string true "ID of the newest comment the client has"
+// @Param limit query int false "Maximum number of comments (default 20, max 100)"
+// @Success 200 {object} models.NewerCommentsResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/comments/newer [get]
+func (h *CommentHandler) GetNewer(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	comments, err := h.commentUsecase.GetNewerComments(c.Context(), tenantID, c.Query("resourceType"), c.Query("resourceId"), c.Query("afterId"), limit)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, "get_newer_failed", err.Error())
+	}
+
+	return response.OK(c, models.NewerCommentsResponse{
+		Comments: comments,
+		Count:    len(comments),
+	})
+}
+
 // GetReplies gets replies to a comment
 // @Summary Get replies to a comment
 // @Tags comments
@@ -186,21 +321,88 @@ func (h *CommentHandler) List(c *fiber.Ctx) error {
 // @Param id path string true "Parent Comment ID"
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
+// @Param include_total query bool false "Count all replies (default true); skip it when only hasMore is needed"
 // @Success 200 {array} models.Comment
 // @Router /api/v1/comments/{id}/replies [get]
 func (h *CommentHandler) GetReplies(c *fiber.Ctx) error {
 	id := c.Params("id")
+	userID, _ := c.Locals("user_id").(string)
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	pageSize, _ := strconv.Atoi(c.Query("page_size", "20"))
+	includeTotal := c.QueryBool("include_total", true)
 
-	replies, total, err := h.commentUsecase.GetReplies(c.Context(), id, page, pageSize)
+	replies, total, hasMore, err := h.commentUsecase.GetReplies(c.Context(), id, userID, page, pageSize, includeTotal)
 	if err != nil {
 		return response.InternalError(c, err.Error())
 	}
 
+	if !includeTotal {
+		pagination := models.NewPagination(0, page, pageSize)
+		return response.OK(c, fiber.Map{
+			"replies":  replies,
+			"page":     pagination.Page,
+			"pageSize": pagination.PageSize,
+			"hasMore":  hasMore,
+		})
+	}
+
+	result := pageResponse("replies", replies, total, page, pageSize)
+	result["hasMore"] = hasMore
+	return response.OK(c, result)
+}
+
+// GetTree gets a resource's comments as a threaded tree
+// @Summary Get a resource's comments as a tree
+// @Tags comments
+// @Produce json
+// @Param resource_type query string true "Resource type"
+// @Param resource_id query string true "Resource ID"
+// @Param max_depth query int false "Deepest reply level to include, capped at the settings' max reply depth (admins may go up to 50)"
+// @Param include_deleted_placeholders query bool false "Keep deleted comments with live replies as [deleted] placeholders"
+// @Success 200 {array} models.CommentWithReplies
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/tree [get]
+func (h *CommentHandler) GetTree(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	isAdmin, _ := c.Locals("is_admin").(bool)
+	req := models.ListCommentsRequest{
+		TenantID:                   tenantID,
+		ResourceType:               c.Query("resource_type"),
+		ResourceID:                 c.Query("resource_id"),
+		IncludeDeletedPlaceholders: c.QueryBool("include_deleted_placeholders"),
+	}
+	maxDepth := c.QueryInt("max_depth")
+
+	tree, err := h.commentUsecase.GetCommentTree(c.Context(), req, maxDepth, isAdmin)
+	if err != nil {
+		return response.BadRequest(c, "get_tree_failed", err.Error())
+	}
+
+	return response.OK(c, tree)
+}
+
+// GetThread gets a comment's whole thread
+// @Summary Get a comment's whole thread
+// @Tags comments
+// @Produce json
+// @Param id path string true "Comment ID (root or any reply)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} response.Response
+// @Router /api/v1/comments/{id}/thread [get]
+func (h *CommentHandler) GetThread(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	root, replies, err := h.commentUsecase.GetThread(c.Context(), id)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			return response.NotFound(c, err.Error())
+		}
+		return response.BadRequest(c, "get_thread_failed", err.Error())
+	}
+
 	return response.OK(c, fiber.Map{
+		"root":    root,
 		"replies": replies,
-		"total":   total,
 	})
 }
 
@@ -224,10 +426,7 @@ func (h *CommentHandler) Search(c *fiber.Ctx) error {
 		return response.InternalError(c, err.Error())
 	}
 
-	return response.OK(c, fiber.Map{
-		"comments": comments,
-		"total":    total,
-	})
+	return response.OK(c, pageResponse("comments", comments, total, page, pageSize))
 }
 
 // GetStats gets comment statistics
@@ -250,3 +449,77 @@ func (h *CommentHandler) GetStats(c *fiber.Ctx) error {
 
 	return response.OK(c, stats)
 }
+
+// MarkSeen marks all current comments on a resource as seen by the caller
+// @Summary Mark a resource's comments as seen
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param request body models.MarkSeenRequest true "Resource to mark as seen"
+// @Success 200 {object} response.SuccessMessage
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/seen [post]
+func (h *CommentHandler) MarkSeen(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	userID, _ := c.Locals("user_id").(string)
+
+	var req models.MarkSeenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	if err := h.commentUsecase.MarkResourceSeen(c.Context(), tenantID, req, userID); err != nil {
+		return response.BadRequest(c, "mark_seen_failed", err.Error())
+	}
+
+	return response.OKMessage(c, "Comments marked as seen")
+}
+
+// auditContext returns the request context carrying the admin the auth
+// middleware found impersonating the user, so audit entries name the admin
+func auditContext(c *fiber.Ctx) context.Context {
+	impersonatedBy, _ := c.Locals("impersonated_by").(string)
+	return usecase.WithImpersonator(c.Context(), impersonatedBy)
+}
+
+// parseDateRange reads the created_after and created_before RFC3339 query
+// parameters into a listing request. It's checked here as well as in the
+// usecase so streamed listings fail before their response starts.
+func parseDateRange(c *fiber.Ctx, req *models.ListCommentsRequest) error {
+	var err error
+	if req.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		return err
+	}
+	if req.CreatedBefore, err = parseTimeQuery(c, "created_before"); err != nil {
+		return err
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && req.CreatedAfter.After(*req.CreatedBefore) {
+		return fmt.Errorf("createdAfter must not be after createdBefore")
+	}
+	return nil
+}
+
+// parseTimeQuery parses an optional RFC3339 query parameter
+func parseTimeQuery(c *fiber.Ctx, param string) (*time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 time", param)
+	}
+	return &t, nil
+}
+
+// pageResponse wraps a page of items with its pagination metadata
+func pageResponse(key string, items any, total int64, page, pageSize int) fiber.Map {
+	pagination := models.NewPagination(total, page, pageSize)
+	return fiber.Map{
+		key:          items,
+		"total":      pagination.Total,
+		"page":       pagination.Page,
+		"pageSize":   pagination.PageSize,
+		"totalPages": pagination.TotalPages,
+	}
+}
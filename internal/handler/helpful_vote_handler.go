@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/usecase"
+	"github.com/minisource/go-common/response"
+)
+
+// HelpfulVoteHandler handles HTTP requests for helpfulness votes
+type HelpfulVoteHandler struct {
+	voteUsecase *usecase.HelpfulVoteUsecase
+}
+
+// NewHelpfulVoteHandler creates a new helpful vote handler
+func NewHelpfulVoteHandler(voteUsecase *usecase.HelpfulVoteUsecase) *HelpfulVoteHandler {
+	return &HelpfulVoteHandler{
+		voteUsecase: voteUsecase,
+	}
+}
+
+// Vote adds or changes a helpfulness vote
+// @Summary Vote whether a comment was helpful
+// @Tags votes
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.HelpfulVoteRequest true "Vote data"
+// @Success 200 {object} response.SuccessMessage
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/{id}/helpful [post]
+func (h *HelpfulVoteHandler) Vote(c *fiber.Ctx) error {
+	commentID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	var req models.HelpfulVoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	if req.Type != models.VoteHelpful && req.Type != models.VoteNotHelpful {
+		return response.BadRequest(c, "invalid_vote_type", "Invalid vote type. Valid types: helpful, not_helpful")
+	}
+
+	if err := h.voteUsecase.Vote(c.Context(), commentID, req.Type, userID); err != nil {
+		return response.BadRequest(c, "vote_failed", err.Error())
+	}
+
+	return response.OKMessage(c, "Vote recorded successfully")
+}
+
+// Unvote removes a helpfulness vote
+// @Summary Remove a helpfulness vote from a comment
+// @Tags votes
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/{id}/helpful [delete]
+func (h *HelpfulVoteHandler) Unvote(c *fiber.Ctx) error {
+	commentID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	if err := h.voteUsecase.Unvote(c.Context(), commentID, userID); err != nil {
+		return response.BadRequest(c, "remove_vote_failed", err.Error())
+	}
+
+	return response.NoContent(c)
+}
+
+// GetUserVote gets the current user's helpfulness vote on a comment
+// @Summary Get current user's helpfulness vote on a comment
+// @Tags votes
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {object} UserVoteResponse
+// @Router /api/v1/comments/{id}/helpful/me [get]
+func (h *HelpfulVoteHandler) GetUserVote(c *fiber.Ctx) error {
+	commentID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	vote, err := h.voteUsecase.GetUserVote(c.Context(), commentID, userID)
+	if err != nil {
+		return response.InternalError(c, err.Error())
+	}
+
+	resp := UserVoteResponse{
+		CommentID: commentID,
+		HasVoted:  vote != nil,
+	}
+	if vote != nil {
+		resp.VoteType = string(*vote)
+	}
+
+	return response.OK(c, resp)
+}
+
+// UserVoteResponse represents user helpfulness vote response
+type UserVoteResponse struct {
+	CommentID string `json:"comment_id"`
+	HasVoted  bool   `json:"has_voted"`
+	VoteType  string `json:"vote_type,omitempty"`
+}
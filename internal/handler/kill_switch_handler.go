@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/usecase"
+	"github.com/minisource/go-common/response"
+)
+
+// KillSwitchHandler handles HTTP requests for comment creation kill switches
+type KillSwitchHandler struct {
+	killSwitchUsecase *usecase.KillSwitchUsecase
+}
+
+// NewKillSwitchHandler creates a new kill switch handler
+func NewKillSwitchHandler(killSwitchUsecase *usecase.KillSwitchUsecase) *KillSwitchHandler {
+	return &KillSwitchHandler{
+		killSwitchUsecase: killSwitchUsecase,
+	}
+}
+
+// Get gets the kill switches that apply to the tenant
+// @Summary Get kill switches
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.KillSwitch
+// @Router /api/v1/admin/kill-switch [get]
+func (h *KillSwitchHandler) Get(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	switches, err := h.killSwitchUsecase.GetKillSwitches(c.Context(), tenantID)
+	if err != nil {
+		return response.InternalError(c, err.Error())
+	}
+
+	return response.OK(c, switches)
+}
+
+// SetTenant turns comment creation off or on for the tenant
+// @Summary Set the tenant kill switch
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.KillSwitchRequest true "Switch state"
+// @Success 200 {object} models.KillSwitch
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/kill-switch [put]
+func (h *KillSwitchHandler) SetTenant(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	userID, _ := c.Locals("user_id").(string)
+
+	var req models.KillSwitchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	killSwitch, err := h.killSwitchUsecase.SetTenantKillSwitch(c.Context(), tenantID, req, userID)
+	if err != nil {
+		return response.BadRequest(c, "kill_switch_failed", err.Error())
+	}
+
+	return response.OK(c, killSwitch)
+}
+
+// SetGlobal turns comment creation off or on for every tenant
+// @Summary Set the global kill switch
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.KillSwitchRequest true "Switch state"
+// @Success 200 {object} models.KillSwitch
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/kill-switch/global [put]
+func (h *KillSwitchHandler) SetGlobal(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+
+	var req models.KillSwitchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	killSwitch, err := h.killSwitchUsecase.SetGlobalKillSwitch(c.Context(), req, userID)
+	if err != nil {
+		if err.Error() == "only operators can change the global kill switch" {
+			return response.Forbidden(c, err.Error())
+		}
+		return response.BadRequest(c, "kill_switch_failed", err.Error())
+	}
+
+	return response.OK(c, killSwitch)
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/minisource/comment/internal/models"
 	"github.com/minisource/comment/internal/usecase"
@@ -26,7 +28,7 @@ func NewReactionHandler(reactionUsecase *usecase.ReactionUsecase) *ReactionHandl
 // @Produce json
 // @Param id path string true "Comment ID"
 // @Param request body models.ReactionRequest true "Reaction data"
-// @Success 200 {object} response.SuccessMessage
+// @Success 200 {object} models.ReactionSummary
 // @Failure 400 {object} response.Response
 // @Router /api/v1/comments/{id}/reactions [post]
 func (h *ReactionHandler) AddReaction(c *fiber.Ctx) error {
@@ -43,11 +45,45 @@ func (h *ReactionHandler) AddReaction(c *fiber.Ctx) error {
 		return response.BadRequest(c, "invalid_reaction_type", "Invalid reaction type. Valid types: like, dislike, love, haha, wow, sad, angry")
 	}
 
-	if err := h.reactionUsecase.AddReaction(c.Context(), commentID, req.Type, userID); err != nil {
+	summary, err := h.reactionUsecase.AddReaction(c.Context(), commentID, req.Type, userID)
+	if err != nil {
 		return response.BadRequest(c, "reaction_failed", err.Error())
 	}
 
-	return response.OKMessage(c, "Reaction added successfully")
+	return response.OK(c, summary)
+}
+
+// ToggleReaction toggles a reaction on or off
+// @Summary Toggle a reaction on a comment
+// @Description Sending the reaction the user already has removes it; any other type adds or switches to it
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.ReactionRequest true "Reaction data"
+// @Success 200 {object} models.ReactionSummary
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/{id}/reactions/toggle [post]
+func (h *ReactionHandler) ToggleReaction(c *fiber.Ctx) error {
+	commentID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	var req models.ReactionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	// Validate reaction type
+	if !isValidReactionType(req.Type) {
+		return response.BadRequest(c, "invalid_reaction_type", "Invalid reaction type. Valid types: like, dislike, love, haha, wow, sad, angry")
+	}
+
+	summary, err := h.reactionUsecase.ToggleReaction(c.Context(), commentID, req.Type, userID)
+	if err != nil {
+		return response.BadRequest(c, "reaction_failed", err.Error())
+	}
+
+	return response.OK(c, summary)
 }
 
 // RemoveReaction removes a reaction
@@ -55,18 +91,57 @@ func (h *ReactionHandler) AddReaction(c *fiber.Ctx) error {
 // @Tags reactions
 // @Produce json
 // @Param id path string true "Comment ID"
-// @Success 204 "No Content"
+// @Success 200 {object} models.ReactionSummary
 // @Failure 400 {object} response.Response
 // @Router /api/v1/comments/{id}/reactions [delete]
 func (h *ReactionHandler) RemoveReaction(c *fiber.Ctx) error {
 	commentID := c.Params("id")
 	userID := c.Locals("user_id").(string)
 
-	if err := h.reactionUsecase.RemoveReaction(c.Context(), commentID, userID); err != nil {
+	summary, err := h.reactionUsecase.RemoveReaction(c.Context(), commentID, userID)
+	if err != nil {
 		return response.BadRequest(c, "remove_reaction_failed", err.Error())
 	}
 
-	return response.NoContent(c)
+	return response.OK(c, summary)
+}
+
+// GetUserReactions gets the current user's reactions to a page of comments
+// @Summary Get current user's reactions to several comments
+// @Description Returns a map of comment ID to reaction type, null where the user hasn't reacted
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param request body UserReactionsRequest true "Comment IDs"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/reactions/me [post]
+func (h *ReactionHandler) GetUserReactions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req UserReactionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	if len(req.CommentIDs) == 0 {
+		return response.BadRequest(c, "invalid_request", "No comment IDs provided")
+	}
+	if len(req.CommentIDs) > usecase.MaxReactionLookupIDs {
+		return response.BadRequest(c, "too_many_ids", fmt.Sprintf("At most %d comment IDs can be looked up at once", usecase.MaxReactionLookupIDs))
+	}
+
+	reactions, err := h.reactionUsecase.GetUserReactionsForComments(c.Context(), req.CommentIDs, userID)
+	if err != nil {
+		return response.InternalError(c, err.Error())
+	}
+
+	return response.OK(c, reactions)
+}
+
+// UserReactionsRequest represents a batch reaction lookup
+type UserReactionsRequest struct {
+	CommentIDs []string `json:"commentIds"`
 }
 
 // GetUserReaction gets the current user's reaction to a comment
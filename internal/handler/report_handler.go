@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/usecase"
+	"github.com/minisource/go-common/response"
+)
+
+// ReportHandler handles HTTP requests for user reports
+type ReportHandler struct {
+	reportUsecase *usecase.ReportUsecase
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportUsecase *usecase.ReportUsecase) *ReportHandler {
+	return &ReportHandler{
+		reportUsecase: reportUsecase,
+	}
+}
+
+// Create reports a comment
+// @Summary Report a comment
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body models.ReportRequest true "Report data"
+// @Success 201 {object} models.Report
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /api/v1/comments/{id}/report [post]
+func (h *ReportHandler) Create(c *fiber.Ctx) error {
+	commentID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	var req models.ReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	if len(req.Description) > 500 {
+		return response.BadRequest(c, "invalid_description", "Description must be at most 500 characters")
+	}
+
+	report, err := h.reportUsecase.ReportComment(c.Context(), commentID, userID, req)
+	if err != nil {
+		switch err.Error() {
+		case "comment not found":
+			return response.NotFound(c, "Comment not found")
+		case "you have already reported this comment":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   "already_reported",
+				"message": err.Error(),
+			})
+		case "invalid report reason":
+			return response.BadRequest(c, "invalid_reason", "Invalid report reason. Valid reasons: spam, inappropriate, harassment, hate_speech, misinformation, other")
+		}
+		return response.BadRequest(c, "report_failed", err.Error())
+	}
+
+	return response.Created(c, report)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/usecase"
+	"github.com/minisource/go-common/response"
+)
+
+// SettingsHandler handles HTTP requests for tenant settings
+type SettingsHandler struct {
+	settingsUsecase *usecase.SettingsUsecase
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsUsecase *usecase.SettingsUsecase) *SettingsHandler {
+	return &SettingsHandler{
+		settingsUsecase: settingsUsecase,
+	}
+}
+
+// Get gets the tenant's settings for a resource type
+// @Summary Get settings for a resource type
+// @Tags admin
+// @Produce json
+// @Param resourceType query string true "Resource type"
+// @Success 200 {object} models.CommentSettings
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/settings [get]
+func (h *SettingsHandler) Get(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	settings, err := h.settingsUsecase.GetSettings(c.Context(), tenantID, c.Query("resourceType"))
+	if err != nil {
+		return response.BadRequest(c, "get_settings_failed", err.Error())
+	}
+
+	return response.OK(c, settings)
+}
+
+// GetPublic gets the settings a public comment widget needs for a resource type
+// @Summary Get public widget settings for a resource type
+// @Tags comments
+// @Produce json
+// @Param resourceType query string true "Resource type"
+// @Success 200 {object} models.PublicSettings
+// @Failure 400 {object} response.Response
+// @Router /api/v1/comments/config [get]
+func (h *SettingsHandler) GetPublic(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	settings, err := h.settingsUsecase.GetPublicSettings(c.Context(), tenantID, c.Query("resourceType"))
+	if err != nil {
+		return response.BadRequest(c, "get_config_failed", err.Error())
+	}
+
+	return response.OK(c, settings)
+}
+
+// Update updates the tenant's settings for a resource type
+// @Summary Update settings for a resource type
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param resourceType query string true "Resource type"
+// @Param request body models.SettingsRequest true "Settings to change"
+// @Success 200 {object} models.CommentSettings
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/settings [put]
+func (h *SettingsHandler) Update(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	var req models.SettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid_request", "Invalid request body")
+	}
+
+	settings, err := h.settingsUsecase.UpdateSettings(c.Context(), tenantID, c.Query("resourceType"), req)
+	if err != nil {
+		return response.BadRequest(c, "update_settings_failed", err.Error())
+	}
+
+	return response.OK(c, settings)
+}
+
+// List lists the tenant's settings for every resource type
+// @Summary List all settings for the tenant
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.CommentSettings
+// @Router /api/v1/admin/settings/all [get]
+func (h *SettingsHandler) List(c *fiber.Ctx) error {
+	tenantID, _ := c.Locals("tenant_id").(string)
+
+	settings, err := h.settingsUsecase.ListSettings(c.Context(), tenantID)
+	if err != nil {
+		return response.InternalError(c, err.Error())
+	}
+
+	return response.OK(c, settings)
+}
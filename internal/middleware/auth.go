@@ -1,17 +1,26 @@
 package middleware
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/minisource/go-sdk/auth"
 )
 
+// HeaderImpersonateUser lets an admin token act as another user
+const HeaderImpersonateUser = "X-Impersonate-User"
+
 // AuthConfig holds auth middleware configuration
 type AuthConfig struct {
 	AuthClient   *auth.Client
 	SkipPaths    []string
 	RequireAdmin []string
+	// PublicReads lists GET route patterns that proceed anonymously when no
+	// Authorization header is sent. A token, when present, is still validated.
+	PublicReads []string
 }
 
 // AuthMiddleware creates an authentication middleware
@@ -27,6 +36,9 @@ func AuthMiddleware(cfg AuthConfig) fiber.Handler {
 
 		// Get authorization header
 		authHeader := c.Get("Authorization")
+		if authHeader == "" && c.Method() == fiber.MethodGet && matchesAnyRoute(path, cfg.PublicReads) {
+			return c.Next()
+		}
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   "unauthorized",
@@ -72,15 +84,103 @@ func AuthMiddleware(cfg AuthConfig) fiber.Handler {
 			}
 		}
 
-		// Set user info in context
-		c.Locals("user_id", result.ClientID)
-		c.Locals("user_name", result.ServiceName)
-		c.Locals("client_id", result.ClientID)
+		// Admins may act on behalf of a user; the real admin is kept for auditing
+		userID, impersonatedBy, err := resolveImpersonation(c.Get(HeaderImpersonateUser), result.ClientID, result.Scopes)
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "forbidden",
+				"message": err.Error(),
+			})
+		}
+
+		setCallerLocals(c, userID, result.ServiceName, tokenPicture(token), result.ClientID, impersonatedBy, result.Scopes)
 
 		return c.Next()
 	}
 }
 
+// setCallerLocals stores who is calling for handlers: user_id, user_name,
+// user_avatar, client_id, is_admin, is_verified and, when impersonating,
+// impersonated_by
+func setCallerLocals(c *fiber.Ctx, userID, userName, userAvatar, clientID, impersonatedBy string, scopes []string) {
+	c.Locals("user_id", userID)
+	c.Locals("user_name", userName)
+	c.Locals("client_id", clientID)
+	c.Locals("is_admin", hasAdminScope(scopes))
+	// Verification and the avatar belong to the token holder, not an
+	// impersonated user
+	c.Locals("is_verified", impersonatedBy == "" && hasVerifiedScope(scopes))
+	if impersonatedBy == "" {
+		c.Locals("user_avatar", userAvatar)
+	} else {
+		c.Locals("impersonated_by", impersonatedBy)
+	}
+}
+
+// tokenPicture reads the OIDC "picture" claim, the caller's avatar URL, from
+// a JWT the auth service has already validated. Opaque tokens carry none.
+func tokenPicture(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		Picture string `json:"picture"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Picture
+}
+
+// matchesAnyRoute reports whether a request path matches one of the route
+// patterns, where ":name" segments match any single segment
+func matchesAnyRoute(path string, patterns []string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, pattern := range patterns {
+		if routeMatches(segments, strings.Split(strings.Trim(strings.TrimSpace(pattern), "/"), "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func routeMatches(segments, pattern []string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, part := range pattern {
+		if strings.HasPrefix(part, ":") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveImpersonation returns the user a request acts as and, when an admin
+// impersonates someone, the admin's own ID. Non-admins can't impersonate.
+func resolveImpersonation(header, clientID string, scopes []string) (userID, impersonatedBy string, err error) {
+	target := strings.TrimSpace(header)
+	if target == "" || target == clientID {
+		return clientID, "", nil
+	}
+	if !hasAdminScope(scopes) {
+		return "", "", errors.New("impersonation requires admin access")
+	}
+	return target, clientID, nil
+}
+
 // hasAdminScope checks if user has admin scope
 func hasAdminScope(scopes []string) bool {
 	for _, scope := range scopes {
@@ -90,3 +190,13 @@ func hasAdminScope(scopes []string) bool {
 	}
 	return false
 }
+
+// hasVerifiedScope checks if the auth service marked the user as verified
+func hasVerifiedScope(scopes []string) bool {
+	for _, scope := range scopes {
+		if scope == "verified" || scope == "comments:verified" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesAnyRoute(t *testing.T) {
+	patterns := []string{"/api/v1/comments", "/api/v1/comments/:id", "/api/v1/comments/:id/replies"}
+
+	assert.True(t, matchesAnyRoute("/api/v1/comments", patterns))
+	assert.True(t, matchesAnyRoute("/api/v1/comments/", patterns))
+	assert.True(t, matchesAnyRoute("/api/v1/comments/abc123", patterns))
+	assert.True(t, matchesAnyRoute("/api/v1/comments/abc123/replies", patterns))
+	assert.False(t, matchesAnyRoute("/api/v1/comments/abc123/reactions/me", patterns))
+	assert.False(t, matchesAnyRoute("/api/v1/admin/comments/pending", patterns))
+	assert.False(t, matchesAnyRoute("/api/v1/comments/abc123", nil))
+}
+
+func TestAuthMiddlewarePublicReads(t *testing.T) {
+	app := fiber.New()
+	app.Use(AuthMiddleware(AuthConfig{
+		PublicReads: []string{"/api/v1/comments/:id"},
+	}))
+
+	handler := func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(string)
+		return c.SendString("user=" + userID)
+	}
+	app.Get("/api/v1/comments/:id", handler)
+	app.Put("/api/v1/comments/:id", handler)
+	app.Get("/api/v1/comments/:id/reactions/me", handler)
+
+	t.Run("Anonymous Public Read", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/comments/abc123", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Anonymous Write", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPut, "/api/v1/comments/abc123", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Anonymous Private Read", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/comments/abc123/reactions/me", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Token Still Checked On Public Read", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/comments/abc123", nil)
+		req.Header.Set("Authorization", "Basic abc")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestResolveImpersonation(t *testing.T) {
+	userID, by, err := resolveImpersonation("", "alice", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", userID)
+	assert.Empty(t, by)
+
+	userID, by, err = resolveImpersonation("alice", "support-admin", []string{"admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", userID)
+	assert.Equal(t, "support-admin", by)
+
+	_, _, err = resolveImpersonation("alice", "mallory", []string{"comments:read"})
+	assert.EqualError(t, err, "impersonation requires admin access")
+}
+
+func TestHasVerifiedScope(t *testing.T) {
+	assert.True(t, hasVerifiedScope([]string{"comments:read", "verified"}))
+	assert.True(t, hasVerifiedScope([]string{"comments:verified"}))
+	assert.False(t, hasVerifiedScope([]string{"comments:read"}))
+	assert.False(t, hasVerifiedScope(nil))
+}
+
+func TestSetCallerLocals(t *testing.T) {
+	tests := []struct {
+		name           string
+		impersonatedBy string
+		scopes         []string
+		wantAdmin      bool
+		wantVerified   bool
+		wantAvatar     any
+	}{
+		{"Regular User", "", []string{"comments:read"}, false, false, "https://cdn.example.com/alice.png"},
+		{"Verified User", "", []string{"comments:read", "verified"}, false, true, "https://cdn.example.com/alice.png"},
+		{"Admin", "", []string{"admin"}, true, false, "https://cdn.example.com/alice.png"},
+		{"Admin Impersonating", "support-admin", []string{"admin", "verified"}, true, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var locals fiber.Map
+			app.Get("/", func(c *fiber.Ctx) error {
+				setCallerLocals(c, "alice", "Alice", "https://cdn.example.com/alice.png", "client-1", tt.impersonatedBy, tt.scopes)
+				locals = fiber.Map{
+					"user_id":         c.Locals("user_id"),
+					"user_name":       c.Locals("user_name"),
+					"user_avatar":     c.Locals("user_avatar"),
+					"client_id":       c.Locals("client_id"),
+					"is_admin":        c.Locals("is_admin"),
+					"is_verified":     c.Locals("is_verified"),
+					"impersonated_by": c.Locals("impersonated_by"),
+				}
+				return nil
+			})
+
+			_, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, "alice", locals["user_id"])
+			assert.Equal(t, "Alice", locals["user_name"])
+			assert.Equal(t, "client-1", locals["client_id"])
+			assert.Equal(t, tt.wantAdmin, locals["is_admin"])
+			assert.Equal(t, tt.wantVerified, locals["is_verified"])
+			assert.Equal(t, tt.wantAvatar, locals["user_avatar"])
+			if tt.impersonatedBy != "" {
+				assert.Equal(t, tt.impersonatedBy, locals["impersonated_by"])
+			} else {
+				assert.Nil(t, locals["impersonated_by"])
+			}
+		})
+	}
+}
+
+func TestTokenPicture(t *testing.T) {
+	jwt := func(claims string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2lnbmF0dXJl"
+	}
+
+	assert.Equal(t, "https://cdn.example.com/alice.png", tokenPicture(jwt(`{"sub":"alice","picture":"https://cdn.example.com/alice.png"}`)))
+	assert.Empty(t, tokenPicture(jwt(`{"sub":"alice"}`)), "no picture claim")
+	assert.Empty(t, tokenPicture(jwt(`not json`)))
+	assert.Empty(t, tokenPicture("opaque-access-token"))
+	assert.Empty(t, tokenPicture("a.!!!.c"))
+}
+
+func TestCallerLocalsReachHandler(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		setCallerLocals(c, "alice", "Alice", "https://cdn.example.com/alice.png", "client-1", "", []string{"comments:write"})
+		return c.Next()
+	})
+	// Read the way CommentHandler.Create reads them
+	app.Post("/api/v1/comments", func(c *fiber.Ctx) error {
+		userName, _ := c.Locals("user_name").(string)
+		userAvatar, _ := c.Locals("user_avatar").(string)
+		return c.SendString(userName + " " + userAvatar)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/comments", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice https://cdn.example.com/alice.png", string(body))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CompactJSONMiddleware drops false, zero, empty and null fields from JSON
+// responses when the request opts in with ?compact=true. Clients that expect
+// every field keep getting them by default.
+func CompactJSONMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !c.QueryBool("compact") {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		if resp.IsBodyStream() || !isJSONContentType(string(resp.Header.ContentType())) {
+			return nil
+		}
+
+		compacted, err := compactJSON(resp.Body())
+		if err != nil {
+			// Leave a body we can't parse as it is
+			return nil
+		}
+		resp.SetBodyRaw(compacted)
+		return nil
+	}
+}
+
+// compactJSON re-encodes a JSON document without its empty values
+func compactJSON(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	value, _ := compactValue(doc)
+	return json.Marshal(value)
+}
+
+// compactValue strips empty values from a decoded JSON value, reporting
+// whether anything is left
+func compactValue(value any) (any, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case bool:
+		return v, v
+	case string:
+		return v, v != ""
+	case json.Number:
+		f, err := v.Float64()
+		return v, err != nil || f != 0
+	case []any:
+		kept := make([]any, 0, len(v))
+		for _, item := range v {
+			// Array positions can matter, so only empty arrays are dropped
+			compacted, _ := compactValue(item)
+			kept = append(kept, compacted)
+		}
+		return kept, len(kept) > 0
+	case map[string]any:
+		for key, item := range v {
+			compacted, ok := compactValue(item)
+			if !ok {
+				delete(v, key)
+				continue
+			}
+			v[key] = compacted
+		}
+		return v, len(v) > 0
+	default:
+		return v, true
+	}
+}
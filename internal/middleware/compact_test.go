@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactJSONMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(CompactJSONMiddleware())
+	app.Get("/comments", func(c *fiber.Ctx) error {
+		comments := make([]fiber.Map, 0, 50)
+		for i := 0; i < 50; i++ {
+			comments = append(comments, fiber.Map{
+				"id":          "65a1f0c2e4b0a1b2c3d4e5f6",
+				"content":     "Nice",
+				"likeCount":   i % 2,
+				"isPinned":    false,
+				"isEdited":    false,
+				"replyCount":  0,
+				"attachments": []any{},
+				"rootId":      nil,
+				"metadata":    fiber.Map{},
+			})
+		}
+		return c.JSON(fiber.Map{"comments": comments, "total": 50, "page": 1})
+	})
+
+	get := func(target string) string {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	full := get("/comments")
+	compact := get("/comments?compact=true")
+
+	assert.Less(t, len(compact), len(full)/2)
+	assert.Contains(t, full, `"isPinned":false`)
+	assert.NotContains(t, compact, `"isPinned"`)
+	assert.NotContains(t, compact, `"attachments"`)
+	assert.NotContains(t, compact, `"metadata"`)
+	assert.Contains(t, compact, `"likeCount":1`)
+	assert.Contains(t, compact, `"total":50`)
+}
+
+func TestCompactJSON(t *testing.T) {
+	body, err := compactJSON([]byte(`{"a":0,"b":false,"c":"","d":[],"e":{},"f":null,"g":[0,1],"h":{"i":true,"j":0},"k":12345678901234567890}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"g":[0,1],"h":{"i":true},"k":12345678901234567890}`, string(body))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireJSONMiddleware rejects write requests whose body isn't JSON with a
+// 415 before BodyParser gets a chance to try form or XML decoding. Requests
+// without a body pass through so body-less actions keep working.
+func RequireJSONMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch:
+		default:
+			return c.Next()
+		}
+
+		if len(c.Body()) == 0 || isJSONContentType(c.Get(fiber.HeaderContentType)) {
+			return c.Next()
+		}
+
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error":   "unsupported_media_type",
+			"message": "Content-Type must be application/json",
+		})
+	}
+}
+
+// isJSONContentType reports whether a Content-Type header is application/json,
+// ignoring parameters such as charset
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), fiber.MIMEApplicationJSON)
+}
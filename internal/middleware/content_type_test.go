@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireJSONMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequireJSONMiddleware())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Post("/comments", ok)
+	app.Get("/comments", ok)
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		contentType string
+		want        int
+	}{
+		{"JSON", http.MethodPost, `{"content":"hi"}`, "application/json", http.StatusOK},
+		{"JSON With Charset", http.MethodPost, `{"content":"hi"}`, "application/json; charset=utf-8", http.StatusOK},
+		{"Form", http.MethodPost, "content=hi", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"XML", http.MethodPost, "<content>hi</content>", "application/xml", http.StatusUnsupportedMediaType},
+		{"Missing Content Type", http.MethodPost, `{"content":"hi"}`, "", http.StatusUnsupportedMediaType},
+		{"Empty Body", http.MethodPost, "", "", http.StatusOK},
+		{"Read", http.MethodGet, "", "text/plain", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/comments", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
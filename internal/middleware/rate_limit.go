@@ -1,10 +1,20 @@
 package middleware
 
 import (
+	"context"
+	"log"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Rate limit backends, usable in MODERATION_RATE_LIMIT_BACKEND
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
 )
 
 // RateLimitConfig holds rate limit configuration
@@ -15,67 +25,140 @@ type RateLimitConfig struct {
 	Window time.Duration
 	// Key function to identify requesters
 	KeyFunc func(c *fiber.Ctx) string
+	// Backend stores counters in process memory (default) or in Redis,
+	// which shares limits across replicas
+	Backend string
+	// Redis client for the redis backend
+	Redis *redis.Client
+}
+
+// rateLimitStore counts a requester's hits in the current window
+type rateLimitStore interface {
+	hit(ctx context.Context, key string) (count int, reset time.Time, err error)
 }
 
 // RateLimitMiddleware creates a rate limiting middleware
 func RateLimitMiddleware(cfg RateLimitConfig) fiber.Handler {
-	type visitor struct {
-		count    int
-		lastSeen time.Time
+	var store rateLimitStore
+	if cfg.Backend == RateLimitBackendRedis && cfg.Redis != nil {
+		store = &redisRateLimitStore{client: cfg.Redis, window: cfg.Window}
+	} else {
+		store = newMemoryRateLimitStore(cfg.Max, cfg.Window)
+	}
+
+	return func(c *fiber.Ctx) error {
+		count, reset, err := store.hit(c.Context(), cfg.KeyFunc(c))
+		if err != nil {
+			// Don't turn a Redis outage into an outage of comment creation
+			log.Printf("Rate limit check failed: %v", err)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(max(cfg.Max-count, 0)))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+		if count > cfg.Max {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   "rate_limit_exceeded",
+				"message": "Too many requests, please try again later",
+			})
+		}
+
+		return c.Next()
 	}
+}
+
+// memoryRateLimitStore counts hits in a per-process map
+type memoryRateLimitStore struct {
+	max      int
+	window   time.Duration
+	mu       sync.Mutex
+	visitors map[string]*rateLimitVisitor
+}
 
-	var (
-		visitors = make(map[string]*visitor)
-		mu       sync.Mutex
-	)
+type rateLimitVisitor struct {
+	count    int
+	lastSeen time.Time
+}
+
+func newMemoryRateLimitStore(limit int, window time.Duration) *memoryRateLimitStore {
+	s := &memoryRateLimitStore{
+		max:      limit,
+		window:   window,
+		visitors: make(map[string]*rateLimitVisitor),
+	}
 
 	// Cleanup goroutine
 	go func() {
 		for {
-			time.Sleep(cfg.Window)
-			mu.Lock()
-			for key, v := range visitors {
-				if time.Since(v.lastSeen) > cfg.Window {
-					delete(visitors, key)
+			time.Sleep(window)
+			s.mu.Lock()
+			for key, v := range s.visitors {
+				if time.Since(v.lastSeen) > window {
+					delete(s.visitors, key)
 				}
 			}
-			mu.Unlock()
+			s.mu.Unlock()
 		}
 	}()
 
-	return func(c *fiber.Ctx) error {
-		key := cfg.KeyFunc(c)
+	return s
+}
 
-		mu.Lock()
-		v, exists := visitors[key]
-		if !exists {
-			visitors[key] = &visitor{count: 1, lastSeen: time.Now()}
-			mu.Unlock()
-			return c.Next()
-		}
+func (s *memoryRateLimitStore) hit(ctx context.Context, key string) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		// Reset if window expired
-		if time.Since(v.lastSeen) > cfg.Window {
-			v.count = 1
-			v.lastSeen = time.Now()
-			mu.Unlock()
-			return c.Next()
-		}
+	now := time.Now()
+	v, exists := s.visitors[key]
+	if !exists {
+		v = &rateLimitVisitor{count: 1, lastSeen: now}
+		s.visitors[key] = v
+		return v.count, now.Add(s.window), nil
+	}
 
-		v.count++
-		if v.count > cfg.Max {
-			mu.Unlock()
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":   "rate_limit_exceeded",
-				"message": "Too many requests, please try again later",
-			})
-		}
+	// Reset if window expired
+	if now.Sub(v.lastSeen) > s.window {
+		v.count = 1
+		v.lastSeen = now
+		return v.count, now.Add(s.window), nil
+	}
+
+	v.count++
+	if v.count <= s.max {
+		v.lastSeen = now
+	}
+	return v.count, v.lastSeen.Add(s.window), nil
+}
 
-		v.lastSeen = time.Now()
-		mu.Unlock()
+// redisRateLimitStore counts hits with INCR on a key that expires with the
+// window, so Redis drops idle counters without a cleanup pass
+type redisRateLimitStore struct {
+	client *redis.Client
+	window time.Duration
+}
 
-		return c.Next()
+func (s *redisRateLimitStore) hit(ctx context.Context, key string) (int, time.Time, error) {
+	key = "ratelimit:" + key
+
+	pipe := s.client.Pipeline()
+	incr := pipe.Incr(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, time.Time{}, err
 	}
+
+	// The first hit of a window starts its expiry; a negative TTL also
+	// repairs a counter whose EXPIRE was lost
+	remaining := ttl.Val()
+	if remaining < 0 {
+		if err := s.client.Expire(ctx, key, s.window).Err(); err != nil {
+			return 0, time.Time{}, err
+		}
+		remaining = s.window
+	}
+
+	return int(incr.Val()), time.Now().Add(remaining), nil
 }
 
 // DefaultRateLimitKeyFunc returns user ID or IP as key
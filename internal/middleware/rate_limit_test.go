@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddlewareMemory(t *testing.T) {
+	app := fiber.New()
+	app.Post("/comments", RateLimitMiddleware(RateLimitConfig{
+		Max:     3,
+		Window:  time.Minute,
+		KeyFunc: DefaultRateLimitKeyFunc,
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	post := func() (int, string, string) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/comments", nil))
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get("X-RateLimit-Remaining"), resp.Header.Get("X-RateLimit-Reset")
+	}
+
+	for i := 0; i < 3; i++ {
+		status, remaining, reset := post()
+		assert.Equal(t, fiber.StatusCreated, status)
+		assert.Equal(t, strconv.Itoa(2-i), remaining)
+
+		resetAt, err := strconv.ParseInt(reset, 10, 64)
+		require.NoError(t, err)
+		assert.InDelta(t, time.Now().Add(time.Minute).Unix(), resetAt, 2)
+	}
+
+	status, remaining, _ := post()
+	assert.Equal(t, fiber.StatusTooManyRequests, status)
+	assert.Equal(t, "0", remaining)
+}
@@ -14,8 +14,15 @@ const (
 	StatusApproved CommentStatus = "approved"
 	StatusRejected CommentStatus = "rejected"
 	StatusSpam     CommentStatus = "spam"
+
+	// StatusPendingSecondApproval marks a comment one moderator approved
+	// while the settings require two distinct sign-offs
+	StatusPendingSecondApproval CommentStatus = "pending_second_approval"
 )
 
+// PendingStatuses are the statuses of comments still awaiting moderation
+var PendingStatuses = []CommentStatus{StatusPending, StatusPendingSecondApproval}
+
 // ReactionType represents the type of reaction
 type ReactionType string
 
@@ -29,6 +36,43 @@ const (
 	ReactionAngry   ReactionType = "angry"
 )
 
+// Now returns the current time in UTC, the zone every stored and returned
+// timestamp uses regardless of the server's local zone
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// SumReactionCounts totals the reactions of every type
+func SumReactionCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+// ViewFlat lists a resource's roots and replies as one chronological stream
+const ViewFlat = "flat"
+
+// VoteType represents a helpfulness vote
+type VoteType string
+
+const (
+	VoteHelpful    VoteType = "helpful"
+	VoteNotHelpful VoteType = "not_helpful"
+)
+
+// CursorStart requests the first page of a cursor-paginated listing
+const CursorStart = "start"
+
+// Moderation actions applied when a settings rule matches
+const (
+	ActionReject    = "reject"
+	ActionPending   = "pending"
+	ActionClose     = "close"
+	ActionTranslate = "translate"
+)
+
 // Comment represents a comment in the system
 type Comment struct {
 	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
@@ -46,30 +90,43 @@ type Comment struct {
 	IsAnonymous  bool   `bson:"is_anonymous" json:"isAnonymous"`
 
 	// Content
-	Content     string       `bson:"content" json:"content"`
-	ContentHTML string       `bson:"content_html,omitempty" json:"contentHtml,omitempty"` // Sanitized HTML
-	Attachments []Attachment `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Content           string       `bson:"content" json:"content"`
+	ContentHTML       string       `bson:"content_html,omitempty" json:"contentHtml,omitempty"` // Sanitized HTML
+	Snippet           string       `bson:"snippet,omitempty" json:"snippet,omitempty"`          // Short plain-text preview
+	RawContent        string       `bson:"raw_content,omitempty" json:"-"`                      // Original input before processing, admin only
+	Attachments       []Attachment `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Mentions          []string     `bson:"mentions,omitempty" json:"mentions,omitempty"`          // @usernames in the content
+	Language          string       `bson:"language,omitempty" json:"language,omitempty"`          // Detected ISO 639-1 code, when the settings expect a language
+	ContentTranslated string       `bson:"content_translated" json:"contentTranslated,omitempty"` // Content in the expected language, when it was written in another
 
 	// Moderation
-	Status          CommentStatus `bson:"status" json:"status"`
-	ModeratedBy     string        `bson:"moderated_by,omitempty" json:"moderatedBy,omitempty"`
-	ModeratedAt     *time.Time    `bson:"moderated_at,omitempty" json:"moderatedAt,omitempty"`
-	RejectionReason string        `bson:"rejection_reason,omitempty" json:"rejectionReason,omitempty"`
-	FlaggedWords    []string      `bson:"flagged_words,omitempty" json:"flaggedWords,omitempty"`
-	ReportCount     int           `bson:"report_count" json:"reportCount"`
+	Status              CommentStatus `bson:"status" json:"status"`
+	ModeratedBy         string        `bson:"moderated_by,omitempty" json:"moderatedBy,omitempty"`
+	ModeratedAt         *time.Time    `bson:"moderated_at,omitempty" json:"moderatedAt,omitempty"`
+	RejectionReason     string        `bson:"rejection_reason,omitempty" json:"rejectionReason,omitempty"`
+	FlaggedWords        []string      `bson:"flagged_words,omitempty" json:"flaggedWords,omitempty"`
+	Labels              []string      `bson:"labels,omitempty" json:"labels,omitempty"`                  // Moderator-set, from the settings' label vocabulary
+	ModerationApprovals []string      `bson:"moderation_approvals" json:"moderationApprovals,omitempty"` // Moderators who approved since the comment was last pending, under dual moderation
+	ReportCount         int           `bson:"report_count" json:"reportCount"`
 
 	// Features
-	IsPinned    bool         `bson:"is_pinned" json:"isPinned"`
-	PinnedBy    string       `bson:"pinned_by,omitempty" json:"pinnedBy,omitempty"`
-	PinnedAt    *time.Time   `bson:"pinned_at,omitempty" json:"pinnedAt,omitempty"`
-	IsEdited    bool         `bson:"is_edited" json:"isEdited"`
-	EditHistory []EditRecord `bson:"edit_history,omitempty" json:"editHistory,omitempty"`
+	IsPinned           bool         `bson:"is_pinned" json:"isPinned"`
+	PinnedBy           string       `bson:"pinned_by,omitempty" json:"pinnedBy,omitempty"`
+	PinnedAt           *time.Time   `bson:"pinned_at,omitempty" json:"pinnedAt,omitempty"`
+	SortWeight         int          `bson:"sort_weight,omitempty" json:"sortWeight,omitempty"` // Curator ordering, higher first; 0 is never stored
+	IsEdited           bool         `bson:"is_edited" json:"isEdited"`
+	ReactionsLocked    bool         `bson:"reactions_locked" json:"reactionsLocked"`
+	EditHistory        []EditRecord `bson:"edit_history,omitempty" json:"editHistory,omitempty"`
+	EditsSinceApproval int          `bson:"edits_since_approval" json:"editsSinceApproval"`
 
 	// Stats
-	ReplyCount     int            `bson:"reply_count" json:"replyCount"`
-	LikeCount      int            `bson:"like_count" json:"likeCount"`
-	DislikeCount   int            `bson:"dislike_count" json:"dislikeCount"`
-	ReactionCounts map[string]int `bson:"reaction_counts,omitempty" json:"reactionCounts,omitempty"`
+	ReplyCount      int            `bson:"reply_count" json:"replyCount"`
+	LikeCount       int            `bson:"like_count" json:"likeCount"`
+	DislikeCount    int            `bson:"dislike_count" json:"dislikeCount"`
+	ReactionCounts  map[string]int `bson:"reaction_counts" json:"reactionCounts"` // Never nil, see CommentRepository.Create
+	TotalReactions  int            `bson:"total_reactions" json:"totalReactions"` // Sum of ReactionCounts, stored for sorting
+	HelpfulCount    int            `bson:"helpful_count" json:"helpfulCount"`
+	NotHelpfulCount int            `bson:"not_helpful_count" json:"notHelpfulCount"`
 
 	// Metadata
 	IPAddress string         `bson:"ip_address,omitempty" json:"-"` // Hidden from API
@@ -85,6 +142,13 @@ type Comment struct {
 
 	// Depth for nested replies
 	Depth int `bson:"depth" json:"depth"`
+
+	// Computed per request, never stored
+	IsUnread            bool   `bson:"-" json:"isUnread,omitempty"`
+	ReplyingToName      string `bson:"-" json:"replyingToName,omitempty"`      // Parent author, in the flat view
+	HasAttachments      bool   `bson:"-" json:"hasAttachments"`                // Set in list responses, for thread headers
+	ApproximatePosition int    `bson:"-" json:"approximatePosition,omitempty"` // 1-based place in the default sort, on create with withPosition
+	RepliesDisabled     bool   `bson:"-" json:"repliesDisabled"`               // Current settings turn replies off, so clients can hide reply buttons
 }
 
 // Attachment represents a file attached to a comment
@@ -105,6 +169,16 @@ type EditRecord struct {
 	EditedBy string    `bson:"edited_by" json:"editedBy"`
 }
 
+// HelpfulVote represents a user's "was this helpful?" vote on a comment,
+// kept separate from emoji reactions
+type HelpfulVote struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CommentID primitive.ObjectID `bson:"comment_id" json:"commentId"`
+	UserID    string             `bson:"user_id" json:"userId"`
+	Type      VoteType           `bson:"type" json:"type"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
 // Reaction represents a user reaction to a comment
 type Reaction struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -114,6 +188,45 @@ type Reaction struct {
 	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
 }
 
+// AuditEntry records who performed an action on a comment. ActorID is always
+// the real caller; OnBehalfOf is set when an admin impersonated a user.
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TenantID   string             `bson:"tenant_id" json:"tenantId"`
+	Action     string             `bson:"action" json:"action"`
+	CommentID  primitive.ObjectID `bson:"comment_id" json:"commentId"`
+	ActorID    string             `bson:"actor_id" json:"actorId"`
+	OnBehalfOf string             `bson:"on_behalf_of,omitempty" json:"onBehalfOf,omitempty"`
+	Status     CommentStatus      `bson:"status,omitempty" json:"status,omitempty"` // Comment status after the action
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"` // Rejection reason, if rejected
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// Kill switch scopes
+const (
+	KillSwitchGlobal = "global"
+	KillSwitchTenant = "tenant"
+)
+
+// KillSwitch disables comment creation globally or for one tenant until
+// switched off. TenantID is empty for the global switch.
+type KillSwitch struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Scope     string             `bson:"scope" json:"scope"`
+	TenantID  string             `bson:"tenant_id" json:"tenantId,omitempty"`
+	Enabled   bool               `bson:"enabled" json:"enabled"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	UpdatedBy string             `bson:"updated_by" json:"updatedBy"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// Report statuses
+const (
+	ReportPending   = "pending"
+	ReportReviewed  = "reviewed"
+	ReportDismissed = "dismissed"
+)
+
 // Report represents a user report on a comment
 type Report struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -127,26 +240,86 @@ type Report struct {
 	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
 }
 
+// ResourceView tracks when a user last viewed the comments of a resource
+type ResourceView struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TenantID     string             `bson:"tenant_id" json:"tenantId"`
+	ResourceType string             `bson:"resource_type" json:"resourceType"`
+	ResourceID   string             `bson:"resource_id" json:"resourceId"`
+	UserID       string             `bson:"user_id" json:"userId"`
+	LastSeenAt   time.Time          `bson:"last_seen_at" json:"lastSeenAt"`
+}
+
+// ResourceCounter holds the denormalized number of live (not deleted)
+// comments on a resource
+type ResourceCounter struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TenantID     string             `bson:"tenant_id" json:"tenantId"`
+	ResourceType string             `bson:"resource_type" json:"resourceType"`
+	ResourceID   string             `bson:"resource_id" json:"resourceId"`
+	Count        int64              `bson:"count" json:"count"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
 // CommentSettings represents tenant-specific comment settings
 type CommentSettings struct {
-	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	TenantID            string             `bson:"tenant_id" json:"tenantId"`
-	ResourceType        string             `bson:"resource_type" json:"resourceType"`
-	RequireApproval     bool               `bson:"require_approval" json:"requireApproval"`
-	AllowAnonymous      bool               `bson:"allow_anonymous" json:"allowAnonymous"`
-	AllowReplies        bool               `bson:"allow_replies" json:"allowReplies"`
-	MaxReplyDepth       int                `bson:"max_reply_depth" json:"maxReplyDepth"`
-	AllowReactions      bool               `bson:"allow_reactions" json:"allowReactions"`
-	AllowedReactions    []ReactionType     `bson:"allowed_reactions" json:"allowedReactions"`
-	AllowAttachments    bool               `bson:"allow_attachments" json:"allowAttachments"`
-	MaxAttachments      int                `bson:"max_attachments" json:"maxAttachments"`
-	MaxCommentLength    int                `bson:"max_comment_length" json:"maxCommentLength"`
-	CommentsEnabled     bool               `bson:"comments_enabled" json:"commentsEnabled"`
-	NotifyOnNewComment  bool               `bson:"notify_on_new_comment" json:"notifyOnNewComment"`
-	NotifyOnReply       bool               `bson:"notify_on_reply" json:"notifyOnReply"`
-	AutoApproveVerified bool               `bson:"auto_approve_verified" json:"autoApproveVerified"`
-	BadWordsFilter      bool               `bson:"bad_words_filter" json:"badWordsFilter"`
-	CustomBadWords      []string           `bson:"custom_bad_words,omitempty" json:"customBadWords,omitempty"`
-	CreatedAt           time.Time          `bson:"created_at" json:"createdAt"`
-	UpdatedAt           time.Time          `bson:"updated_at" json:"updatedAt"`
+	ID                      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TenantID                string             `bson:"tenant_id" json:"tenantId"`
+	ResourceType            string             `bson:"resource_type" json:"resourceType"`
+	RequireApproval         bool               `bson:"require_approval" json:"requireApproval"`
+	DualModeration          bool               `bson:"dual_moderation" json:"dualModeration"` // approval needs two distinct moderators
+	AllowAnonymous          bool               `bson:"allow_anonymous" json:"allowAnonymous"`
+	BlockDisposableEmails   bool               `bson:"block_disposable_emails" json:"blockDisposableEmails"` // reject signed-in authors with throwaway email domains
+	AnonymousAllowName      bool               `bson:"anonymous_allow_name" json:"anonymousAllowName"`       // use the provided authorName instead of "Anonymous"
+	AnonymousRequireName    bool               `bson:"anonymous_require_name" json:"anonymousRequireName"`   // reject anonymous comments without an authorName
+	AllowReplies            bool               `bson:"allow_replies" json:"allowReplies"`
+	CascadeDelete           bool               `bson:"cascade_delete" json:"cascadeDelete"` // soft-deleting a comment soft-deletes its replies too
+	MaxReplyDepth           int                `bson:"max_reply_depth" json:"maxReplyDepth"`
+	AllowReactions          bool               `bson:"allow_reactions" json:"allowReactions"`
+	AllowSelfReaction       bool               `bson:"allow_self_reaction" json:"allowSelfReaction"` // authors may react to their own comments, and those reactions count
+	AllowedReactions        []ReactionType     `bson:"allowed_reactions" json:"allowedReactions"`
+	AllowAttachments        bool               `bson:"allow_attachments" json:"allowAttachments"`
+	MaxAttachments          int                `bson:"max_attachments" json:"maxAttachments"`
+	MaxAttachmentSize       int64              `bson:"max_attachment_size" json:"maxAttachmentSize"`            // bytes per attachment, 0 disables
+	MaxTotalAttachmentSize  int64              `bson:"max_total_attachment_size" json:"maxTotalAttachmentSize"` // bytes across a comment's attachments, 0 disables
+	MaxCommentLength        int                `bson:"max_comment_length" json:"maxCommentLength"`
+	CommentsEnabled         bool               `bson:"comments_enabled" json:"commentsEnabled"`
+	NotifyOnNewComment      bool               `bson:"notify_on_new_comment" json:"notifyOnNewComment"`
+	NotifyOnReply           bool               `bson:"notify_on_reply" json:"notifyOnReply"`
+	AutoApproveVerified     bool               `bson:"auto_approve_verified" json:"autoApproveVerified"`
+	BadWordsFilter          bool               `bson:"bad_words_filter" json:"badWordsFilter"`
+	RenderHTML              bool               `bson:"render_html" json:"renderHtml"` // store sanitized, linkified contentHtml
+	CustomBadWords          []string           `bson:"custom_bad_words,omitempty" json:"customBadWords,omitempty"`
+	RejectLowInfoComments   bool               `bson:"reject_low_info_comments" json:"rejectLowInfoComments"`
+	ReModerateAfterEdits    int                `bson:"re_moderate_after_edits" json:"reModerateAfterEdits"`                         // 0 disables
+	LockEditWhilePending    bool               `bson:"lock_edit_while_pending" json:"lockEditWhilePending"`                         // authors can't edit comments awaiting moderation
+	LockEditsAfterReply     bool               `bson:"lock_edits_after_reply" json:"lockEditsAfterReply"`                           // authors can't edit comments that have replies
+	EditWindowSeconds       int                `bson:"edit_window_seconds" json:"editWindowSeconds"`                                // authors may edit this long after posting, 0 disables
+	MaxPendingPerAuthor     int                `bson:"max_pending_per_author" json:"maxPendingPerAuthor"`                           // 0 disables
+	AutoHideReportThreshold int                `bson:"auto_hide_report_threshold" json:"autoHideReportThreshold"`                   // 0 disables
+	MaxRootComments         int                `bson:"max_root_comments" json:"maxRootComments"`                                    // per resource, 0 disables
+	MaxRootCommentsAction   string             `bson:"max_root_comments_action,omitempty" json:"maxRootCommentsAction,omitempty"`   // reject (default) or close
+	LowInfoAction           string             `bson:"low_info_action,omitempty" json:"lowInfoAction,omitempty"`                    // reject (default) or pending
+	RejectParentEchoes      bool               `bson:"reject_parent_echoes" json:"rejectParentEchoes"`                              // replies that just repeat their parent
+	ParentEchoAction        string             `bson:"parent_echo_action,omitempty" json:"parentEchoAction,omitempty"`              // reject (default) or pending
+	ParentEchoThreshold     float64            `bson:"parent_echo_threshold,omitempty" json:"parentEchoThreshold,omitempty"`        // word overlap from 0 to 1; 0 uses 0.9
+	PendingAutoCloseHours   int                `bson:"pending_auto_close_hours" json:"pendingAutoCloseHours"`                       // 0 disables
+	PendingAutoCloseAction  string             `bson:"pending_auto_close_action,omitempty" json:"pendingAutoCloseAction,omitempty"` // reject (default) or approve
+	BlockedCountries        []string           `bson:"blocked_countries,omitempty" json:"blockedCountries,omitempty"`               // ISO 3166-1 alpha-2 codes
+	AllowedCountries        []string           `bson:"allowed_countries,omitempty" json:"allowedCountries,omitempty"`               // if set, only these may comment
+	AllowedOrigins          []string           `bson:"allowed_origins,omitempty" json:"allowedOrigins,omitempty"`                   // if set, only these sites may create comments
+	AllowedScripts          []string           `bson:"allowed_scripts,omitempty" json:"allowedScripts,omitempty"`                   // Unicode script names, e.g. Latin; empty allows all
+	ExpectedLanguage        string             `bson:"expected_language,omitempty" json:"expectedLanguage,omitempty"`               // ISO 639-1 code; empty disables the language check
+	LanguageMismatchAction  string             `bson:"language_mismatch_action,omitempty" json:"languageMismatchAction,omitempty"`  // pending (default) or translate
+	ModerationLabels        []string           `bson:"moderation_labels,omitempty" json:"moderationLabels,omitempty"`               // vocabulary moderators may label comments with
+	Webhook                 *WebhookSettings   `bson:"webhook,omitempty" json:"webhook,omitempty"`                                  // which events reach the webhook endpoint, and in what shape
+	CreatedAt               time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt               time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// WebhookSettings filters and reshapes the webhook events of a tenant's
+// resource type
+type WebhookSettings struct {
+	Events   []string          `bson:"events,omitempty" json:"events,omitempty"`     // events to send; empty sends all
+	Template map[string]string `bson:"template,omitempty" json:"template,omitempty"` // payload field -> event data field; empty sends the data as is
 }
@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // CreateCommentRequest represents the request to create a new comment
 type CreateCommentRequest struct {
@@ -13,6 +17,12 @@ type CreateCommentRequest struct {
 	IsAnonymous  bool           `json:"isAnonymous,omitempty"`
 	Attachments  []Attachment   `json:"attachments,omitempty"`
 	Metadata     map[string]any `json:"metadata,omitempty"`
+	// ResourceOwnerID is notified of new root comments; metadata.owner_id
+	// is used when it's empty
+	ResourceOwnerID string `json:"resourceOwnerId,omitempty"`
+	// Origin is set by the handler from the Origin header, or failing that
+	// the Referer, for settings with allowed origins
+	Origin string `json:"-"`
 }
 
 // UpdateCommentRequest represents the request to update a comment
@@ -32,6 +42,44 @@ type PinCommentRequest struct {
 	IsPinned bool `json:"isPinned"`
 }
 
+// LockReactionsRequest represents the request to lock/unlock reactions on a comment
+type LockReactionsRequest struct {
+	IsLocked bool `json:"isLocked"`
+}
+
+// SortWeightRequest represents the request to set a comment's manual sort weight
+type SortWeightRequest struct {
+	SortWeight int `json:"sortWeight" validate:"min=0"`
+}
+
+// LabelsRequest represents the request to add or remove moderation labels
+type LabelsRequest struct {
+	Labels []string `json:"labels" validate:"required,min=1"`
+}
+
+// ReopenReportRequest represents the request to reopen a resolved report
+type ReopenReportRequest struct {
+	// RequeueComment sends an approved comment back to the moderation queue
+	RequeueComment bool `json:"requeueComment"`
+}
+
+// KillSwitchRequest represents the request to turn a kill switch on or off
+type KillSwitchRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// MergeCommentsRequest represents the request to merge one comment thread into another
+type MergeCommentsRequest struct {
+	SourceID string `json:"sourceId" validate:"required"`
+	TargetID string `json:"targetId" validate:"required"`
+}
+
+// HelpfulVoteRequest represents the request to vote on a comment's helpfulness
+type HelpfulVoteRequest struct {
+	Type VoteType `json:"type" validate:"required,oneof=helpful not_helpful"`
+}
+
 // ReactionRequest represents the request to add/update a reaction
 type ReactionRequest struct {
 	Type ReactionType `json:"type" validate:"required,oneof=like dislike love haha wow sad angry"`
@@ -51,12 +99,31 @@ type ListCommentsRequest struct {
 	ParentID       string        `query:"parentId"`
 	Status         CommentStatus `query:"status"`
 	AuthorID       string        `query:"authorId"`
+	AuthorIDs      []string      `query:"authorIds"` // Admin only; matches any of these authors, together with AuthorID
 	IsPinned       *bool         `query:"isPinned"`
-	SortBy         string        `query:"sortBy"`    // created_at, like_count, reply_count
+	SortBy         string        `query:"sortBy"`    // created_at, like_count, reply_count, helpful_count, total_reactions
 	SortOrder      string        `query:"sortOrder"` // asc, desc
 	Page           int           `query:"page"`
 	PageSize       int           `query:"pageSize"`
 	IncludeDeleted bool          `query:"includeDeleted"`
+	UnreadFor      string        `query:"-"`      // User ID to compute isUnread for; empty disables tracking
+	PendingFor     string        `query:"-"`      // User ID whose own pending comments are listed with approved ones
+	View           string        `query:"view"`   // "flat" returns roots and replies in one chronological stream
+	Cursor         string        `query:"cursor"` // CursorStart or a nextCursor; switches to keyset pagination by created_at
+	Label          string        `query:"label"`
+	// IncludeDeletedPlaceholders keeps deleted comments that still have live
+	// replies in threaded views, with their content and author redacted
+	IncludeDeletedPlaceholders bool `query:"includeDeletedPlaceholders"`
+	// CreatedAfter and CreatedBefore bound created_at, inclusive and
+	// exclusive, so a day is [midnight, next midnight)
+	CreatedAfter  *time.Time `query:"-"`
+	CreatedBefore *time.Time `query:"-"`
+}
+
+// MarkSeenRequest represents the request to mark a resource's comments as seen
+type MarkSeenRequest struct {
+	ResourceType string `json:"resourceType" validate:"required"`
+	ResourceID   string `json:"resourceId" validate:"required"`
 }
 
 // ListCommentsResponse represents paginated comments response
@@ -65,7 +132,61 @@ type ListCommentsResponse struct {
 	Total      int64      `json:"total"`
 	Page       int        `json:"page"`
 	PageSize   int        `json:"pageSize"`
-	TotalPages int        `json:"totalPages"`
+	TotalPages int64      `json:"totalPages"`
+	NextCursor string     `json:"nextCursor,omitempty"` // Cursor mode only; empty on the last page
+	// RepliesDisabled is set when the listing's resource type currently
+	// doesn't allow replies
+	RepliesDisabled bool `json:"repliesDisabled"`
+}
+
+// NewerCommentsResponse represents root comments newer than a known comment
+type NewerCommentsResponse struct {
+	Comments []*Comment `json:"comments"`
+	Count    int        `json:"count"`
+}
+
+// ExportCommentsRequest represents query parameters for an admin export
+type ExportCommentsRequest struct {
+	TenantID     string        `query:"tenantId"`
+	ResourceType string        `query:"resourceType"`
+	ResourceID   string        `query:"resourceId"`
+	Status       CommentStatus `query:"status"`
+	AfterID      string        `query:"afterId"` // Resume after this comment, the last one of the previous call
+	Limit        int           `query:"limit"`
+}
+
+// ExportedComment is a single comment line in an admin export
+type ExportedComment struct {
+	ID           string        `json:"id"`
+	TenantID     string        `json:"tenantId"`
+	ResourceType string        `json:"resourceType"`
+	ResourceID   string        `json:"resourceId"`
+	ParentID     string        `json:"parentId,omitempty"`
+	AuthorID     string        `json:"authorId"`
+	AuthorName   string        `json:"authorName"`
+	AuthorEmail  string        `json:"authorEmail,omitempty"`
+	IPAddress    string        `json:"ipAddress,omitempty"`
+	IsAnonymous  bool          `json:"isAnonymous"`
+	Content      string        `json:"content"`
+	Status       CommentStatus `json:"status"`
+	IsDeleted    bool          `json:"isDeleted"`
+	CreatedAt    time.Time     `json:"createdAt"`
+}
+
+// CommentReportsResponse represents a page of a comment's reports for moderators
+type CommentReportsResponse struct {
+	Reports         []*Report        `json:"reports"`
+	Total           int64            `json:"total"`
+	Page            int              `json:"page"`
+	PageSize        int              `json:"pageSize"`
+	TotalPages      int64            `json:"totalPages"`
+	ReasonBreakdown map[string]int64 `json:"reasonBreakdown"`
+}
+
+// AdminComment exposes admin-only comment fields to moderators
+type AdminComment struct {
+	*Comment
+	RawContent string `json:"rawContent,omitempty"`
 }
 
 // CommentWithReplies represents a comment with its replies
@@ -82,7 +203,39 @@ type CommentStats struct {
 	RejectedCount     int64            `json:"rejectedCount"`
 	TotalReactions    int64            `json:"totalReactions"`
 	AverageRating     float64          `json:"averageRating,omitempty"`
-	ReactionBreakdown map[string]int64 `json:"reactionBreakdown,omitempty"`
+	ReactionBreakdown map[string]int64 `json:"reactionBreakdown"`
+}
+
+// RecountResourceRequest represents the request to rebuild a resource's comment counter
+type RecountResourceRequest struct {
+	ResourceType string `json:"resourceType" validate:"required"`
+	ResourceID   string `json:"resourceId" validate:"required"`
+}
+
+// StatusCounts represents a resource's comment counts by status
+type StatusCounts struct {
+	Counts map[string]int64 `json:"counts"`
+	Total  int64            `json:"total"`
+}
+
+// ModerationTransition represents one status change in a comment's moderation history
+type ModerationTransition struct {
+	Action      string        `json:"action"`
+	Status      CommentStatus `json:"status"`
+	Reason      string        `json:"reason,omitempty"`
+	ModeratorID string        `json:"moderatorId"`
+	OnBehalfOf  string        `json:"onBehalfOf,omitempty"`
+	At          time.Time     `json:"at"`
+}
+
+// AuthorSummary represents an author's moderation record for reviewers
+type AuthorSummary struct {
+	AuthorID      string           `json:"authorId"`
+	ResourceType  string           `json:"resourceType,omitempty"`
+	Counts        map[string]int64 `json:"counts"`
+	TotalComments int64            `json:"totalComments"`
+	ApprovalRate  float64          `json:"approvalRate"` // approved share of moderated (non-pending) comments
+	ReportCount   int64            `json:"reportCount"`
 }
 
 // PendingModeration represents comments pending moderation
@@ -97,21 +250,78 @@ type UserReaction struct {
 	Type      *ReactionType      `json:"type"` // nil if no reaction
 }
 
+// ReactionSummary represents a comment's reaction counts right after a
+// reaction change, along with the caller's resulting reaction
+type ReactionSummary struct {
+	CommentID      string         `json:"commentId"`
+	LikeCount      int            `json:"likeCount"`
+	DislikeCount   int            `json:"dislikeCount"`
+	ReactionCounts map[string]int `json:"reactionCounts"`
+	UserReaction   *ReactionType  `json:"userReaction"` // nil if no reaction
+}
+
+// PublicSettings represents the settings a public comment widget needs to
+// render; moderation internals stay admin-only
+type PublicSettings struct {
+	ResourceType     string         `json:"resourceType"`
+	CommentsEnabled  bool           `json:"commentsEnabled"`
+	RequireApproval  bool           `json:"requireApproval"`
+	AllowReplies     bool           `json:"allowReplies"`
+	AllowReactions   bool           `json:"allowReactions"`
+	AllowedReactions []ReactionType `json:"allowedReactions"`
+	AllowAttachments bool           `json:"allowAttachments"`
+	MaxCommentLength int            `json:"maxCommentLength"`
+}
+
 // SettingsRequest represents request to update tenant settings
 type SettingsRequest struct {
-	RequireApproval     *bool          `json:"requireApproval,omitempty"`
-	AllowAnonymous      *bool          `json:"allowAnonymous,omitempty"`
-	AllowReplies        *bool          `json:"allowReplies,omitempty"`
-	MaxReplyDepth       *int           `json:"maxReplyDepth,omitempty"`
-	AllowReactions      *bool          `json:"allowReactions,omitempty"`
-	AllowedReactions    []ReactionType `json:"allowedReactions,omitempty"`
-	AllowAttachments    *bool          `json:"allowAttachments,omitempty"`
-	MaxAttachments      *int           `json:"maxAttachments,omitempty"`
-	MaxCommentLength    *int           `json:"maxCommentLength,omitempty"`
-	CommentsEnabled     *bool          `json:"commentsEnabled,omitempty"`
-	NotifyOnNewComment  *bool          `json:"notifyOnNewComment,omitempty"`
-	NotifyOnReply       *bool          `json:"notifyOnReply,omitempty"`
-	AutoApproveVerified *bool          `json:"autoApproveVerified,omitempty"`
-	BadWordsFilter      *bool          `json:"badWordsFilter,omitempty"`
-	CustomBadWords      []string       `json:"customBadWords,omitempty"`
+	RequireApproval         *bool          `json:"requireApproval,omitempty"`
+	DualModeration          *bool          `json:"dualModeration,omitempty"`
+	AllowAnonymous          *bool          `json:"allowAnonymous,omitempty"`
+	BlockDisposableEmails   *bool          `json:"blockDisposableEmails,omitempty"`
+	AnonymousAllowName      *bool          `json:"anonymousAllowName,omitempty"`
+	AnonymousRequireName    *bool          `json:"anonymousRequireName,omitempty"`
+	AllowReplies            *bool          `json:"allowReplies,omitempty"`
+	CascadeDelete           *bool          `json:"cascadeDelete,omitempty"`
+	MaxReplyDepth           *int           `json:"maxReplyDepth,omitempty"`
+	AllowReactions          *bool          `json:"allowReactions,omitempty"`
+	AllowSelfReaction       *bool          `json:"allowSelfReaction,omitempty"`
+	AllowedReactions        []ReactionType `json:"allowedReactions,omitempty"`
+	AllowAttachments        *bool          `json:"allowAttachments,omitempty"`
+	MaxAttachments          *int           `json:"maxAttachments,omitempty"`
+	MaxAttachmentSize       *int64         `json:"maxAttachmentSize,omitempty" validate:"omitempty,min=0"`
+	MaxTotalAttachmentSize  *int64         `json:"maxTotalAttachmentSize,omitempty" validate:"omitempty,min=0"`
+	MaxCommentLength        *int           `json:"maxCommentLength,omitempty"`
+	CommentsEnabled         *bool          `json:"commentsEnabled,omitempty"`
+	NotifyOnNewComment      *bool          `json:"notifyOnNewComment,omitempty"`
+	NotifyOnReply           *bool          `json:"notifyOnReply,omitempty"`
+	AutoApproveVerified     *bool          `json:"autoApproveVerified,omitempty"`
+	BadWordsFilter          *bool          `json:"badWordsFilter,omitempty"`
+	RenderHTML              *bool          `json:"renderHtml,omitempty"`
+	CustomBadWords          []string       `json:"customBadWords,omitempty"`
+	RejectLowInfoComments   *bool          `json:"rejectLowInfoComments,omitempty"`
+	ReModerateAfterEdits    *int           `json:"reModerateAfterEdits,omitempty" validate:"omitempty,min=0"`
+	LockEditWhilePending    *bool          `json:"lockEditWhilePending,omitempty"`
+	LockEditsAfterReply     *bool          `json:"lockEditsAfterReply,omitempty"`
+	EditWindowSeconds       *int           `json:"editWindowSeconds,omitempty" validate:"omitempty,min=0"`
+	MaxPendingPerAuthor     *int           `json:"maxPendingPerAuthor,omitempty" validate:"omitempty,min=0"`
+	AutoHideReportThreshold *int           `json:"autoHideReportThreshold,omitempty" validate:"omitempty,min=0"`
+	MaxRootComments         *int           `json:"maxRootComments,omitempty" validate:"omitempty,min=0"`
+	MaxRootCommentsAction   *string        `json:"maxRootCommentsAction,omitempty" validate:"omitempty,oneof=reject close"`
+	LowInfoAction           *string        `json:"lowInfoAction,omitempty" validate:"omitempty,oneof=reject pending"`
+	RejectParentEchoes      *bool          `json:"rejectParentEchoes,omitempty"`
+	ParentEchoAction        *string        `json:"parentEchoAction,omitempty" validate:"omitempty,oneof=reject pending"`
+	ParentEchoThreshold     *float64       `json:"parentEchoThreshold,omitempty" validate:"omitempty,gt=0,lte=1"`
+	PendingAutoCloseHours   *int           `json:"pendingAutoCloseHours,omitempty" validate:"omitempty,min=0"`
+	PendingAutoCloseAction  *string        `json:"pendingAutoCloseAction,omitempty" validate:"omitempty,oneof=approve reject"`
+	BlockedCountries        []string       `json:"blockedCountries,omitempty"`
+	AllowedCountries        []string       `json:"allowedCountries,omitempty"`
+	AllowedOrigins          []string       `json:"allowedOrigins,omitempty"`
+	AllowedScripts          []string       `json:"allowedScripts,omitempty"`
+	ExpectedLanguage        *string        `json:"expectedLanguage,omitempty" validate:"omitempty,len=2"`
+	LanguageMismatchAction  *string        `json:"languageMismatchAction,omitempty" validate:"omitempty,oneof=pending translate"`
+	ModerationLabels        []string       `json:"moderationLabels,omitempty"`
+
+	// Replaces the whole webhook section; send {} to clear it
+	Webhook *WebhookSettings `json:"webhook,omitempty"`
 }
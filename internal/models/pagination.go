@@ -0,0 +1,42 @@
+package models
+
+// Default and maximum page sizes applied by the repositories
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Pagination describes where a page sits in a result set
+type Pagination struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"pageSize"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"totalPages"`
+}
+
+// NewPagination computes page metadata for a total, applying the same page
+// defaults as the repositories so a zero page size can never divide by zero
+func NewPagination(total int64, page, pageSize int) Pagination {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+	if total < 0 {
+		total = 0
+	}
+
+	size := int64(pageSize)
+	totalPages := total / size
+	if total%size > 0 {
+		totalPages++
+	}
+
+	return Pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
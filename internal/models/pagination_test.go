@@ -0,0 +1,46 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPagination(t *testing.T) {
+	t.Run("Partial Last Page", func(t *testing.T) {
+		p := NewPagination(45, 2, 20)
+		assert.Equal(t, 2, p.Page)
+		assert.Equal(t, 20, p.PageSize)
+		assert.Equal(t, int64(45), p.Total)
+		assert.Equal(t, int64(3), p.TotalPages)
+	})
+
+	t.Run("Exact Pages", func(t *testing.T) {
+		assert.Equal(t, int64(2), NewPagination(40, 1, 20).TotalPages)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, int64(0), NewPagination(0, 1, 20).TotalPages)
+	})
+
+	t.Run("Zero Page Size", func(t *testing.T) {
+		p := NewPagination(45, 0, 0)
+		assert.Equal(t, 1, p.Page)
+		assert.Equal(t, DefaultPageSize, p.PageSize)
+		assert.Equal(t, int64(3), p.TotalPages)
+	})
+
+	t.Run("Oversized Page Size", func(t *testing.T) {
+		assert.Equal(t, DefaultPageSize, NewPagination(45, 1, 500).PageSize)
+	})
+
+	t.Run("Large Totals", func(t *testing.T) {
+		p := NewPagination(math.MaxInt64, 1, 100)
+		assert.Equal(t, int64(math.MaxInt64/100+1), p.TotalPages)
+
+		// More pages than fit in an int32
+		p = NewPagination((int64(math.MaxInt32)+1)*20, 1, 20)
+		assert.Equal(t, int64(math.MaxInt32)+1, p.TotalPages)
+	})
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository handles audit log data operations
+type AuditRepository struct {
+	db         *database.MongoDB
+	collection *mongo.Collection
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *database.MongoDB) *AuditRepository {
+	return &AuditRepository{
+		db:         db,
+		collection: db.Collection("audit_log"),
+	}
+}
+
+// Create inserts an audit entry
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	entry.CreatedAt = models.Now()
+
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByComment retrieves a comment's audit entries for the given actions, oldest first
+func (r *AuditRepository) GetByComment(ctx context.Context, commentID primitive.ObjectID, actions []string) ([]*models.AuditEntry, error) {
+	filter := bson.M{
+		"comment_id": commentID,
+		"action":     bson.M{"$in": actions},
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/minisource/comment/internal/database"
@@ -29,8 +32,8 @@ func NewCommentRepository(db *database.MongoDB) *CommentRepository {
 
 // Create inserts a new comment
 func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
-	comment.CreatedAt = time.Now()
-	comment.UpdatedAt = time.Now()
+	stampCreated(comment)
+	initReactionCounts(comment)
 
 	result, err := r.collection.InsertOne(ctx, comment)
 	if err != nil {
@@ -41,6 +44,21 @@ func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment)
 	return nil
 }
 
+// stampCreated sets a new comment's creation and update times, in UTC
+func stampCreated(comment *models.Comment) {
+	now := models.Now()
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+}
+
+// initReactionCounts gives a new comment an empty reaction_counts object so
+// it has the same shape before and after its first reaction
+func initReactionCounts(comment *models.Comment) {
+	if comment.ReactionCounts == nil {
+		comment.ReactionCounts = map[string]int{}
+	}
+}
+
 // GetByID retrieves a comment by ID
 func (r *CommentRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
 	var comment models.Comment
@@ -54,21 +72,69 @@ func (r *CommentRepository) GetByID(ctx context.Context, id primitive.ObjectID)
 	return &comment, nil
 }
 
-// Update updates a comment
+// Update updates a comment. Edit history is never rewritten here; use
+// UpdateWithEdit to record an edit.
 func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment) error {
-	comment.UpdatedAt = time.Now()
+	comment.UpdatedAt = models.Now()
 
-	_, err := r.collection.UpdateOne(
+	doc, err := updateDocument(comment)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": comment.ID},
+		bson.M{"$set": doc},
+	)
+	return err
+}
+
+// UpdateWithEdit updates a comment and appends an edit record, keeping only
+// the newest keep records (all of them when keep is 0)
+func (r *CommentRepository) UpdateWithEdit(ctx context.Context, comment *models.Comment, edit models.EditRecord, keep int) error {
+	comment.UpdatedAt = models.Now()
+
+	doc, err := updateDocument(comment)
+	if err != nil {
+		return err
+	}
+
+	push := bson.M{"$each": bson.A{edit}}
+	if keep > 0 {
+		push["$slice"] = -keep
+	}
+
+	_, err = r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": comment.ID},
-		bson.M{"$set": comment},
+		bson.M{
+			"$set":  doc,
+			"$push": bson.M{"edit_history": push},
+		},
 	)
 	return err
 }
 
+// updateDocument returns the fields of a comment to $set, leaving out the
+// edit history so it isn't rewritten on every update
+func updateDocument(comment *models.Comment) (bson.M, error) {
+	raw, err := bson.Marshal(comment)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "edit_history")
+	return doc, nil
+}
+
 // UpdateFields updates specific fields of a comment
 func (r *CommentRepository) UpdateFields(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
-	fields["updated_at"] = time.Now()
+	fields["updated_at"] = models.Now()
 
 	_, err := r.collection.UpdateOne(
 		ctx,
@@ -78,9 +144,50 @@ func (r *CommentRepository) UpdateFields(ctx context.Context, id primitive.Objec
 	return err
 }
 
+// SetSortWeight sets a comment's manual sort weight. A zero weight is unset
+// so it sorts alongside comments that were never weighted.
+func (r *CommentRepository) SetSortWeight(ctx context.Context, id primitive.ObjectID, weight int) error {
+	update := bson.M{"$set": bson.M{"sort_weight": weight, "updated_at": models.Now()}}
+	if weight == 0 {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": models.Now()},
+			"$unset": bson.M{"sort_weight": ""},
+		}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// AddLabels adds moderation labels to a comment, ignoring ones it already has
+func (r *CommentRepository) AddLabels(ctx context.Context, id primitive.ObjectID, labels []string) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"labels": bson.M{"$each": labels}},
+			"$set":      bson.M{"updated_at": models.Now()},
+		},
+	)
+	return err
+}
+
+// RemoveLabels removes moderation labels from a comment
+func (r *CommentRepository) RemoveLabels(ctx context.Context, id primitive.ObjectID, labels []string) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$pull": bson.M{"labels": bson.M{"$in": labels}},
+			"$set":  bson.M{"updated_at": models.Now()},
+		},
+	)
+	return err
+}
+
 // SoftDelete marks a comment as deleted
 func (r *CommentRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedBy string) error {
-	now := time.Now()
+	now := models.Now()
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -96,14 +203,226 @@ func (r *CommentRepository) SoftDelete(ctx context.Context, id primitive.ObjectI
 	return err
 }
 
+// SoftDeleteMany marks several comments as deleted, leaving ones that already
+// are untouched
+func (r *CommentRepository) SoftDeleteMany(ctx context.Context, ids []primitive.ObjectID, deletedBy string) error {
+	now := models.Now()
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "is_deleted": false},
+		bson.M{
+			"$set": bson.M{
+				"is_deleted": true,
+				"deleted_at": now,
+				"deleted_by": deletedBy,
+				"updated_at": now,
+			},
+		},
+	)
+	return err
+}
+
+// Restore clears the soft-delete markers on a comment
+func (r *CommentRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"is_deleted": false,
+				"updated_at": models.Now(),
+			},
+			"$unset": bson.M{
+				"deleted_at": "",
+				"deleted_by": "",
+			},
+		},
+	)
+	return err
+}
+
 // HardDelete permanently deletes a comment
 func (r *CommentRepository) HardDelete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
-// List retrieves comments with filters
-func (r *CommentRepository) List(ctx context.Context, req models.ListCommentsRequest) ([]*models.Comment, int64, error) {
+// List retrieves comments with filters. The total is only counted with
+// countTotal; callers that already know it skip the count.
+func (r *CommentRepository) List(ctx context.Context, req models.ListCommentsRequest, countTotal bool) ([]*models.Comment, int64, error) {
+	filter := listFilter(req)
+
+	// Count total
+	var total int64
+	if countTotal {
+		var err error
+		total, err = r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// Set defaults
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	findOptions := options.Find().
+		SetSort(listOrder(req)).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+// ListByCursor retrieves a page of comments after the request's cursor,
+// ordered by created_at and _id, along with the total and the cursor for the
+// next page (empty on the last page). It never skips, so deep pages cost the
+// same as the first.
+func (r *CommentRepository) ListByCursor(ctx context.Context, req models.ListCommentsRequest) ([]*models.Comment, int64, string, error) {
+	filter := listFilter(req)
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	order := cursorOrder(req)
+	if req.Cursor != models.CursorStart {
+		createdAt, id, err := decodeListCursor(req.Cursor)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		addCursorFilter(filter, createdAt, id, order)
+	}
+
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	// Fetch one extra to learn whether there is a next page
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(req.PageSize + 1))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, 0, "", err
+	}
+
+	comments, next := cursorPage(comments, req.PageSize)
+	return comments, total, next, nil
+}
+
+// cursorOrder returns the direction of a cursor listing: oldest first for
+// the flat view (as in offset mode) or sortOrder=asc, newest first otherwise
+func cursorOrder(req models.ListCommentsRequest) int {
+	if req.View == models.ViewFlat || req.SortOrder == "asc" {
+		return 1
+	}
+	return -1
+}
+
+// addCursorFilter restricts filter to comments after the cursor position in
+// the given direction. It goes under $and so it can't clash with an existing $or.
+func addCursorFilter(filter bson.M, createdAt time.Time, id primitive.ObjectID, order int) {
+	op := "$lt"
+	if order > 0 {
+		op = "$gt"
+	}
+
+	after := bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{op: createdAt}},
+		bson.M{"created_at": createdAt, "_id": bson.M{op: id}},
+	}}
+	and, _ := filter["$and"].(bson.A)
+	filter["$and"] = append(and, after)
+}
+
+// cursorPage trims a result fetched with one extra comment to pageSize and
+// returns the cursor of its last comment when more remain
+func cursorPage(comments []*models.Comment, pageSize int) ([]*models.Comment, string) {
+	if len(comments) <= pageSize {
+		return comments, ""
+	}
+	comments = comments[:pageSize]
+	return comments, encodeListCursor(comments[pageSize-1])
+}
+
+// encodeListCursor builds the opaque cursor for a comment's position
+func encodeListCursor(comment *models.Comment) string {
+	raw := strconv.FormatInt(comment.CreatedAt.UnixNano(), 10) + ":" + comment.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeListCursor reads the position out of a cursor from encodeListCursor
+func decodeListCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	invalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, invalid
+	}
+	nanos, hexID, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, primitive.NilObjectID, invalid
+	}
+	unixNanos, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, invalid
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, invalid
+	}
+	return time.Unix(0, unixNanos).UTC(), id, nil
+}
+
+// addStatusFilter restricts filter to status. When listing approved comments
+// for a known user, that user's own pending comments are included too so
+// authors don't think a comment awaiting approval vanished.
+func addStatusFilter(filter bson.M, status models.CommentStatus, pendingFor string) {
+	if status != models.StatusApproved || pendingFor == "" {
+		filter["status"] = status
+		return
+	}
+	filter["$or"] = bson.A{
+		bson.M{"status": models.StatusApproved},
+		bson.M{"status": bson.M{"$in": models.PendingStatuses}, "author_id": pendingFor},
+	}
+}
+
+// listOrder returns the sort for a listing. The flat view is always
+// chronological so replies interleave with roots by creation time.
+func listOrder(req models.ListCommentsRequest) bson.D {
+	if req.View == models.ViewFlat {
+		return bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+	}
+	return listSort(req.SortBy, req.SortOrder)
+}
+
+// listFilter builds the query for a comment listing
+func listFilter(req models.ListCommentsRequest) bson.M {
 	filter := bson.M{}
 
 	if req.TenantID != "" {
@@ -120,80 +439,195 @@ func (r *CommentRepository) List(ctx context.Context, req models.ListCommentsReq
 		if err == nil {
 			filter["parent_id"] = parentID
 		}
-	} else {
+	} else if req.View != models.ViewFlat {
 		// If no parent ID specified, get only root comments
 		filter["parent_id"] = nil
 	}
 	if req.Status != "" {
-		filter["status"] = req.Status
+		addStatusFilter(filter, req.Status, req.PendingFor)
 	}
-	if req.AuthorID != "" {
+	if len(req.AuthorIDs) > 0 {
+		authorIDs := req.AuthorIDs
+		if req.AuthorID != "" {
+			authorIDs = append([]string{req.AuthorID}, authorIDs...)
+		}
+		filter["author_id"] = bson.M{"$in": authorIDs}
+	} else if req.AuthorID != "" {
 		filter["author_id"] = req.AuthorID
 	}
 	if req.IsPinned != nil {
 		filter["is_pinned"] = *req.IsPinned
 	}
+	if req.Label != "" {
+		filter["labels"] = req.Label
+	}
+	if req.CreatedAfter != nil || req.CreatedBefore != nil {
+		createdAt := bson.M{}
+		if req.CreatedAfter != nil {
+			createdAt["$gte"] = *req.CreatedAfter
+		}
+		if req.CreatedBefore != nil {
+			createdAt["$lt"] = *req.CreatedBefore
+		}
+		filter["created_at"] = createdAt
+	}
 	if !req.IncludeDeleted {
 		filter["is_deleted"] = false
 	}
 
-	// Count total
-	total, err := r.collection.CountDocuments(ctx, filter)
+	return filter
+}
+
+// ListNewer retrieves approved root comments on a resource created strictly
+// after the anchor comment, oldest first
+func (r *CommentRepository) ListNewer(ctx context.Context, tenantID, resourceType, resourceID string, anchor *models.Comment, limit int) ([]*models.Comment, error) {
+	filter := newerThanFilter(anchor.CreatedAt, anchor.ID)
+	filter["tenant_id"] = tenantID
+	filter["resource_type"] = resourceType
+	filter["resource_id"] = resourceID
+	filter["parent_id"] = nil
+	filter["is_deleted"] = false
+	filter["status"] = models.StatusApproved
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	// Set defaults
-	if req.Page < 1 {
-		req.Page = 1
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
 	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 20
+
+	return comments, nil
+}
+
+// newerThanFilter matches comments ordered after (createdAt, id), using the
+// ID to break ties between comments created in the same instant
+func newerThanFilter(createdAt time.Time, id primitive.ObjectID) bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"created_at": bson.M{"$gt": createdAt}},
+			bson.M{"created_at": createdAt, "_id": bson.M{"$gt": id}},
+		},
 	}
+}
 
-	// Sort options
+// listSort builds the sort order for comment listings. Pinned comments always
+// come first; unknown sort fields fall back to newest first. _id breaks ties
+// so comments with equal keys (e.g. created in the same millisecond) keep
+// their place across pages.
+func listSort(sortBy, sortOrder string) bson.D {
 	sortField := "created_at"
-	sortOrder := -1 // desc
-	if req.SortBy != "" {
-		switch req.SortBy {
-		case "created_at", "like_count", "reply_count":
-			sortField = req.SortBy
+	order := -1 // desc
+	switch sortBy {
+	case "created_at", "like_count", "reply_count", "helpful_count", "total_reactions":
+		sortField = sortBy
+	}
+	if sortOrder == "asc" {
+		order = 1
+	}
+
+	sort := bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: sortField, Value: order}}
+	if sortField == "helpful_count" {
+		// Among equally helpful comments, prefer fewer not-helpful votes
+		sort = append(sort, bson.E{Key: "not_helpful_count", Value: -order})
+	}
+	return append(sort, bson.E{Key: "_id", Value: order})
+}
+
+// Stream iterates over the comments matching an export request, oldest first,
+// after the given comment when one is set and at most req.Limit of them,
+// without loading the whole result set into memory
+func (r *CommentRepository) Stream(ctx context.Context, req models.ExportCommentsRequest, after *models.Comment, fn func(*models.Comment) error) error {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(req.Limit))
+
+	cursor, err := r.collection.Find(ctx, exportFilter(req, after), findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var comment models.Comment
+		if err := cursor.Decode(&comment); err != nil {
+			return err
 		}
+		if err := fn(&comment); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// exportFilter builds the filter for an export request, resuming after the
+// given comment when one is set
+func exportFilter(req models.ExportCommentsRequest, after *models.Comment) bson.M {
+	filter := bson.M{"tenant_id": req.TenantID}
+	if req.ResourceType != "" {
+		filter["resource_type"] = req.ResourceType
+	}
+	if req.ResourceID != "" {
+		filter["resource_id"] = req.ResourceID
+	}
+	if req.Status != "" {
+		filter["status"] = req.Status
 	}
-	if req.SortOrder == "asc" {
-		sortOrder = 1
+	if after != nil {
+		addCursorFilter(filter, after.CreatedAt, after.ID, 1)
 	}
+	return filter
+}
 
+// StreamList iterates over the comments a listing matches, in listing order
+// and without pagination, stopping after limit comments or when fn fails
+func (r *CommentRepository) StreamList(ctx context.Context, req models.ListCommentsRequest, limit int, fn func(*models.Comment) error) error {
 	findOptions := options.Find().
-		SetSort(bson.D{{Key: "is_pinned", Value: -1}, {Key: sortField, Value: sortOrder}}).
-		SetSkip(int64((req.Page - 1) * req.PageSize)).
-		SetLimit(int64(req.PageSize))
+		SetSort(listOrder(req)).
+		SetLimit(int64(limit))
 
-	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	cursor, err := r.collection.Find(ctx, listFilter(req), findOptions)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
 	defer cursor.Close(ctx)
 
-	var comments []*models.Comment
-	if err := cursor.All(ctx, &comments); err != nil {
-		return nil, 0, err
+	for cursor.Next(ctx) {
+		var comment models.Comment
+		if err := cursor.Decode(&comment); err != nil {
+			return err
+		}
+		if err := fn(&comment); err != nil {
+			return err
+		}
 	}
 
-	return comments, total, nil
+	return cursor.Err()
 }
 
-// GetReplies retrieves replies for a comment
-func (r *CommentRepository) GetReplies(ctx context.Context, parentID primitive.ObjectID, page, pageSize int) ([]*models.Comment, int64, error) {
+// GetReplies retrieves approved replies to a comment, plus the viewer's own
+// pending replies when viewerID is set. hasMore reports whether replies exist
+// past this page; the total is only counted when withTotal is set.
+func (r *CommentRepository) GetReplies(ctx context.Context, parentID primitive.ObjectID, viewerID string, page, pageSize int, withTotal bool) (replies []*models.Comment, total int64, hasMore bool, err error) {
 	filter := bson.M{
 		"parent_id":  parentID,
 		"is_deleted": false,
-		"status":     models.StatusApproved,
 	}
+	addStatusFilter(filter, models.StatusApproved, viewerID)
 
-	total, err := r.collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
+	if withTotal {
+		total, err = r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, 0, false, err
+		}
 	}
 
 	if page < 1 {
@@ -203,29 +637,233 @@ func (r *CommentRepository) GetReplies(ctx context.Context, parentID primitive.O
 		pageSize = 20
 	}
 
+	// Fetch one extra reply to learn whether another page exists
 	findOptions := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
 		SetSkip(int64((page - 1) * pageSize)).
-		SetLimit(int64(pageSize))
+		SetLimit(int64(pageSize + 1))
 
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &replies); err != nil {
+		return nil, 0, false, err
+	}
+
+	replies, hasMore = trimPage(replies, pageSize)
+	return replies, total, hasMore, nil
+}
+
+// trimPage cuts a result fetched with a limit of pageSize+1 down to pageSize,
+// reporting whether the extra item was there
+func trimPage(items []*models.Comment, pageSize int) ([]*models.Comment, bool) {
+	if len(items) > pageSize {
+		return items[:pageSize], true
+	}
+	return items, false
+}
+
+// CountReplies counts the non-deleted direct replies to a comment
+func (r *CommentRepository) CountReplies(ctx context.Context, parentID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"parent_id":  parentID,
+		"is_deleted": false,
+	})
+}
+
+// CountResource counts a resource's live (not deleted) comments
+func (r *CommentRepository) CountResource(ctx context.Context, tenantID, resourceType, resourceID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"is_deleted":    false,
+	})
+}
+
+// GetByIDs retrieves comments by ID, keyed by ID
+func (r *CommentRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Comment, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+	return byID, nil
+}
+
+// CountPendingByAuthor counts an author's comments awaiting review in a tenant
+func (r *CommentRepository) CountPendingByAuthor(ctx context.Context, tenantID, authorID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"tenant_id":  tenantID,
+		"author_id":  authorID,
+		"status":     bson.M{"$in": models.PendingStatuses},
+		"is_deleted": false,
+	})
+}
+
+// CountActiveRoots counts a resource's root comments that are approved or
+// awaiting review
+func (r *CommentRepository) CountActiveRoots(ctx context.Context, tenantID, resourceType, resourceID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"parent_id":     nil,
+		"status":        bson.M{"$in": append([]models.CommentStatus{models.StatusApproved}, models.PendingStatuses...)},
+		"is_deleted":    false,
+	})
+}
+
+// CountRootsRankedAbove counts a resource's approved root comments that the
+// default sort puts ahead of a new, unweighted and unpinned comment: those
+// with a positive sort weight, and pinned ones without a weight
+func (r *CommentRepository) CountRootsRankedAbove(ctx context.Context, tenantID, resourceType, resourceID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"parent_id":     nil,
+		"status":        models.StatusApproved,
+		"is_deleted":    false,
+		"$or": bson.A{
+			bson.M{"sort_weight": bson.M{"$gt": 0}},
+			bson.M{"sort_weight": bson.M{"$in": bson.A{nil, 0}}, "is_pinned": true},
+		},
+	})
+}
+
+// GetStalePending retrieves pending comments, including ones awaiting a second approval, for a tenant's
+// resource type created before a cutoff, oldest first.
+// With after set, only comments past it are returned, so callers can page past ones they leave pending.
+func (r *CommentRepository) GetStalePending(ctx context.Context, tenantID, resourceType string, before time.Time, after *models.Comment, limit int) ([]*models.Comment, error) {
+	filter := stalePendingFilter(tenantID, resourceType, before, after)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// stalePendingFilter matches a resource type's pending comments (see PendingStatuses) created
+// before a cutoff and, when after is set, past it in created_at/_id order
+func stalePendingFilter(tenantID, resourceType string, before time.Time, after *models.Comment) bson.M {
+	filter := bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"status":        bson.M{"$in": models.PendingStatuses},
+		"is_deleted":    false,
+		"created_at":    bson.M{"$lt": before},
+	}
+	if after != nil {
+		addCursorFilter(filter, after.CreatedAt, after.ID, 1)
+	}
+	return filter
+}
+
+// GetThread retrieves every approved reply under a root comment, ordered by
+// depth and then creation time
+func (r *CommentRepository) GetThread(ctx context.Context, rootID primitive.ObjectID) ([]*models.Comment, error) {
+	filter := bson.M{
+		"root_id":    rootID,
+		"is_deleted": false,
+		"status":     models.StatusApproved,
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "depth", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var replies []*models.Comment
 	if err := cursor.All(ctx, &replies); err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	return replies, nil
+}
+
+// GetResourceTree retrieves a resource's approved comments down to maxDepth in
+// one query, oldest first, so callers can assemble the tree in memory.
+// Soft-deleted comments are only included with includeDeleted.
+func (r *CommentRepository) GetResourceTree(ctx context.Context, tenantID, resourceType, resourceID string, maxDepth, limit int, includeDeleted bool) ([]*models.Comment, error) {
+	filter := bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"status":        models.StatusApproved,
+		"depth":         bson.M{"$lte": maxDepth},
+	}
+	if !includeDeleted {
+		filter["is_deleted"] = false
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetChildren retrieves the direct replies of the given comments, including deleted ones
+func (r *CommentRepository) GetChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]*models.Comment, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"parent_id": bson.M{"$in": parentIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var children []*models.Comment
+	if err := cursor.All(ctx, &children); err != nil {
+		return nil, err
 	}
 
-	return replies, total, nil
+	return children, nil
 }
 
 // GetPending retrieves pending comments for moderation
 func (r *CommentRepository) GetPending(ctx context.Context, tenantID string, page, pageSize int) ([]*models.Comment, int64, error) {
 	filter := bson.M{
-		"status":     models.StatusPending,
+		"status":     bson.M{"$in": models.PendingStatuses},
 		"is_deleted": false,
 	}
 	if tenantID != "" {
@@ -245,7 +883,7 @@ func (r *CommentRepository) GetPending(ctx context.Context, tenantID string, pag
 	}
 
 	findOptions := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
 		SetSkip(int64((page - 1) * pageSize)).
 		SetLimit(int64(pageSize))
 
@@ -278,7 +916,7 @@ func (r *CommentRepository) GetStats(ctx context.Context, tenantID, resourceType
 			"_id":      nil,
 			"total":    bson.M{"$sum": 1},
 			"approved": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.StatusApproved}}, 1, 0}}},
-			"pending":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.StatusPending}}, 1, 0}}},
+			"pending":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$status", models.PendingStatuses}}, 1, 0}}},
 			"rejected": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.StatusRejected}}, 1, 0}}},
 		}}},
 	}
@@ -302,9 +940,150 @@ func (r *CommentRepository) GetStats(ctx context.Context, tenantID, resourceType
 		stats.RejectedCount = int64(results[0]["rejected"].(int32))
 	}
 
+	// Sum the denormalized per-comment reaction counts by type. Like the
+	// status counts above, the match is served by idx_resource_comments.
+	reactionPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: bson.M{"counts": bson.M{"$objectToArray": "$reaction_counts"}}}},
+		{{Key: "$unwind", Value: "$counts"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$counts.k",
+			"count": bson.M{"$sum": "$counts.v"},
+		}}},
+	}
+
+	reactionCursor, err := r.collection.Aggregate(ctx, reactionPipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer reactionCursor.Close(ctx)
+
+	var reactionResults []bson.M
+	if err := reactionCursor.All(ctx, &reactionResults); err != nil {
+		return nil, err
+	}
+
+	stats.ReactionBreakdown, stats.TotalReactions = groupedCounts(reactionResults)
+
 	return stats, nil
 }
 
+// groupedCounts turns grouped {_id: key, count: n} results into per-key
+// totals and an overall total
+func groupedCounts(results []bson.M) (map[string]int64, int64) {
+	breakdown := make(map[string]int64, len(results))
+	var total int64
+
+	for _, result := range results {
+		key, ok := result["_id"].(string)
+		if !ok {
+			continue
+		}
+
+		var count int64
+		switch v := result["count"].(type) {
+		case int32:
+			count = int64(v)
+		case int64:
+			count = v
+		case float64:
+			count = int64(v)
+		}
+		if count <= 0 {
+			continue
+		}
+
+		breakdown[key] += count
+		total += count
+	}
+
+	return breakdown, total
+}
+
+// CountByStatus counts a resource's non-deleted comments grouped by status
+func (r *CommentRepository) CountByStatus(ctx context.Context, tenantID, resourceType, resourceID string) (map[string]int64, int64, error) {
+	return r.countByStatus(ctx, bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"is_deleted":    false,
+	})
+}
+
+// CountAuthorByStatus counts an author's non-deleted comments grouped by
+// status, optionally limited to one resource type
+func (r *CommentRepository) CountAuthorByStatus(ctx context.Context, tenantID, authorID, resourceType string) (map[string]int64, int64, error) {
+	match := authorMatch(tenantID, authorID, resourceType)
+	match["is_deleted"] = false
+	return r.countByStatus(ctx, match)
+}
+
+// countByStatus counts the comments matching match grouped by status
+func (r *CommentRepository) countByStatus(ctx context.Context, match bson.M) (map[string]int64, int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$status",
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+
+	counts, total := groupedCounts(results)
+	return counts, total, nil
+}
+
+// SumAuthorReports totals the reports filed against an author's comments,
+// including deleted ones, optionally limited to one resource type
+func (r *CommentRepository) SumAuthorReports(ctx context.Context, tenantID, authorID, resourceType string) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: authorMatch(tenantID, authorID, resourceType)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$report_count"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+// authorMatch selects an author's comments in a tenant, optionally limited to one resource type
+func authorMatch(tenantID, authorID, resourceType string) bson.M {
+	match := bson.M{
+		"tenant_id": tenantID,
+		"author_id": authorID,
+	}
+	if resourceType != "" {
+		match["resource_type"] = resourceType
+	}
+	return match
+}
+
 // IncrementReplyCount increments the reply count of a comment
 func (r *CommentRepository) IncrementReplyCount(ctx context.Context, id primitive.ObjectID, delta int) error {
 	_, err := r.collection.UpdateOne(
@@ -312,7 +1091,7 @@ func (r *CommentRepository) IncrementReplyCount(ctx context.Context, id primitiv
 		bson.M{"_id": id},
 		bson.M{
 			"$inc": bson.M{"reply_count": delta},
-			"$set": bson.M{"updated_at": time.Now()},
+			"$set": bson.M{"updated_at": models.Now()},
 		},
 	)
 	return err
@@ -328,26 +1107,54 @@ func (r *CommentRepository) UpdateReactionCounts(ctx context.Context, id primiti
 				"like_count":      likeCount,
 				"dislike_count":   dislikeCount,
 				"reaction_counts": reactionCounts,
-				"updated_at":      time.Now(),
+				"total_reactions": models.SumReactionCounts(reactionCounts),
+				"updated_at":      models.Now(),
 			},
 		},
 	)
 	return err
 }
 
-// IncrementReportCount increments the report count of a comment
-func (r *CommentRepository) IncrementReportCount(ctx context.Context, id primitive.ObjectID) error {
+// UpdateHelpfulCounts updates the helpfulness vote counts of a comment
+func (r *CommentRepository) UpdateHelpfulCounts(ctx context.Context, id primitive.ObjectID, helpfulCount, notHelpfulCount int) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
 		bson.M{
-			"$inc": bson.M{"report_count": 1},
-			"$set": bson.M{"updated_at": time.Now()},
+			"$set": bson.M{
+				"helpful_count":     helpfulCount,
+				"not_helpful_count": notHelpfulCount,
+				"updated_at":        models.Now(),
+			},
 		},
 	)
 	return err
 }
 
+// IncrementReportCount increments the report count of a comment and returns the new count
+func (r *CommentRepository) IncrementReportCount(ctx context.Context, id primitive.ObjectID) (int, error) {
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"report_count": 1})
+
+	var result struct {
+		ReportCount int `bson:"report_count"`
+	}
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"report_count": 1},
+			"$set": bson.M{"updated_at": models.Now()},
+		},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, err
+	}
+	return result.ReportCount, nil
+}
+
 // Search searches comments by content
 func (r *CommentRepository) Search(ctx context.Context, tenantID, query string, page, pageSize int) ([]*models.Comment, int64, error) {
 	filter := bson.M{
@@ -370,7 +1177,7 @@ func (r *CommentRepository) Search(ctx context.Context, tenantID, query string,
 	}
 
 	findOptions := options.Find().
-		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: -1}}).
 		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
 		SetSkip(int64((page - 1) * pageSize)).
 		SetLimit(int64(pageSize))
@@ -0,0 +1,502 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListSort(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+		want      bson.D
+	}{
+		{"Default", "", "", bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{"Unknown Field", "author_email", "asc", bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{"Likes", "like_count", "desc", bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: "like_count", Value: -1}, {Key: "_id", Value: -1}}},
+		{"Most Reacted", "total_reactions", "desc", bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: "total_reactions", Value: -1}, {Key: "_id", Value: -1}}},
+		{
+			"Most Helpful",
+			"helpful_count",
+			"desc",
+			bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: "helpful_count", Value: -1}, {Key: "not_helpful_count", Value: 1}, {Key: "_id", Value: -1}},
+		},
+		{
+			"Least Helpful",
+			"helpful_count",
+			"asc",
+			bson.D{{Key: "sort_weight", Value: -1}, {Key: "is_pinned", Value: -1}, {Key: "helpful_count", Value: 1}, {Key: "not_helpful_count", Value: -1}, {Key: "_id", Value: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, listSort(tt.sortBy, tt.sortOrder))
+		})
+	}
+}
+
+func TestListSortWeightFirst(t *testing.T) {
+	now := time.Now()
+	pinned := &models.Comment{AuthorName: "pinned", IsPinned: true, CreatedAt: now}
+	newest := &models.Comment{AuthorName: "newest", CreatedAt: now.Add(time.Minute)}
+	curated := &models.Comment{AuthorName: "curated", SortWeight: 5, CreatedAt: now.Add(-time.Hour)}
+	top := &models.Comment{AuthorName: "top", SortWeight: 10, CreatedAt: now.Add(-2 * time.Hour)}
+
+	// Evaluate the sort the way MongoDB would for the fields it names
+	fields := map[string]func(*models.Comment) int64{
+		"sort_weight": func(c *models.Comment) int64 { return int64(c.SortWeight) },
+		"is_pinned": func(c *models.Comment) int64 {
+			if c.IsPinned {
+				return 1
+			}
+			return 0
+		},
+		"created_at": func(c *models.Comment) int64 { return c.CreatedAt.UnixNano() },
+		"_id":        func(c *models.Comment) int64 { return 0 },
+	}
+	spec := listSort("", "")
+	comments := []*models.Comment{pinned, newest, curated, top}
+	sort.SliceStable(comments, func(i, j int) bool {
+		for _, key := range spec {
+			a, b := fields[key.Key](comments[i]), fields[key.Key](comments[j])
+			if a != b {
+				return (a > b) == (key.Value == -1)
+			}
+		}
+		return false
+	})
+
+	var order []string
+	for _, c := range comments {
+		order = append(order, c.AuthorName)
+	}
+	assert.Equal(t, []string{"top", "curated", "pinned", "newest"}, order)
+}
+
+func TestInitReactionCounts(t *testing.T) {
+	comment := &models.Comment{}
+	initReactionCounts(comment)
+
+	assert.NotNil(t, comment.ReactionCounts)
+	assert.Empty(t, comment.ReactionCounts)
+
+	body, err := json.Marshal(comment)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"reactionCounts":{}`)
+
+	// Existing counts are left alone
+	comment.ReactionCounts["like"] = 3
+	initReactionCounts(comment)
+	assert.Equal(t, map[string]int{"like": 3}, comment.ReactionCounts)
+}
+
+func TestListFilterAuthors(t *testing.T) {
+	// Evaluate the author part of the filter the way MongoDB would
+	matches := func(filter bson.M, c *models.Comment) bool {
+		switch cond := filter["author_id"].(type) {
+		case nil:
+			return true
+		case string:
+			return c.AuthorID == cond
+		case bson.M:
+			return slices.Contains(cond["$in"].([]string), c.AuthorID)
+		}
+		return false
+	}
+
+	comments := []*models.Comment{{AuthorID: "alice"}, {AuthorID: "bob"}, {AuthorID: "carol"}, {AuthorID: "dave"}}
+	listed := func(req models.ListCommentsRequest) []string {
+		filter := listFilter(req)
+		var authors []string
+		for _, c := range comments {
+			if matches(filter, c) {
+				authors = append(authors, c.AuthorID)
+			}
+		}
+		return authors
+	}
+
+	assert.Equal(t, []string{"alice", "carol"}, listed(models.ListCommentsRequest{AuthorIDs: []string{"alice", "carol"}}))
+	assert.Equal(t, []string{"alice", "bob", "carol"}, listed(models.ListCommentsRequest{AuthorID: "bob", AuthorIDs: []string{"alice", "carol"}}), "authorId joins the set")
+	assert.Equal(t, []string{"dave"}, listed(models.ListCommentsRequest{AuthorID: "dave"}))
+	assert.Len(t, listed(models.ListCommentsRequest{}), 4)
+}
+
+func TestListFilterDateRange(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	next := day.Add(24 * time.Hour)
+
+	filter := listFilter(models.ListCommentsRequest{CreatedAfter: &day, CreatedBefore: &next})
+	assert.Equal(t, bson.M{"$gte": day, "$lt": next}, filter["created_at"])
+
+	filter = listFilter(models.ListCommentsRequest{CreatedAfter: &day})
+	assert.Equal(t, bson.M{"$gte": day}, filter["created_at"])
+
+	filter = listFilter(models.ListCommentsRequest{})
+	assert.NotContains(t, filter, "created_at")
+}
+
+func TestListFilterOwnPending(t *testing.T) {
+	// Evaluate the status part of the filter the way MongoDB would
+	visible := func(filter bson.M, c *models.Comment) bool {
+		if status, ok := filter["status"]; ok {
+			return c.Status == status
+		}
+		for _, clause := range filter["$or"].(bson.A) {
+			cond := clause.(bson.M)
+			if in, ok := cond["status"].(bson.M); ok {
+				if !slices.Contains(in["$in"].([]models.CommentStatus), c.Status) {
+					continue
+				}
+			} else if c.Status != cond["status"] {
+				continue
+			}
+			if author, ok := cond["author_id"]; ok && c.AuthorID != author {
+				continue
+			}
+			return true
+		}
+		return false
+	}
+
+	approved := &models.Comment{AuthorID: "bob", Status: models.StatusApproved}
+	ownPending := &models.Comment{AuthorID: "alice", Status: models.StatusPending}
+	othersPending := &models.Comment{AuthorID: "bob", Status: models.StatusPending}
+	ownHalfApproved := &models.Comment{AuthorID: "alice", Status: models.StatusPendingSecondApproval}
+	ownRejected := &models.Comment{AuthorID: "alice", Status: models.StatusRejected}
+
+	filter := listFilter(models.ListCommentsRequest{Status: models.StatusApproved, PendingFor: "alice"})
+	assert.True(t, visible(filter, approved))
+	assert.True(t, visible(filter, ownPending), "authors see their own pending comments")
+	assert.True(t, visible(filter, ownHalfApproved), "including ones awaiting a second approval")
+	assert.False(t, visible(filter, othersPending), "others' pending comments stay hidden")
+	assert.False(t, visible(filter, ownRejected))
+
+	filter = listFilter(models.ListCommentsRequest{Status: models.StatusApproved})
+	assert.False(t, visible(filter, ownPending), "anonymous readers see approved only")
+
+	filter = listFilter(models.ListCommentsRequest{Status: models.StatusPending, PendingFor: "alice"})
+	assert.True(t, visible(filter, othersPending), "an explicit status is used as is")
+}
+
+func TestGroupedCounts(t *testing.T) {
+	// Grouped results for a resource whose comments were reacted to several times
+	results := []bson.M{
+		{"_id": "like", "count": int32(1180)},
+		{"_id": "love", "count": int64(35)},
+		{"_id": "haha", "count": float64(12)},
+		{"_id": "sad", "count": int32(0)},
+		{"_id": nil, "count": int32(4)},
+	}
+
+	breakdown, total := groupedCounts(results)
+
+	assert.Equal(t, map[string]int64{"like": 1180, "love": 35, "haha": 12}, breakdown)
+	assert.Equal(t, int64(1227), total)
+
+	breakdown, total = groupedCounts(nil)
+	assert.NotNil(t, breakdown, "resources without reactions get an empty breakdown")
+	assert.Empty(t, breakdown)
+	assert.Zero(t, total)
+}
+
+func TestNewerThanFilter(t *testing.T) {
+	anchorTime := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	anchorID := primitive.NewObjectIDFromTimestamp(anchorTime)
+	filter := newerThanFilter(anchorTime, anchorID)
+
+	// Evaluate the filter the way MongoDB would for a (created_at, _id) pair
+	matches := func(createdAt time.Time, id primitive.ObjectID) bool {
+		for _, clause := range filter["$or"].(bson.A) {
+			cond := clause.(bson.M)
+			if gt, ok := cond["created_at"].(bson.M); ok {
+				if createdAt.After(gt["$gt"].(time.Time)) {
+					return true
+				}
+				continue
+			}
+			if createdAt.Equal(cond["created_at"].(time.Time)) {
+				if id.Hex() > cond["_id"].(bson.M)["$gt"].(primitive.ObjectID).Hex() {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	sameInstantLater := anchorID
+	sameInstantLater[11]++
+	sameInstantEarlier := anchorID
+	sameInstantEarlier[11]--
+
+	assert.True(t, matches(anchorTime.Add(time.Second), primitive.NewObjectID()), "later comment")
+	assert.True(t, matches(anchorTime, sameInstantLater), "same instant, higher ID")
+	assert.False(t, matches(anchorTime, anchorID), "the anchor itself")
+	assert.False(t, matches(anchorTime, sameInstantEarlier), "same instant, lower ID")
+	assert.False(t, matches(anchorTime.Add(-time.Second), primitive.NewObjectID()), "older comment")
+}
+
+func TestExportFilter(t *testing.T) {
+	req := models.ExportCommentsRequest{TenantID: "t1", ResourceType: "post", Status: models.StatusApproved}
+
+	filter := exportFilter(req, nil)
+	assert.Equal(t, bson.M{"tenant_id": "t1", "resource_type": "post", "status": models.StatusApproved}, filter)
+
+	after := &models.Comment{ID: primitive.NewObjectID(), CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	filter = exportFilter(req, after)
+	and, ok := filter["$and"].(bson.A)
+	require.True(t, ok, "resuming adds a cursor condition")
+	require.Len(t, and, 1)
+	assert.Equal(t, bson.A{
+		bson.M{"created_at": bson.M{"$gt": after.CreatedAt}},
+		bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$gt": after.ID}},
+	}, and[0].(bson.M)["$or"], "continues oldest first past the last exported comment")
+}
+
+func TestListFilterFlatView(t *testing.T) {
+	req := models.ListCommentsRequest{TenantID: "t1", ResourceType: "post", ResourceID: "p1", Status: models.StatusApproved}
+
+	_, rootsOnly := listFilter(req)["parent_id"]
+	assert.True(t, rootsOnly, "default view lists roots only")
+
+	req.View = models.ViewFlat
+	filter := listFilter(req)
+	_, hasParent := filter["parent_id"]
+	assert.False(t, hasParent, "flat view includes replies")
+	assert.Equal(t, models.StatusApproved, filter["status"])
+	assert.Equal(t, bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}, listOrder(req))
+}
+
+func TestListFilterLabel(t *testing.T) {
+	req := models.ListCommentsRequest{TenantID: "t1", ResourceType: "post", ResourceID: "p1"}
+
+	_, hasLabel := listFilter(req)["labels"]
+	assert.False(t, hasLabel, "no label filter by default")
+
+	// A scalar match on an array field selects comments carrying the label
+	req.Label = "off-topic"
+	assert.Equal(t, "off-topic", listFilter(req)["labels"])
+}
+
+func TestListSortStablePages(t *testing.T) {
+	// Comments created in the same millisecond, as on a busy resource
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	comments := make([]*models.Comment, 10)
+	for i := range comments {
+		comments[i] = &models.Comment{ID: primitive.NewObjectID(), CreatedAt: createdAt}
+	}
+	comments[3].IsPinned = true
+
+	// Compare the way MongoDB would for the fields the sort names
+	compare := map[string]func(a, b *models.Comment) int{
+		"sort_weight": func(a, b *models.Comment) int { return cmp.Compare(a.SortWeight, b.SortWeight) },
+		"is_pinned": func(a, b *models.Comment) int {
+			if a.IsPinned == b.IsPinned {
+				return 0
+			}
+			if a.IsPinned {
+				return 1
+			}
+			return -1
+		},
+		"created_at": func(a, b *models.Comment) int { return a.CreatedAt.Compare(b.CreatedAt) },
+		"_id":        func(a, b *models.Comment) int { return bytes.Compare(a.ID[:], b.ID[:]) },
+	}
+
+	for _, order := range []string{"desc", "asc"} {
+		t.Run(order, func(t *testing.T) {
+			spec := listSort("created_at", order)
+			rng := rand.New(rand.NewSource(1))
+
+			// Each page is a separate query; ties come back in whatever
+			// order the server happens to scan them
+			seen := map[primitive.ObjectID]int{}
+			const pageSize = 3
+			for skip := 0; skip < len(comments); skip += pageSize {
+				scan := slices.Clone(comments)
+				rng.Shuffle(len(scan), func(i, j int) { scan[i], scan[j] = scan[j], scan[i] })
+				slices.SortStableFunc(scan, func(a, b *models.Comment) int {
+					for _, key := range spec {
+						if c := compare[key.Key](a, b); c != 0 {
+							return c * key.Value.(int)
+						}
+					}
+					return 0
+				})
+
+				for _, c := range scan[skip:min(skip+pageSize, len(scan))] {
+					seen[c.ID]++
+				}
+			}
+
+			assert.Len(t, seen, len(comments), "no comment falls between pages")
+			for id, n := range seen {
+				assert.Equal(t, 1, n, "comment %s repeated across pages", id.Hex())
+			}
+		})
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	comment := &models.Comment{
+		ID:        primitive.NewObjectID(),
+		CreatedAt: time.Date(2025, 3, 1, 12, 30, 0, 123000000, time.UTC),
+	}
+
+	t.Run("Round Trip", func(t *testing.T) {
+		createdAt, id, err := decodeListCursor(encodeListCursor(comment))
+		require.NoError(t, err)
+		assert.True(t, comment.CreatedAt.Equal(createdAt))
+		assert.Equal(t, comment.ID, id)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, cursor := range []string{"", "!!!", "bm90LWEtY3Vyc29y", base64.RawURLEncoding.EncodeToString([]byte("123:nothex"))} {
+			_, _, err := decodeListCursor(cursor)
+			assert.EqualError(t, err, "invalid cursor", cursor)
+		}
+	})
+
+	t.Run("Filter Keeps Own Pending", func(t *testing.T) {
+		req := models.ListCommentsRequest{TenantID: "t1", Status: models.StatusApproved, PendingFor: "alice"}
+		filter := listFilter(req)
+		addCursorFilter(filter, comment.CreatedAt, comment.ID, cursorOrder(req))
+
+		assert.Contains(t, filter, "$or", "status $or is untouched")
+		and := filter["$and"].(bson.A)
+		require.Len(t, and, 1)
+		assert.Equal(t, bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": comment.CreatedAt}},
+			bson.M{"created_at": comment.CreatedAt, "_id": bson.M{"$lt": comment.ID}},
+		}}, and[0])
+	})
+
+	t.Run("Direction", func(t *testing.T) {
+		assert.Equal(t, -1, cursorOrder(models.ListCommentsRequest{}))
+		assert.Equal(t, 1, cursorOrder(models.ListCommentsRequest{SortOrder: "asc"}))
+		assert.Equal(t, 1, cursorOrder(models.ListCommentsRequest{View: models.ViewFlat, SortOrder: "desc"}))
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		comments := make([]*models.Comment, 3)
+		for i := range comments {
+			comments[i] = &models.Comment{ID: primitive.NewObjectID(), CreatedAt: comment.CreatedAt.Add(time.Duration(i) * time.Second)}
+		}
+
+		page, next := cursorPage(comments, 2)
+		assert.Len(t, page, 2)
+		assert.Equal(t, encodeListCursor(comments[1]), next)
+
+		page, next = cursorPage(comments[:2], 2)
+		assert.Len(t, page, 2)
+		assert.Empty(t, next, "no cursor on the last page")
+	})
+}
+
+func TestStalePendingFilter(t *testing.T) {
+	before := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	filter := stalePendingFilter("t1", "article", before, nil)
+	assert.NotContains(t, filter, "$and", "the first page starts at the oldest")
+	assert.Equal(t, bson.M{"$in": models.PendingStatuses}, filter["status"], "half-approved comments are swept too")
+
+	// Later pages start past the last comment seen, whether or not it was closed
+	last := &models.Comment{ID: primitive.NewObjectID(), CreatedAt: before.Add(-time.Hour)}
+	filter = stalePendingFilter("t1", "article", before, last)
+	assert.Equal(t, bson.M{"$lt": before}, filter["created_at"])
+	assert.Equal(t, bson.A{bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$gt": last.CreatedAt}},
+		bson.M{"created_at": last.CreatedAt, "_id": bson.M{"$gt": last.ID}},
+	}}}, filter["$and"])
+}
+
+func TestUpdateDocumentOmitsEditHistory(t *testing.T) {
+	comment := &models.Comment{
+		ID:          primitive.NewObjectID(),
+		Content:     "edited",
+		EditHistory: []models.EditRecord{{Content: "original"}},
+	}
+
+	doc, err := updateDocument(comment)
+	assert.NoError(t, err)
+	assert.NotContains(t, doc, "edit_history")
+	assert.Equal(t, "edited", doc["content"])
+}
+
+func TestGroupedCountsByStatus(t *testing.T) {
+	// Grouped results for a resource with comments in several statuses,
+	// including one this code has no constant for
+	results := []bson.M{
+		{"_id": string(models.StatusApproved), "count": int32(12)},
+		{"_id": string(models.StatusPending), "count": int32(3)},
+		{"_id": string(models.StatusRejected), "count": int32(2)},
+		{"_id": string(models.StatusSpam), "count": int32(1)},
+		{"_id": "withdrawn", "count": int32(1)},
+	}
+
+	counts, total := groupedCounts(results)
+
+	assert.Equal(t, map[string]int64{"approved": 12, "pending": 3, "rejected": 2, "spam": 1, "withdrawn": 1}, counts)
+	assert.Equal(t, int64(19), total)
+}
+
+func TestStampCreatedIsUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	defer func() { time.Local = local }()
+
+	comment := &models.Comment{}
+	stampCreated(comment)
+
+	assert.Equal(t, time.UTC, comment.CreatedAt.Location())
+	assert.Equal(t, time.UTC, comment.UpdatedAt.Location())
+
+	body, err := json.Marshal(comment)
+	assert.NoError(t, err)
+	assert.Regexp(t, `"createdAt":"[^"]+Z"`, string(body))
+}
+
+func TestTrimPage(t *testing.T) {
+	replies := func(n int) []*models.Comment {
+		items := make([]*models.Comment, n)
+		for i := range items {
+			items[i] = &models.Comment{ID: primitive.NewObjectID()}
+		}
+		return items
+	}
+
+	// A full page plus the extra reply: more remain
+	fetched := replies(6)
+	page, hasMore := trimPage(fetched, 5)
+	assert.True(t, hasMore)
+	assert.Equal(t, fetched[:5], page)
+
+	// Exactly a page: nothing follows
+	page, hasMore = trimPage(replies(5), 5)
+	assert.False(t, hasMore)
+	assert.Len(t, page, 5)
+
+	// A short last page
+	page, hasMore = trimPage(replies(2), 5)
+	assert.False(t, hasMore)
+	assert.Len(t, page, 2)
+
+	page, hasMore = trimPage(nil, 5)
+	assert.False(t, hasMore)
+	assert.Empty(t, page)
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HelpfulVoteRepository handles helpfulness vote data operations
+type HelpfulVoteRepository struct {
+	db         *database.MongoDB
+	collection *mongo.Collection
+}
+
+// NewHelpfulVoteRepository creates a new helpful vote repository
+func NewHelpfulVoteRepository(db *database.MongoDB) *HelpfulVoteRepository {
+	return &HelpfulVoteRepository{
+		db:         db,
+		collection: db.Collection("helpful_votes"),
+	}
+}
+
+// Upsert creates or updates a user's vote on a comment
+func (r *HelpfulVoteRepository) Upsert(ctx context.Context, vote *models.HelpfulVote) error {
+	filter := bson.M{
+		"comment_id": vote.CommentID,
+		"user_id":    vote.UserID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"type":       vote.Type,
+			"created_at": models.Now(),
+		},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	result, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return err
+	}
+
+	if result.UpsertedID != nil {
+		vote.ID = result.UpsertedID.(primitive.ObjectID)
+	}
+
+	return nil
+}
+
+// GetByUserAndComment retrieves a user's vote on a comment
+func (r *HelpfulVoteRepository) GetByUserAndComment(ctx context.Context, userID string, commentID primitive.ObjectID) (*models.HelpfulVote, error) {
+	var vote models.HelpfulVote
+	err := r.collection.FindOne(ctx, bson.M{
+		"comment_id": commentID,
+		"user_id":    userID,
+	}).Decode(&vote)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &vote, nil
+}
+
+// Delete removes a user's vote
+func (r *HelpfulVoteRepository) Delete(ctx context.Context, userID string, commentID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{
+		"comment_id": commentID,
+		"user_id":    userID,
+	})
+	return err
+}
+
+// DeleteByCommentID removes every vote on a comment
+func (r *HelpfulVoteRepository) DeleteByCommentID(ctx context.Context, commentID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"comment_id": commentID})
+	return err
+}
+
+// GetVoteCounts retrieves the helpful and not-helpful vote counts for a comment
+func (r *HelpfulVoteRepository) GetVoteCounts(ctx context.Context, commentID primitive.ObjectID) (int, int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"comment_id": commentID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$type",
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, 0, err
+	}
+
+	helpfulCount := 0
+	notHelpfulCount := 0
+
+	for _, result := range results {
+		voteType, _ := result["_id"].(string)
+		count := int(result["count"].(int32))
+
+		switch models.VoteType(voteType) {
+		case models.VoteHelpful:
+			helpfulCount = count
+		case models.VoteNotHelpful:
+			notHelpfulCount = count
+		}
+	}
+
+	return helpfulCount, notHelpfulCount, nil
+}
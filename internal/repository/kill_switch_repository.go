@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// KillSwitchRepository handles comment creation kill switches. Switches live
+// in MongoDB so a flip reaches every replica on its next request.
+type KillSwitchRepository struct {
+	db         *database.MongoDB
+	collection *mongo.Collection
+}
+
+// NewKillSwitchRepository creates a new kill switch repository
+func NewKillSwitchRepository(db *database.MongoDB) *KillSwitchRepository {
+	return &KillSwitchRepository{
+		db:         db,
+		collection: db.Collection("kill_switches"),
+	}
+}
+
+// Set turns a kill switch on or off, creating it if needed
+func (r *KillSwitchRepository) Set(ctx context.Context, scope, tenantID string, enabled bool, reason, updatedBy string) (*models.KillSwitch, error) {
+	filter := bson.M{
+		"scope":     scope,
+		"tenant_id": tenantID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"enabled":    enabled,
+			"reason":     reason,
+			"updated_by": updatedBy,
+			"updated_at": models.Now(),
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+
+	var killSwitch models.KillSwitch
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&killSwitch); err != nil {
+		return nil, err
+	}
+
+	return &killSwitch, nil
+}
+
+// GetForTenant retrieves the global switch and the tenant's own switch, if set
+func (r *KillSwitchRepository) GetForTenant(ctx context.Context, tenantID string) ([]*models.KillSwitch, error) {
+	cursor, err := r.collection.Find(ctx, killSwitchFilter(tenantID))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var switches []*models.KillSwitch
+	if err := cursor.All(ctx, &switches); err != nil {
+		return nil, err
+	}
+
+	return switches, nil
+}
+
+// CommentingDisabled reports whether the global or the tenant's switch is on
+func (r *KillSwitchRepository) CommentingDisabled(ctx context.Context, tenantID string) (bool, error) {
+	filter := killSwitchFilter(tenantID)
+	filter["enabled"] = true
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// killSwitchFilter matches the switches that apply to a tenant
+func killSwitchFilter(tenantID string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"scope": models.KillSwitchGlobal},
+			{"scope": models.KillSwitchTenant, "tenant_id": tenantID},
+		},
+	}
+}
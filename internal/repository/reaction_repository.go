@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"errors"
-	"time"
 
 	"github.com/minisource/comment/internal/database"
 	"github.com/minisource/comment/internal/models"
@@ -37,12 +36,14 @@ func (r *ReactionRepository) Upsert(ctx context.Context, reaction *models.Reacti
 	update := bson.M{
 		"$set": bson.M{
 			"type":       reaction.Type,
-			"created_at": time.Now(),
+			"created_at": models.Now(),
 		},
 	}
 
 	opts := options.Update().SetUpsert(true)
-	result, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	result, err := retryOnDuplicateKey(func() (*mongo.UpdateResult, error) {
+		return r.collection.UpdateOne(ctx, filter, update, opts)
+	})
 	if err != nil {
 		return err
 	}
@@ -54,6 +55,18 @@ func (r *ReactionRepository) Upsert(ctx context.Context, reaction *models.Reacti
 	return nil
 }
 
+// retryOnDuplicateKey runs an upsert and, if it lost a race with a concurrent
+// upsert of the same document (e.g. a double-click), runs it once more. The
+// retry matches the document the other request inserted and updates it, so
+// the caller sees the same result as if the requests had been serialized.
+func retryOnDuplicateKey(upsert func() (*mongo.UpdateResult, error)) (*mongo.UpdateResult, error) {
+	result, err := upsert()
+	if mongo.IsDuplicateKeyError(err) {
+		return upsert()
+	}
+	return result, err
+}
+
 // GetByUserAndComment retrieves a user's reaction to a comment
 func (r *ReactionRepository) GetByUserAndComment(ctx context.Context, userID string, commentID primitive.ObjectID) (*models.Reaction, error) {
 	var reaction models.Reaction
@@ -81,10 +94,17 @@ func (r *ReactionRepository) Delete(ctx context.Context, userID string, commentI
 	return err
 }
 
-// GetReactionCounts retrieves reaction counts for a comment
-func (r *ReactionRepository) GetReactionCounts(ctx context.Context, commentID primitive.ObjectID) (map[string]int, int, int, error) {
+// DeleteByCommentID removes every reaction on a comment
+func (r *ReactionRepository) DeleteByCommentID(ctx context.Context, commentID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"comment_id": commentID})
+	return err
+}
+
+// GetReactionCounts retrieves reaction counts for a comment, leaving out the
+// reaction of excludeUserID when it is set
+func (r *ReactionRepository) GetReactionCounts(ctx context.Context, commentID primitive.ObjectID, excludeUserID string) (map[string]int, int, int, error) {
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"comment_id": commentID}}},
+		{{Key: "$match", Value: reactionCountMatch(commentID, excludeUserID)}},
 		{{Key: "$group", Value: bson.M{
 			"_id":   "$type",
 			"count": bson.M{"$sum": 1},
@@ -121,6 +141,15 @@ func (r *ReactionRepository) GetReactionCounts(ctx context.Context, commentID pr
 	return counts, likeCount, dislikeCount, nil
 }
 
+// reactionCountMatch selects the reactions counted for a comment
+func reactionCountMatch(commentID primitive.ObjectID, excludeUserID string) bson.M {
+	match := bson.M{"comment_id": commentID}
+	if excludeUserID != "" {
+		match["user_id"] = bson.M{"$ne": excludeUserID}
+	}
+	return match
+}
+
 // GetUserReactions retrieves all reactions by a user for a list of comments
 func (r *ReactionRepository) GetUserReactions(ctx context.Context, userID string, commentIDs []primitive.ObjectID) (map[primitive.ObjectID]*models.ReactionType, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeUniqueStore emulates an upsert against a unique index: the lookup and
+// the insert are separate steps, so two first-time upserts can both miss the
+// lookup and race to insert
+type fakeUniqueStore struct {
+	mu   sync.Mutex
+	docs map[string]string
+}
+
+func (s *fakeUniqueStore) upsert(key, value string, lookedUp func()) (*mongo.UpdateResult, error) {
+	s.mu.Lock()
+	_, exists := s.docs[key]
+	s.mu.Unlock()
+
+	lookedUp()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !exists {
+		if _, taken := s.docs[key]; taken {
+			return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}}}
+		}
+		s.docs[key] = value
+		return &mongo.UpdateResult{UpsertedCount: 1}, nil
+	}
+	s.docs[key] = value
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func TestReactionCountMatch(t *testing.T) {
+	commentID := primitive.NewObjectID()
+
+	assert.Equal(t, bson.M{"comment_id": commentID}, reactionCountMatch(commentID, ""))
+	assert.Equal(t, bson.M{"comment_id": commentID, "user_id": bson.M{"$ne": "alice"}}, reactionCountMatch(commentID, "alice"))
+}
+
+func TestRetryOnDuplicateKeyConcurrentUpserts(t *testing.T) {
+	store := &fakeUniqueStore{docs: make(map[string]string)}
+
+	// Both requests look up before either inserts, forcing the race
+	var lookups sync.WaitGroup
+	lookups.Add(2)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			first := true
+			_, errs[i] = retryOnDuplicateKey(func() (*mongo.UpdateResult, error) {
+				return store.upsert("comment-1:user-1", "like", func() {
+					if first {
+						first = false
+						lookups.Done()
+						lookups.Wait()
+					}
+				})
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Len(t, store.docs, 1, "a single reaction")
+	assert.Equal(t, "like", store.docs["comment-1:user-1"])
+}
+
+func TestRetryOnDuplicateKeyOtherErrors(t *testing.T) {
+	calls := 0
+	_, err := retryOnDuplicateKey(func() (*mongo.UpdateResult, error) {
+		calls++
+		return nil, errors.New("connection reset")
+	})
+
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, 1, calls, "only duplicate-key errors are retried")
+}
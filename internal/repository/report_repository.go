@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"errors"
-	"time"
 
 	"github.com/minisource/comment/internal/database"
 	"github.com/minisource/comment/internal/models"
@@ -29,8 +28,8 @@ func NewReportRepository(db *database.MongoDB) *ReportRepository {
 
 // Create inserts a new report
 func (r *ReportRepository) Create(ctx context.Context, report *models.Report) error {
-	report.CreatedAt = time.Now()
-	report.Status = "pending"
+	report.CreatedAt = models.Now()
+	report.Status = models.ReportPending
 
 	result, err := r.collection.InsertOne(ctx, report)
 	if err != nil {
@@ -45,25 +44,49 @@ func (r *ReportRepository) Create(ctx context.Context, report *models.Report) er
 	return nil
 }
 
-// GetByCommentID retrieves reports for a comment
-func (r *ReportRepository) GetByCommentID(ctx context.Context, commentID primitive.ObjectID) ([]*models.Report, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{"comment_id": commentID})
+// GetByCommentID retrieves a page of reports for a comment, newest first
+// unless sortOrder is "asc"
+func (r *ReportRepository) GetByCommentID(ctx context.Context, commentID primitive.ObjectID, page, pageSize int, sortOrder string) ([]*models.Report, int64, error) {
+	filter := bson.M{"comment_id": commentID}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	order := -1
+	if sortOrder == "asc" {
+		order = 1
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: order}, {Key: "_id", Value: order}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer cursor.Close(ctx)
 
 	var reports []*models.Report
 	if err := cursor.All(ctx, &reports); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return reports, nil
+	return reports, total, nil
 }
 
 // GetPending retrieves pending reports
 func (r *ReportRepository) GetPending(ctx context.Context, page, pageSize int) ([]*models.Report, int64, error) {
-	filter := bson.M{"status": "pending"}
+	filter := bson.M{"status": models.ReportPending}
 
 	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -78,7 +101,7 @@ func (r *ReportRepository) GetPending(ctx context.Context, page, pageSize int) (
 	}
 
 	findOptions := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
 		SetSkip(int64((page - 1) * pageSize)).
 		SetLimit(int64(pageSize))
 
@@ -98,7 +121,7 @@ func (r *ReportRepository) GetPending(ctx context.Context, page, pageSize int) (
 
 // UpdateStatus updates the status of a report
 func (r *ReportRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status, reviewedBy string) error {
-	now := time.Now()
+	now := models.Now()
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -113,7 +136,98 @@ func (r *ReportRepository) UpdateStatus(ctx context.Context, id primitive.Object
 	return err
 }
 
+// GetByID retrieves a report by ID
+func (r *ReportRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Report, error) {
+	var report models.Report
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Reopen sets a reviewed or dismissed report back to pending and clears its
+// review. It reports false when the report wasn't resolved, for example
+// because another moderator reopened it first.
+func (r *ReportRepository) Reopen(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":    id,
+			"status": bson.M{"$in": bson.A{models.ReportReviewed, models.ReportDismissed}},
+		},
+		bson.M{
+			"$set":   bson.M{"status": models.ReportPending},
+			"$unset": bson.M{"reviewed_by": "", "reviewed_at": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
 // CountByCommentID counts reports for a comment
 func (r *ReportRepository) CountByCommentID(ctx context.Context, commentID primitive.ObjectID) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{"comment_id": commentID})
 }
+
+// DeleteByCommentID removes every report on a comment
+func (r *ReportRepository) DeleteByCommentID(ctx context.Context, commentID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"comment_id": commentID})
+	return err
+}
+
+// GetReasonBreakdown counts reports for a comment grouped by reason
+func (r *ReportRepository) GetReasonBreakdown(ctx context.Context, commentID primitive.ObjectID) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"comment_id": commentID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$reason",
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Reason string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	breakdown := make(map[string]int64, len(results))
+	for _, result := range results {
+		breakdown[result.Reason] = result.Count
+	}
+
+	return breakdown, nil
+}
+
+// GetCommentsWithPendingReports returns which of the given comments still have unresolved reports
+func (r *ReportRepository) GetCommentsWithPendingReports(ctx context.Context, commentIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	ids, err := r.collection.Distinct(ctx, "comment_id", bson.M{
+		"comment_id": bson.M{"$in": commentIDs},
+		"status":     models.ReportPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			reported[oid] = true
+		}
+	}
+	return reported, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResourceCounterRepository handles denormalized per-resource comment counts
+type ResourceCounterRepository struct {
+	db         *database.MongoDB
+	collection *mongo.Collection
+}
+
+// NewResourceCounterRepository creates a new resource counter repository
+func NewResourceCounterRepository(db *database.MongoDB) *ResourceCounterRepository {
+	return &ResourceCounterRepository{
+		db:         db,
+		collection: db.Collection("resource_counters"),
+	}
+}
+
+// counterFilter matches a resource's counter
+func counterFilter(tenantID, resourceType, resourceID string) bson.M {
+	return bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+	}
+}
+
+// Get retrieves a resource's live comment count; ok is false if the resource
+// has no counter yet
+func (r *ResourceCounterRepository) Get(ctx context.Context, tenantID, resourceType, resourceID string) (int64, bool, error) {
+	var counter models.ResourceCounter
+	err := r.collection.FindOne(ctx, counterFilter(tenantID, resourceType, resourceID)).Decode(&counter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return counter.Count, true, nil
+}
+
+// Increment adds delta to an existing counter with $inc, so concurrent
+// changes never overwrite each other. It reports whether the counter existed;
+// missing counters are left for Seed, since a count starting from zero would
+// be wrong for resources with older comments.
+func (r *ResourceCounterRepository) Increment(ctx context.Context, tenantID, resourceType, resourceID string, delta int64) (bool, error) {
+	update := bson.M{
+		"$inc": bson.M{"count": delta},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, counterFilter(tenantID, resourceType, resourceID), update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Seed creates a missing counter from a fresh count. $max keeps the highest
+// count when concurrent creates race to seed the same counter.
+func (r *ResourceCounterRepository) Seed(ctx context.Context, tenantID, resourceType, resourceID string, count int64) error {
+	update := bson.M{
+		"$max": bson.M{"count": count},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, counterFilter(tenantID, resourceType, resourceID), update, opts)
+	return err
+}
+
+// Set overwrites a resource's counter with a recount
+func (r *ResourceCounterRepository) Set(ctx context.Context, tenantID, resourceType, resourceID string, count int64) error {
+	update := bson.M{
+		"$set": bson.M{"count": count, "updated_at": time.Now().UTC()},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, counterFilter(tenantID, resourceType, resourceID), update, opts)
+	return err
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResourceViewRepository handles per-user last-seen tracking for resources
+type ResourceViewRepository struct {
+	db         *database.MongoDB
+	collection *mongo.Collection
+}
+
+// NewResourceViewRepository creates a new resource view repository
+func NewResourceViewRepository(db *database.MongoDB) *ResourceViewRepository {
+	return &ResourceViewRepository{
+		db:         db,
+		collection: db.Collection("resource_views"),
+	}
+}
+
+// MarkSeen records that a user has seen a resource's comments up to seenAt
+func (r *ResourceViewRepository) MarkSeen(ctx context.Context, tenantID, resourceType, resourceID, userID string, seenAt time.Time) error {
+	filter := bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"user_id":       userID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{"last_seen_at": seenAt},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+// GetLastSeen retrieves when a user last saw a resource's comments, nil if never
+func (r *ResourceViewRepository) GetLastSeen(ctx context.Context, tenantID, resourceType, resourceID, userID string) (*time.Time, error) {
+	var view models.ResourceView
+	err := r.collection.FindOne(ctx, bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+		"user_id":       userID,
+	}).Decode(&view)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &view.LastSeenAt, nil
+}
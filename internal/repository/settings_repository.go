@@ -3,8 +3,8 @@ package repository
 import (
 	"context"
 	"errors"
-	"time"
 
+	"github.com/minisource/comment/config"
 	"github.com/minisource/comment/internal/database"
 	"github.com/minisource/comment/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,13 +17,16 @@ import (
 type SettingsRepository struct {
 	db         *database.MongoDB
 	collection *mongo.Collection
+	defaults   config.ModerationConfig
 }
 
-// NewSettingsRepository creates a new settings repository
-func NewSettingsRepository(db *database.MongoDB) *SettingsRepository {
+// NewSettingsRepository creates a new settings repository. New settings take
+// their moderation defaults from defaults.
+func NewSettingsRepository(db *database.MongoDB, defaults config.ModerationConfig) *SettingsRepository {
 	return &SettingsRepository{
 		db:         db,
 		collection: db.Collection("settings"),
+		defaults:   defaults,
 	}
 }
 
@@ -38,26 +41,7 @@ func (r *SettingsRepository) GetOrCreate(ctx context.Context, tenantID, resource
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			// Create default settings
-			settings = models.CommentSettings{
-				TenantID:            tenantID,
-				ResourceType:        resourceType,
-				RequireApproval:     true,
-				AllowAnonymous:      false,
-				AllowReplies:        true,
-				MaxReplyDepth:       5,
-				AllowReactions:      true,
-				AllowedReactions:    []models.ReactionType{models.ReactionLike, models.ReactionDislike, models.ReactionLove, models.ReactionHaha, models.ReactionWow, models.ReactionSad, models.ReactionAngry},
-				AllowAttachments:    false,
-				MaxAttachments:      3,
-				MaxCommentLength:    5000,
-				CommentsEnabled:     true,
-				NotifyOnNewComment:  true,
-				NotifyOnReply:       true,
-				AutoApproveVerified: false,
-				BadWordsFilter:      true,
-				CreatedAt:           time.Now(),
-				UpdatedAt:           time.Now(),
-			}
+			settings = defaultSettings(tenantID, resourceType, r.defaults)
 
 			result, err := r.collection.InsertOne(ctx, settings)
 			if err != nil {
@@ -76,6 +60,53 @@ func (r *SettingsRepository) GetOrCreate(ctx context.Context, tenantID, resource
 	return &settings, nil
 }
 
+// GetEffective retrieves stored settings, or the defaults without storing
+// them, so unauthenticated reads never create documents
+func (r *SettingsRepository) GetEffective(ctx context.Context, tenantID, resourceType string) (*models.CommentSettings, error) {
+	var settings models.CommentSettings
+	err := r.collection.FindOne(ctx, bson.M{
+		"tenant_id":     tenantID,
+		"resource_type": resourceType,
+	}).Decode(&settings)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		settings = defaultSettings(tenantID, resourceType, r.defaults)
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// defaultSettings returns the settings a tenant's resource type starts with,
+// honoring the service-wide moderation configuration
+func defaultSettings(tenantID, resourceType string, cfg config.ModerationConfig) models.CommentSettings {
+	return models.CommentSettings{
+		TenantID:                tenantID,
+		ResourceType:            resourceType,
+		RequireApproval:         cfg.RequireApproval,
+		AllowAnonymous:          cfg.AllowAnonymous,
+		AllowReplies:            true,
+		MaxReplyDepth:           cfg.MaxReplyDepth,
+		AllowReactions:          true,
+		AllowSelfReaction:       true,
+		AllowedReactions:        []models.ReactionType{models.ReactionLike, models.ReactionDislike, models.ReactionLove, models.ReactionHaha, models.ReactionWow, models.ReactionSad, models.ReactionAngry},
+		AllowAttachments:        false,
+		MaxAttachments:          3,
+		MaxCommentLength:        cfg.MaxCommentLength,
+		CommentsEnabled:         true,
+		NotifyOnNewComment:      true,
+		NotifyOnReply:           true,
+		AutoApproveVerified:     false,
+		BadWordsFilter:          true,
+		RenderHTML:              true,
+		LowInfoAction:           models.ActionReject,
+		AutoHideReportThreshold: 5,
+		CreatedAt:               models.Now(),
+		UpdatedAt:               models.Now(),
+	}
+}
+
 // Update updates settings
 func (r *SettingsRepository) Update(ctx context.Context, tenantID, resourceType string, req models.SettingsRequest) (*models.CommentSettings, error) {
 	filter := bson.M{
@@ -83,23 +114,38 @@ func (r *SettingsRepository) Update(ctx context.Context, tenantID, resourceType
 		"resource_type": resourceType,
 	}
 
-	update := bson.M{"updated_at": time.Now()}
+	update := bson.M{"updated_at": models.Now()}
 
 	if req.RequireApproval != nil {
 		update["require_approval"] = *req.RequireApproval
 	}
+	if req.DualModeration != nil {
+		update["dual_moderation"] = *req.DualModeration
+	}
 	if req.AllowAnonymous != nil {
 		update["allow_anonymous"] = *req.AllowAnonymous
 	}
+	if req.AnonymousAllowName != nil {
+		update["anonymous_allow_name"] = *req.AnonymousAllowName
+	}
+	if req.AnonymousRequireName != nil {
+		update["anonymous_require_name"] = *req.AnonymousRequireName
+	}
 	if req.AllowReplies != nil {
 		update["allow_replies"] = *req.AllowReplies
 	}
+	if req.CascadeDelete != nil {
+		update["cascade_delete"] = *req.CascadeDelete
+	}
 	if req.MaxReplyDepth != nil {
 		update["max_reply_depth"] = *req.MaxReplyDepth
 	}
 	if req.AllowReactions != nil {
 		update["allow_reactions"] = *req.AllowReactions
 	}
+	if req.AllowSelfReaction != nil {
+		update["allow_self_reaction"] = *req.AllowSelfReaction
+	}
 	if req.AllowedReactions != nil {
 		update["allowed_reactions"] = req.AllowedReactions
 	}
@@ -109,6 +155,12 @@ func (r *SettingsRepository) Update(ctx context.Context, tenantID, resourceType
 	if req.MaxAttachments != nil {
 		update["max_attachments"] = *req.MaxAttachments
 	}
+	if req.MaxAttachmentSize != nil {
+		update["max_attachment_size"] = *req.MaxAttachmentSize
+	}
+	if req.MaxTotalAttachmentSize != nil {
+		update["max_total_attachment_size"] = *req.MaxTotalAttachmentSize
+	}
 	if req.MaxCommentLength != nil {
 		update["max_comment_length"] = *req.MaxCommentLength
 	}
@@ -127,9 +179,84 @@ func (r *SettingsRepository) Update(ctx context.Context, tenantID, resourceType
 	if req.BadWordsFilter != nil {
 		update["bad_words_filter"] = *req.BadWordsFilter
 	}
+	if req.RenderHTML != nil {
+		update["render_html"] = *req.RenderHTML
+	}
 	if req.CustomBadWords != nil {
 		update["custom_bad_words"] = req.CustomBadWords
 	}
+	if req.RejectLowInfoComments != nil {
+		update["reject_low_info_comments"] = *req.RejectLowInfoComments
+	}
+	if req.ReModerateAfterEdits != nil {
+		update["re_moderate_after_edits"] = *req.ReModerateAfterEdits
+	}
+	if req.LockEditWhilePending != nil {
+		update["lock_edit_while_pending"] = *req.LockEditWhilePending
+	}
+	if req.LockEditsAfterReply != nil {
+		update["lock_edits_after_reply"] = *req.LockEditsAfterReply
+	}
+	if req.EditWindowSeconds != nil {
+		update["edit_window_seconds"] = *req.EditWindowSeconds
+	}
+	if req.MaxPendingPerAuthor != nil {
+		update["max_pending_per_author"] = *req.MaxPendingPerAuthor
+	}
+	if req.AutoHideReportThreshold != nil {
+		update["auto_hide_report_threshold"] = *req.AutoHideReportThreshold
+	}
+	if req.MaxRootComments != nil {
+		update["max_root_comments"] = *req.MaxRootComments
+	}
+	if req.MaxRootCommentsAction != nil {
+		update["max_root_comments_action"] = *req.MaxRootCommentsAction
+	}
+	if req.LowInfoAction != nil {
+		update["low_info_action"] = *req.LowInfoAction
+	}
+	if req.RejectParentEchoes != nil {
+		update["reject_parent_echoes"] = *req.RejectParentEchoes
+	}
+	if req.ParentEchoAction != nil {
+		update["parent_echo_action"] = *req.ParentEchoAction
+	}
+	if req.ParentEchoThreshold != nil {
+		update["parent_echo_threshold"] = *req.ParentEchoThreshold
+	}
+	if req.PendingAutoCloseHours != nil {
+		update["pending_auto_close_hours"] = *req.PendingAutoCloseHours
+	}
+	if req.PendingAutoCloseAction != nil {
+		update["pending_auto_close_action"] = *req.PendingAutoCloseAction
+	}
+	if req.BlockDisposableEmails != nil {
+		update["block_disposable_emails"] = *req.BlockDisposableEmails
+	}
+	if req.BlockedCountries != nil {
+		update["blocked_countries"] = req.BlockedCountries
+	}
+	if req.AllowedCountries != nil {
+		update["allowed_countries"] = req.AllowedCountries
+	}
+	if req.AllowedOrigins != nil {
+		update["allowed_origins"] = req.AllowedOrigins
+	}
+	if req.AllowedScripts != nil {
+		update["allowed_scripts"] = req.AllowedScripts
+	}
+	if req.ExpectedLanguage != nil {
+		update["expected_language"] = *req.ExpectedLanguage
+	}
+	if req.LanguageMismatchAction != nil {
+		update["language_mismatch_action"] = *req.LanguageMismatchAction
+	}
+	if req.ModerationLabels != nil {
+		update["moderation_labels"] = req.ModerationLabels
+	}
+	if req.Webhook != nil {
+		update["webhook"] = req.Webhook
+	}
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
 
@@ -157,3 +284,19 @@ func (r *SettingsRepository) GetByTenant(ctx context.Context, tenantID string) (
 
 	return settings, nil
 }
+
+// GetWithPendingAutoClose retrieves all settings that auto-close stale pending comments
+func (r *SettingsRepository) GetWithPendingAutoClose(ctx context.Context) ([]*models.CommentSettings, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"pending_auto_close_hours": bson.M{"$gt": 0}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var settings []*models.CommentSettings
+	if err := cursor.All(ctx, &settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/minisource/comment/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSettingsFromConfig(t *testing.T) {
+	t.Setenv("MODERATION_REQUIRE_APPROVAL", "false")
+	t.Setenv("MODERATION_ALLOW_ANONYMOUS", "true")
+	t.Setenv("MODERATION_MAX_COMMENT_LENGTH", "280")
+	t.Setenv("MODERATION_MAX_REPLY_DEPTH", "2")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	settings := defaultSettings("shop", "product", cfg.Moderation)
+
+	assert.Equal(t, "shop", settings.TenantID)
+	assert.Equal(t, "product", settings.ResourceType)
+	assert.False(t, settings.RequireApproval)
+	assert.True(t, settings.AllowAnonymous)
+	assert.Equal(t, 280, settings.MaxCommentLength)
+	assert.Equal(t, 2, settings.MaxReplyDepth)
+	assert.True(t, settings.CommentsEnabled)
+	assert.True(t, settings.RenderHTML)
+	assert.True(t, settings.AllowSelfReaction)
+	assert.Equal(t, 5, settings.AutoHideReportThreshold)
+}
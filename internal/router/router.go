@@ -1,13 +1,17 @@
 package router
 
 import (
+	"context"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
 	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/cache"
+	"github.com/minisource/comment/internal/client"
 	"github.com/minisource/comment/internal/database"
 	"github.com/minisource/comment/internal/handler"
 	"github.com/minisource/comment/internal/middleware"
@@ -15,6 +19,7 @@ import (
 	"github.com/minisource/comment/internal/usecase"
 	"github.com/minisource/go-common/logging"
 	"github.com/minisource/go-sdk/auth"
+	"github.com/redis/go-redis/v9"
 )
 
 // Router holds all dependencies for routing
@@ -22,10 +27,15 @@ type Router struct {
 	app             *fiber.App
 	cfg             *config.Config
 	db              *database.MongoDB
+	redis           *redis.Client
 	logger          logging.Logger
 	commentHandler  *handler.CommentHandler
 	reactionHandler *handler.ReactionHandler
+	voteHandler     *handler.HelpfulVoteHandler
+	reportHandler   *handler.ReportHandler
 	adminHandler    *handler.AdminHandler
+	settingsHandler *handler.SettingsHandler
+	killHandler     *handler.KillSwitchHandler
 	healthHandler   *handler.HealthHandler
 }
 
@@ -34,29 +44,90 @@ func NewRouter(cfg *config.Config, db *database.MongoDB, logger logging.Logger)
 	// Create repositories
 	commentRepo := repository.NewCommentRepository(db)
 	reactionRepo := repository.NewReactionRepository(db)
+	voteRepo := repository.NewHelpfulVoteRepository(db)
 	reportRepo := repository.NewReportRepository(db)
-	settingsRepo := repository.NewSettingsRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db, cfg.Moderation)
+	viewRepo := repository.NewResourceViewRepository(db)
+	counterRepo := repository.NewResourceCounterRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	killSwitchRepo := repository.NewKillSwitchRepository(db)
 
 	// Create notifier client (placeholder)
 	var notifierClient usecase.NotifierClient = nil
 
+	// Create webhook client (disabled without WEBHOOK_URL)
+	var webhookClient usecase.WebhookDispatcher
+	if cfg.Webhook.URL != "" {
+		webhookClient = client.NewWebhookClient(cfg.Webhook.URL, cfg.Webhook.Secret, cfg.Webhook.Timeout, cfg.Webhook.MaxRetries, cfg.Webhook.RetryBackoff)
+	}
+
+	// Create geo resolver (disabled without GEO_LOOKUP_URL, which disables geoblocking)
+	var geoResolver usecase.GeoResolver
+	if cfg.Geo.LookupURL != "" {
+		geoResolver = client.NewGeoClient(cfg.Geo.LookupURL, cfg.Geo.CountryField, cfg.Geo.Timeout)
+	}
+
+	// Create translation provider (placeholder, disables the language check)
+	var translator usecase.TranslationProvider = nil
+
+	// Create resource validators per resource type (none configured, any resource ID is accepted)
+	var resourceValidators map[string]usecase.ResourceValidator
+
+	// Connect to Redis if anything uses it; without it reads go to MongoDB
+	// and rate limits are kept in memory
+	var redisClient *redis.Client
+	if cfg.Redis.CacheEnabled || cfg.Moderation.RateLimitBackend == middleware.RateLimitBackendRedis {
+		client, err := database.NewRedis(cfg.Redis)
+		if err != nil {
+			logger.Warn(logging.General, logging.Startup, "Redis unavailable, falling back to MongoDB reads and in-memory rate limits", map[logging.ExtraKey]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			redisClient = client
+		}
+	}
+
+	// Create read cache (optional)
+	var readCache usecase.Cache
+	if cfg.Redis.CacheEnabled && redisClient != nil {
+		readCache = cache.NewRedisCache(redisClient, cfg.Redis.CacheTTL)
+	}
+
 	// Create usecases
-	commentUsecase := usecase.NewCommentUsecase(commentRepo, reactionRepo, reportRepo, settingsRepo, notifierClient, cfg)
-	reactionUsecase := usecase.NewReactionUsecase(commentRepo, reactionRepo)
+	commentUsecase := usecase.NewCommentUsecase(commentRepo, reactionRepo, reportRepo, voteRepo, settingsRepo, viewRepo, counterRepo, auditRepo, notifierClient, webhookClient, geoResolver, translator, resourceValidators, killSwitchRepo, readCache, cfg)
+	reactionUsecase := usecase.NewReactionUsecase(commentRepo, reactionRepo, settingsRepo, readCache, webhookClient, cfg.Reactions.CountRefreshInterval)
+	voteUsecase := usecase.NewHelpfulVoteUsecase(commentRepo, voteRepo, readCache)
+	settingsUsecase := usecase.NewSettingsUsecase(settingsRepo)
+	killSwitchUsecase := usecase.NewKillSwitchUsecase(killSwitchRepo, cfg.Auth.OperatorUserIDs)
+	reportUsecase := usecase.NewReportUsecase(commentRepo, reportRepo, settingsRepo, auditRepo, notifierClient, readCache, cfg)
+
+	// Auto-close stale pending comments per tenant policy
+	if cfg.Moderation.PendingSweepInterval > 0 {
+		go commentUsecase.RunPendingSweep(context.Background(), cfg.Moderation.PendingSweepInterval)
+	}
 
 	// Create handlers
 	commentHandler := handler.NewCommentHandler(commentUsecase)
 	reactionHandler := handler.NewReactionHandler(reactionUsecase)
-	adminHandler := handler.NewAdminHandler(commentUsecase)
+	voteHandler := handler.NewHelpfulVoteHandler(voteUsecase)
+	reportHandler := handler.NewReportHandler(reportUsecase)
+	adminHandler := handler.NewAdminHandler(commentUsecase, reportUsecase)
+	settingsHandler := handler.NewSettingsHandler(settingsUsecase)
+	killHandler := handler.NewKillSwitchHandler(killSwitchUsecase)
 	healthHandler := handler.NewHealthHandler(db)
 
 	return &Router{
 		cfg:             cfg,
 		db:              db,
+		redis:           redisClient,
 		logger:          logger,
 		commentHandler:  commentHandler,
 		reactionHandler: reactionHandler,
+		voteHandler:     voteHandler,
+		reportHandler:   reportHandler,
 		adminHandler:    adminHandler,
+		settingsHandler: settingsHandler,
+		killHandler:     killHandler,
 		healthHandler:   healthHandler,
 	}
 }
@@ -71,13 +142,17 @@ func (r *Router) Setup() *fiber.App {
 
 	// Global middleware
 	r.app.Use(recover.New())
+	if r.cfg.Server.Compression {
+		r.app.Use(compress.New())
+	}
 	r.app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Tenant-ID",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Tenant-ID, X-Impersonate-User",
 		AllowMethods: "GET, POST, PUT, PATCH, DELETE, OPTIONS",
 	}))
 	r.app.Use(middleware.LoggingMiddleware(r.logger))
 	r.app.Use(middleware.TenantMiddleware())
+	r.app.Use(middleware.CompactJSONMiddleware())
 
 	// Swagger route
 	r.app.Get("/swagger/*", swagger.HandlerDefault)
@@ -96,16 +171,19 @@ func (r *Router) Setup() *fiber.App {
 		AuthClient:   authClient,
 		SkipPaths:    []string{"/health", "/ready", "/live"},
 		RequireAdmin: []string{"/api/v1/admin"},
+		PublicReads:  r.cfg.Auth.PublicReadPaths,
 	})
 
 	// API routes
-	api := r.app.Group("/api/v1", authMiddleware)
+	api := r.app.Group("/api/v1", authMiddleware, middleware.RequireJSONMiddleware())
 
 	// Rate limiting for comment creation
 	rateLimiter := middleware.RateLimitMiddleware(middleware.RateLimitConfig{
 		Max:     r.cfg.Moderation.RateLimitPerMinute,
 		Window:  time.Minute,
 		KeyFunc: middleware.DefaultRateLimitKeyFunc,
+		Backend: r.cfg.Moderation.RateLimitBackend,
+		Redis:   r.redis,
 	})
 
 	// Comment routes
@@ -114,24 +192,72 @@ func (r *Router) Setup() *fiber.App {
 	comments.Get("/", r.commentHandler.List)
 	comments.Get("/search", r.commentHandler.Search)
 	comments.Get("/stats", r.commentHandler.GetStats)
+	comments.Get("/newer", r.commentHandler.GetNewer)
+	comments.Get("/tree", r.commentHandler.GetTree)
+	comments.Get("/config", r.settingsHandler.GetPublic)
+	comments.Post("/seen", r.commentHandler.MarkSeen)
+	comments.Post("/reactions/me", r.reactionHandler.GetUserReactions)
 	comments.Get("/:id", r.commentHandler.Get)
 	comments.Put("/:id", r.commentHandler.Update)
 	comments.Delete("/:id", r.commentHandler.Delete)
 	comments.Get("/:id/replies", r.commentHandler.GetReplies)
+	comments.Get("/:id/thread", r.commentHandler.GetThread)
+	comments.Get("/:id/history", r.commentHandler.GetHistory)
 
 	// Reaction routes
 	comments.Post("/:id/reactions", r.reactionHandler.AddReaction)
+	comments.Post("/:id/reactions/toggle", r.reactionHandler.ToggleReaction)
 	comments.Delete("/:id/reactions", r.reactionHandler.RemoveReaction)
 	comments.Get("/:id/reactions/me", r.reactionHandler.GetUserReaction)
 
+	// Helpfulness vote routes
+	comments.Post("/:id/helpful", r.voteHandler.Vote)
+	comments.Delete("/:id/helpful", r.voteHandler.Unvote)
+	comments.Get("/:id/helpful/me", r.voteHandler.GetUserVote)
+
+	// Report routes
+	comments.Post("/:id/report", r.reportHandler.Create)
+
 	// Admin routes
 	admin := api.Group("/admin")
 	adminComments := admin.Group("/comments")
+	adminComments.Get("/", r.adminHandler.ListComments)
 	adminComments.Get("/pending", r.adminHandler.GetPendingComments)
+	adminComments.Get("/export", r.adminHandler.ExportComments)
+	adminComments.Get("/stream-list", r.adminHandler.StreamComments)
+	adminComments.Get("/status-counts", r.adminHandler.GetStatusCounts)
+	adminComments.Get("/:id", r.adminHandler.GetComment)
+	adminComments.Get("/:id/reports", r.adminHandler.GetCommentReports)
+	adminComments.Get("/:id/moderation-history", r.adminHandler.GetModerationHistory)
 	adminComments.Post("/:id/moderate", r.adminHandler.ModerateComment)
 	adminComments.Post("/:id/pin", r.adminHandler.PinComment)
+	adminComments.Post("/:id/lock-reactions", r.adminHandler.LockReactions)
+	adminComments.Post("/:id/sort-weight", r.adminHandler.SetSortWeight)
+	adminComments.Post("/:id/labels", r.adminHandler.AddLabels)
+	adminComments.Delete("/:id/labels", r.adminHandler.RemoveLabels)
+	adminComments.Post("/:id/restore", r.adminHandler.RestoreComment)
 	adminComments.Delete("/:id", r.adminHandler.HardDelete)
 	adminComments.Post("/bulk-moderate", r.adminHandler.BulkModerate)
+	adminComments.Post("/bulk-delete", r.adminHandler.BulkDelete)
+	adminComments.Post("/merge", r.adminHandler.MergeComments)
+	adminComments.Post("/recount", r.adminHandler.RecountResource)
+
+	// Report routes
+	admin.Post("/reports/:id/reopen", r.adminHandler.ReopenReport)
+
+	// Author routes
+	admin.Get("/authors/:id/summary", r.adminHandler.GetAuthorSummary)
+
+	// Settings routes
+	adminSettings := admin.Group("/settings")
+	adminSettings.Get("/", r.settingsHandler.Get)
+	adminSettings.Put("/", r.settingsHandler.Update)
+	adminSettings.Get("/all", r.settingsHandler.List)
+
+	// Kill switches for comment creation
+	admin.Get("/kill-switch", r.killHandler.Get)
+	admin.Put("/kill-switch", r.killHandler.SetTenant)
+	admin.Put("/kill-switch/global", r.killHandler.SetGlobal)
 
 	return r.app
 }
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Cache interface for caching comment reads. Implementations own the TTL and
+// treat backend failures as misses, so a cache outage only costs latency.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte)
+	Delete(ctx context.Context, keys ...string)
+}
+
+// cachedPage is the stored form of a cached comment listing
+type cachedPage struct {
+	Comments []*models.Comment `bson:"comments"`
+	Total    int64             `bson:"total"`
+}
+
+// commentCacheKey is the cache key for a single comment
+func commentCacheKey(id string) string {
+	return "comment:" + id
+}
+
+// listCacheKey is the cache key for a page of a resource's comments
+func listCacheKey(tenantID, resourceType, resourceID string, page int) string {
+	return fmt.Sprintf("comments:%s:%s:%s:%d", tenantID, resourceType, resourceID, page)
+}
+
+// isCacheableList reports whether a (normalized) list request is the default
+// public first page of a resource. Anything filtered, re-sorted, per-user or
+// cursor-paginated goes straight to Mongo.
+func isCacheableList(req models.ListCommentsRequest) bool {
+	return req.TenantID != "" && req.ResourceType != "" && req.ResourceID != "" &&
+		req.Page <= 1 &&
+		(req.PageSize == 0 || req.PageSize == models.DefaultPageSize) &&
+		req.Status == models.StatusApproved &&
+		req.ParentID == "" && req.AuthorID == "" && len(req.AuthorIDs) == 0 && req.Label == "" && req.IsPinned == nil &&
+		req.CreatedAfter == nil && req.CreatedBefore == nil &&
+		isDefaultListSort(req.SortBy, req.SortOrder) &&
+		req.View == "" && req.Cursor == "" &&
+		req.PendingFor == "" && !req.IncludeDeleted
+}
+
+// isDefaultListSort reports whether a listing uses the default newest-first
+// order, either left empty or spelled out as the list handler fills it in
+func isDefaultListSort(sortBy, sortOrder string) bool {
+	return (sortBy == "" || sortBy == "created_at") && (sortOrder == "" || sortOrder == "desc")
+}
+
+// commentCacheKeys lists the keys a change to the given comments makes stale:
+// each comment, its parent (whose reply count may have changed) and the
+// cached first page of its resource
+func commentCacheKeys(comments ...*models.Comment) []string {
+	var keys []string
+	for _, comment := range comments {
+		keys = append(keys,
+			commentCacheKey(comment.ID.Hex()),
+			listCacheKey(comment.TenantID, comment.ResourceType, comment.ResourceID, 1),
+		)
+		if comment.ParentID != nil {
+			keys = append(keys, commentCacheKey(comment.ParentID.Hex()))
+		}
+	}
+	return keys
+}
+
+// invalidateCache drops cached reads made stale by changes to comments
+func invalidateCache(ctx context.Context, cache Cache, comments ...*models.Comment) {
+	if cache == nil || len(comments) == 0 {
+		return
+	}
+	cache.Delete(ctx, commentCacheKeys(comments...)...)
+}
+
+// getCached decodes a cached value into out, reporting whether it was found
+func getCached(ctx context.Context, cache Cache, key string, out any) bool {
+	if cache == nil {
+		return false
+	}
+	data, ok := cache.Get(ctx, key)
+	if !ok {
+		return false
+	}
+	if err := bson.Unmarshal(data, out); err != nil {
+		log.Printf("Failed to decode cached %s: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// setCached stores value under key. Values are BSON encoded so fields hidden
+// from JSON responses survive the round trip.
+func setCached(ctx context.Context, cache Cache, key string, value any) {
+	if cache == nil {
+		return
+	}
+	data, err := bson.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode %s for cache: %v", key, err)
+		return
+	}
+	cache.Set(ctx, key, data)
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// memoryCache is an in-process Cache for tests
+type memoryCache map[string][]byte
+
+func (c memoryCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	data, ok := c[key]
+	return data, ok
+}
+
+func (c memoryCache) Set(ctx context.Context, key string, value []byte) {
+	c[key] = value
+}
+
+func (c memoryCache) Delete(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		delete(c, key)
+	}
+}
+
+func TestIsCacheableList(t *testing.T) {
+	base := models.ListCommentsRequest{
+		TenantID:     "tenant-1",
+		ResourceType: "product",
+		ResourceID:   "123",
+		Status:       models.StatusApproved,
+	}
+	assert.True(t, isCacheableList(base))
+
+	pinned := true
+	tests := []struct {
+		name   string
+		modify func(*models.ListCommentsRequest)
+	}{
+		{"Second Page", func(r *models.ListCommentsRequest) { r.Page = 2 }},
+		{"Custom Page Size", func(r *models.ListCommentsRequest) { r.PageSize = 50 }},
+		{"Own Pending", func(r *models.ListCommentsRequest) { r.PendingFor = "user-1" }},
+		{"Admin Status", func(r *models.ListCommentsRequest) { r.Status = models.StatusPending }},
+		{"Sorted", func(r *models.ListCommentsRequest) { r.SortBy = "like_count" }},
+		{"Oldest First", func(r *models.ListCommentsRequest) { r.SortOrder = "asc" }},
+		{"Pinned Filter", func(r *models.ListCommentsRequest) { r.IsPinned = &pinned }},
+		{"Label", func(r *models.ListCommentsRequest) { r.Label = "spoiler" }},
+		{"Flat View", func(r *models.ListCommentsRequest) { r.View = models.ViewFlat }},
+		{"Cursor", func(r *models.ListCommentsRequest) { r.Cursor = models.CursorStart }},
+		{"No Resource", func(r *models.ListCommentsRequest) { r.ResourceID = "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base
+			tt.modify(&req)
+			assert.False(t, isCacheableList(req))
+		})
+	}
+
+	t.Run("Explicit Defaults", func(t *testing.T) {
+		req := base
+		req.Page = 1
+		req.PageSize = models.DefaultPageSize
+		req.SortBy = "created_at"
+		req.SortOrder = "desc"
+		assert.True(t, isCacheableList(req))
+	})
+}
+
+// unreachableDB returns a database whose every query fails fast, so a test
+// passes only if the code under test never needs it
+func unreachableDB(t *testing.T) *database.MongoDB {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	return &database.MongoDB{Client: client, Database: client.Database("comments_test")}
+}
+
+func TestListCommentsServedFromCache(t *testing.T) {
+	db := unreachableDB(t)
+	cache := memoryCache{}
+	u := &CommentUsecase{
+		commentRepo:  repository.NewCommentRepository(db),
+		settingsRepo: repository.NewSettingsRepository(db, config.ModerationConfig{}),
+		cache:        cache,
+	}
+
+	cached := &models.Comment{ID: primitive.NewObjectID(), Content: "cached", Status: models.StatusApproved}
+	setCached(context.Background(), cache, listCacheKey("tenant-1", "product", "123", 1), cachedPage{Comments: []*models.Comment{cached}, Total: 1})
+
+	// An anonymous reader's request, as CommentHandler.List builds it
+	req := models.ListCommentsRequest{
+		TenantID:     "tenant-1",
+		ResourceType: "product",
+		ResourceID:   "123",
+		Page:         1,
+		PageSize:     20,
+		SortBy:       "created_at",
+		SortOrder:    "desc",
+	}
+	resp, err := u.ListComments(context.Background(), req, "", false)
+	require.NoError(t, err, "the first page never reaches MongoDB")
+	require.Len(t, resp.Comments, 1)
+	assert.Equal(t, "cached", resp.Comments[0].Content)
+	assert.Equal(t, int64(1), resp.Total)
+}
+
+func TestCommentCacheKeys(t *testing.T) {
+	parentID := primitive.NewObjectID()
+	reply := &models.Comment{
+		ID:           primitive.NewObjectID(),
+		TenantID:     "tenant-1",
+		ResourceType: "product",
+		ResourceID:   "123",
+		ParentID:     &parentID,
+	}
+
+	assert.ElementsMatch(t, []string{
+		commentCacheKey(reply.ID.Hex()),
+		commentCacheKey(parentID.Hex()),
+		"comments:tenant-1:product:123:1",
+	}, commentCacheKeys(reply))
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cache := memoryCache{}
+	comment := &models.Comment{
+		ID:           primitive.NewObjectID(),
+		TenantID:     "tenant-1",
+		ResourceType: "product",
+		ResourceID:   "123",
+		Content:      "Great product",
+		IPAddress:    "203.0.113.7",
+		Status:       models.StatusApproved,
+	}
+	key := commentCacheKey(comment.ID.Hex())
+
+	setCached(ctx, cache, key, comment)
+
+	var cached models.Comment
+	require.True(t, getCached(ctx, cache, key, &cached))
+	assert.Equal(t, comment.Content, cached.Content)
+	assert.Equal(t, comment.IPAddress, cached.IPAddress, "fields hidden from JSON are kept")
+
+	invalidateCache(ctx, cache, comment)
+	assert.False(t, getCached(ctx, cache, key, &cached))
+
+	t.Run("Nil Cache", func(t *testing.T) {
+		setCached(ctx, nil, key, comment)
+		assert.False(t, getCached(ctx, nil, key, &cached))
+		invalidateCache(ctx, nil, comment)
+	})
+}
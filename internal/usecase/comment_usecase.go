@@ -4,32 +4,95 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/minisource/comment/config"
 	"github.com/minisource/comment/internal/models"
 	"github.com/minisource/comment/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // CommentUsecase handles comment business logic
 type CommentUsecase struct {
-	commentRepo   *repository.CommentRepository
-	reactionRepo  *repository.ReactionRepository
-	reportRepo    *repository.ReportRepository
-	settingsRepo  *repository.SettingsRepository
-	notifier      NotifierClient
-	cfg           *config.Config
-	badWordsRegex *regexp.Regexp
+	commentRepo  *repository.CommentRepository
+	reactionRepo *repository.ReactionRepository
+	reportRepo   *repository.ReportRepository
+	voteRepo     *repository.HelpfulVoteRepository
+	settingsRepo *repository.SettingsRepository
+	viewRepo     *repository.ResourceViewRepository
+	counterRepo  *repository.ResourceCounterRepository
+	auditRepo    *repository.AuditRepository
+	notifier     NotifierClient
+	webhooks     WebhookDispatcher
+	geoResolver  GeoResolver
+	translator   TranslationProvider
+	validators   map[string]ResourceValidator
+	killSwitches KillSwitchChecker
+	cache        Cache
+	cfg          *config.Config
+	pipeline     *ContentPipeline
 }
 
+// Audit actions
+const (
+	AuditCommentCreated   = "comment.created"
+	AuditCommentModerated = "comment.moderated"
+	AuditCommentRestored  = "comment.restored"
+)
+
+// moderationActions are the audit actions that set a comment's status
+var moderationActions = []string{AuditCommentCreated, AuditCommentModerated, AuditCommentAutoHidden}
+
+// Reported-delete actions, usable in MODERATION_REPORTED_DELETE_ACTION
+const (
+	ReportedDeleteBlock     = "block"
+	ReportedDeleteTombstone = "tombstone"
+)
+
+// ThreadFullRejectionReason is the reason given to authors of root comments
+// closed by a resource's root comment cap
+const ThreadFullRejectionReason = "This discussion is full"
+
 // NotifierClient interface for sending notifications
 type NotifierClient interface {
 	SendNotification(ctx context.Context, notification NotificationRequest) error
 }
 
+// BatchNotifierClient is implemented by notifiers that can send several
+// notifications in one call
+type BatchNotifierClient interface {
+	SendNotifications(ctx context.Context, notifications []NotificationRequest) error
+}
+
+// KillSwitchChecker interface for checking whether comment creation is switched off
+type KillSwitchChecker interface {
+	CommentingDisabled(ctx context.Context, tenantID string) (bool, error)
+}
+
+// GeoResolver interface for resolving a client IP to an ISO country code
+type GeoResolver interface {
+	CountryForIP(ctx context.Context, ip string) (string, error)
+}
+
+// TranslationProvider interface for detecting the language of comment content
+// and translating it. Languages are ISO 639-1 codes.
+type TranslationProvider interface {
+	DetectLanguage(ctx context.Context, text string) (string, error)
+	Translate(ctx context.Context, text, targetLanguage string) (string, error)
+}
+
+// ResourceValidator interface for checking that a commented-on resource exists
+type ResourceValidator interface {
+	ResourceExists(ctx context.Context, tenantID, resourceID string) (bool, error)
+}
+
 // NotificationRequest represents a notification to send
 type NotificationRequest struct {
 	Type       string            `json:"type"`
@@ -37,6 +100,37 @@ type NotificationRequest struct {
 	Title      string            `json:"title"`
 	Body       string            `json:"body"`
 	Data       map[string]string `json:"data"`
+	// Actor is the user whose action caused the notification; never sent
+	Actor string `json:"-"`
+}
+
+// isSelfNotification reports whether a notification would only tell its
+// actor about their own action
+func isSelfNotification(notification NotificationRequest) bool {
+	return notification.Actor != "" && len(notification.Recipients) == 1 && notification.Recipients[0] == notification.Actor
+}
+
+// dropSelfNotifications filters out self-notifications when cfg suppresses them
+func dropSelfNotifications(notifications []NotificationRequest, cfg config.NotifierConfig) []NotificationRequest {
+	if !cfg.SuppressSelfNotifications {
+		return notifications
+	}
+	kept := make([]NotificationRequest, 0, len(notifications))
+	for _, notification := range notifications {
+		if !isSelfNotification(notification) {
+			kept = append(kept, notification)
+		}
+	}
+	return kept
+}
+
+// dispatchNotification sends one notification unless it is a suppressed
+// self-notification. Every single-notification path goes through here.
+func dispatchNotification(ctx context.Context, notifier NotifierClient, cfg config.NotifierConfig, notification NotificationRequest) error {
+	if cfg.SuppressSelfNotifications && isSelfNotification(notification) {
+		return nil
+	}
+	return notifier.SendNotification(ctx, notification)
 }
 
 // NewCommentUsecase creates a new comment usecase
@@ -44,30 +138,69 @@ func NewCommentUsecase(
 	commentRepo *repository.CommentRepository,
 	reactionRepo *repository.ReactionRepository,
 	reportRepo *repository.ReportRepository,
+	voteRepo *repository.HelpfulVoteRepository,
 	settingsRepo *repository.SettingsRepository,
+	viewRepo *repository.ResourceViewRepository,
+	counterRepo *repository.ResourceCounterRepository,
+	auditRepo *repository.AuditRepository,
 	notifier NotifierClient,
+	webhooks WebhookDispatcher,
+	geoResolver GeoResolver,
+	translator TranslationProvider,
+	validators map[string]ResourceValidator,
+	killSwitches KillSwitchChecker,
+	cache Cache,
 	cfg *config.Config,
 ) *CommentUsecase {
-	// Build bad words regex
-	var badWordsRegex *regexp.Regexp
-	if cfg.Moderation.BadWordsEnabled && len(cfg.Moderation.BadWordsList) > 0 {
-		pattern := "(?i)\\b(" + strings.Join(cfg.Moderation.BadWordsList, "|") + ")\\b"
-		badWordsRegex, _ = regexp.Compile(pattern)
-	}
-
 	return &CommentUsecase{
-		commentRepo:   commentRepo,
-		reactionRepo:  reactionRepo,
-		reportRepo:    reportRepo,
-		settingsRepo:  settingsRepo,
-		notifier:      notifier,
-		cfg:           cfg,
-		badWordsRegex: badWordsRegex,
+		commentRepo:  commentRepo,
+		reactionRepo: reactionRepo,
+		reportRepo:   reportRepo,
+		voteRepo:     voteRepo,
+		settingsRepo: settingsRepo,
+		viewRepo:     viewRepo,
+		counterRepo:  counterRepo,
+		auditRepo:    auditRepo,
+		notifier:     notifier,
+		webhooks:     webhooks,
+		geoResolver:  geoResolver,
+		translator:   translator,
+		validators:   validators,
+		killSwitches: killSwitches,
+		cache:        cache,
+		cfg:          cfg,
+		pipeline:     NewContentPipeline(cfg.Moderation),
 	}
 }
 
 // CreateComment creates a new comment
-func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCommentRequest, authorID, authorName, authorEmail, ipAddress, userAgent string) (*models.Comment, error) {
+func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCommentRequest, authorID, authorName, authorEmail, authorAvatar, ipAddress, userAgent string, isVerified, isAdmin bool) (*models.Comment, error) {
+	// Check for parent comment (reply)
+	var parent *models.Comment
+	if req.ParentID != "" {
+		pid, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent ID")
+		}
+
+		parent, err = u.commentRepo.GetByID(ctx, pid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent comment: %w", err)
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("parent comment not found")
+		}
+
+		// Replies always live on the parent's resource
+		inheritParentResource(&req, parent)
+	}
+
+	// Checked once a reply carries its parent's tenant, so naming another
+	// tenant can't slip a reply into a switched-off one
+	if err := checkKillSwitch(ctx, u.killSwitches, req.TenantID); err != nil {
+		return nil, err
+	}
+
 	// Get settings
 	settings, err := u.settingsRepo.GetOrCreate(ctx, req.TenantID, req.ResourceType)
 	if err != nil {
@@ -79,63 +212,115 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCom
 		return nil, fmt.Errorf("comments are disabled for this resource type")
 	}
 
+	// Check regional restrictions
+	if err := checkGeoRestriction(ctx, u.geoResolver, ipAddress, settings); err != nil {
+		return nil, err
+	}
+
+	// Embedded widgets may only post from the tenant's own sites
+	if !isAdmin {
+		if err := checkOrigin(req.Origin, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replies inherit an already-checked resource from their parent
+	if parent == nil {
+		if err := checkResourceExists(ctx, u.validators[req.ResourceType], req.TenantID, req.ResourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cap how many root comments one resource collects; replies stay open
+	threadFull := false
+	if parent == nil && settings.MaxRootComments > 0 && !isAdmin {
+		roots, err := u.commentRepo.CountActiveRoots(ctx, req.TenantID, req.ResourceType, req.ResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count root comments: %w", err)
+		}
+		if threadFull, err = checkRootLimit(roots, settings); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check anonymous permissions
 	if req.IsAnonymous && !settings.AllowAnonymous {
 		return nil, fmt.Errorf("anonymous comments are not allowed")
 	}
 
+	// Keep throwaway accounts out where the tenant asks for it
+	if settings.BlockDisposableEmails && !req.IsAnonymous && isDisposableEmail(authorEmail, u.cfg.Moderation.DisposableEmailDomains) {
+		return nil, fmt.Errorf("comments from disposable email addresses are not allowed")
+	}
+
 	// Validate content length
 	if len(req.Content) > settings.MaxCommentLength {
 		return nil, fmt.Errorf("comment exceeds maximum length of %d characters", settings.MaxCommentLength)
 	}
 
-	// Check for parent comment (reply)
-	var parentID *primitive.ObjectID
-	var rootID *primitive.ObjectID
-	depth := 0
-
-	if req.ParentID != "" {
-		pid, err := primitive.ObjectIDFromHex(req.ParentID)
-		if err != nil {
-			return nil, fmt.Errorf("invalid parent ID")
-		}
-
-		parent, err := u.commentRepo.GetByID(ctx, pid)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get parent comment: %w", err)
-		}
-		if parent == nil {
-			return nil, fmt.Errorf("parent comment not found")
-		}
+	parentID, rootID, depth := replyPlacement(parent)
 
+	if parent != nil {
 		// Check if replies are allowed
 		if !settings.AllowReplies {
 			return nil, fmt.Errorf("replies are not allowed")
 		}
 
 		// Check max reply depth
-		depth = parent.Depth + 1
 		if depth > settings.MaxReplyDepth {
 			return nil, fmt.Errorf("maximum reply depth exceeded")
 		}
+	}
 
-		parentID = &pid
-		if parent.RootID != nil {
-			rootID = parent.RootID
-		} else {
-			rootID = &pid
-		}
+	if err := checkAttachmentURLs(req.Attachments, u.cfg.Moderation.RequireHTTPSURLs); err != nil {
+		return nil, err
+	}
+	if err := checkAttachmentSizes(req.Attachments, settings); err != nil {
+		return nil, err
+	}
+
+	// Run content through the processing pipeline
+	processed, err := u.pipeline.Run(req.Content, settings)
+	if err != nil {
+		return nil, err
 	}
+	flaggedWords := processed.FlaggedWords
 
-	// Check for bad words
-	flaggedWords := u.checkBadWords(req.Content, settings.CustomBadWords)
+	// Catch replies that just copy their parent
+	echoHold, err := checkParentEcho(processed.Content, parent, settings)
+	if err != nil {
+		return nil, err
+	}
+	language := checkLanguage(ctx, u.translator, processed.Content, settings)
 
 	// Determine initial status
-	status := models.StatusPending
-	if !settings.RequireApproval {
-		status = models.StatusApproved
-	} else if len(flaggedWords) > 0 {
-		status = models.StatusPending // Force pending if bad words detected
+	status := initialStatus(settings, flaggedWords, isVerified)
+	if processed.HoldForReview || echoHold || language.Hold {
+		status = models.StatusPending
+	}
+
+	// Spam scoring overrides auto-approval
+	metadata := req.Metadata
+	if threshold := u.cfg.Moderation.SpamScoreThreshold; threshold > 0 {
+		score := spamScore(processed.Content, u.cfg.Moderation)
+		if score >= threshold {
+			status = models.StatusSpam
+		}
+		metadata = withSpamScore(metadata, score)
+	}
+	if threadFull {
+		status = models.StatusRejected
+	}
+
+	// Keep one author from flooding the moderation queue
+	if status == models.StatusPending && settings.MaxPendingPerAuthor > 0 && authorID != "" && !isAdmin {
+		pending, err := u.commentRepo.CountPendingByAuthor(ctx, req.TenantID, authorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count pending comments: %w", err)
+		}
+		if err := checkPendingLimit(pending, settings); err != nil {
+			return nil, err
+		}
 	}
 
 	// Set author info
@@ -144,9 +329,14 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCom
 		displayName = req.AuthorName
 	}
 	if req.IsAnonymous {
-		displayName = "Anonymous"
+		displayName, err = anonymousDisplayName(req.AuthorName, settings)
+		if err != nil {
+			return nil, err
+		}
 		authorEmail = ""
+		authorAvatar = ""
 	}
+	authorAvatar = allowedAvatar(authorAvatar, u.cfg.Moderation.RequireHTTPSURLs)
 
 	comment := &models.Comment{
 		TenantID:     req.TenantID,
@@ -157,9 +347,14 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCom
 		AuthorID:     authorID,
 		AuthorName:   displayName,
 		AuthorEmail:  authorEmail,
+		AuthorAvatar: authorAvatar,
 		IsAnonymous:  req.IsAnonymous,
-		Content:      req.Content,
+		Content:      processed.Content,
+		ContentHTML:  processed.ContentHTML,
+		Snippet:      processed.Snippet,
+		RawContent:   u.rawContent(processed),
 		Attachments:  req.Attachments,
+		Mentions:     extractMentions(processed.Content),
 		Status:       status,
 		FlaggedWords: flaggedWords,
 		IsPinned:     false,
@@ -169,14 +364,25 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCom
 		DislikeCount: 0,
 		IPAddress:    ipAddress,
 		UserAgent:    userAgent,
-		Metadata:     req.Metadata,
+		Metadata:     metadata,
 		Depth:        depth,
 		IsDeleted:    false,
 	}
+	comment.Language = language.Language
+	comment.ContentTranslated = language.Translated
+	if threadFull {
+		now := models.Now()
+		comment.RejectionReason = ThreadFullRejectionReason
+		comment.ModeratedBy = AutoCloseModerator
+		comment.ModeratedAt = &now
+	}
 
 	if err := u.commentRepo.Create(ctx, comment); err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
+	u.audit(ctx, AuditCommentCreated, comment, authorID)
+	dispatchWebhook(u.webhooks, u.settingsRepo.GetEffective, u.cfg.Notifier.MetadataKeys, WebhookCommentCreated, comment)
+	u.adjustResourceCount(ctx, comment, 1)
 
 	// Increment parent reply count
 	if parentID != nil {
@@ -184,15 +390,131 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, req models.CreateCom
 			log.Printf("Failed to increment reply count: %v", err)
 		}
 	}
+	invalidateCache(ctx, u.cache, comment)
 
 	// Send notifications
-	go u.sendNewCommentNotification(comment, settings)
+	go u.sendNewCommentNotification(comment, settings, newCommentRecipient(req, parent))
+	if comment.Status == models.StatusApproved {
+		go u.sendMentionNotifications(comment)
+	}
 
 	return comment, nil
 }
 
+// AnnotatePosition sets ApproximatePosition on a just-created comment: where
+// it lands among its resource's roots in the default sort, or among its
+// siblings for a reply. Failures are logged and leave the position unset.
+func (u *CommentUsecase) AnnotatePosition(ctx context.Context, comment *models.Comment) {
+	var rankedAbove, siblings int64
+	var err error
+	if comment.ParentID == nil {
+		rankedAbove, err = u.commentRepo.CountRootsRankedAbove(ctx, comment.TenantID, comment.ResourceType, comment.ResourceID)
+	} else {
+		siblings, err = u.commentRepo.CountReplies(ctx, *comment.ParentID)
+	}
+	if err != nil {
+		log.Printf("Failed to compute position of comment %s: %v", comment.ID.Hex(), err)
+		return
+	}
+	comment.ApproximatePosition = approximatePosition(comment, rankedAbove, siblings)
+}
+
+// approximatePosition places a new comment in its listing. Roots are newest
+// first after weighted and pinned ones, so they follow the rankedAbove roots;
+// replies are oldest first, so they come last among their siblings, which
+// include the reply itself. Comments readers won't see get 0.
+func approximatePosition(comment *models.Comment, rankedAbove, siblings int64) int {
+	if comment.Status != models.StatusApproved && !isPending(comment.Status) {
+		return 0
+	}
+	if comment.ParentID != nil {
+		return int(max(siblings, 1))
+	}
+	return int(rankedAbove) + 1
+}
+
 // GetComment retrieves a comment by ID
-func (u *CommentUsecase) GetComment(ctx context.Context, id string) (*models.Comment, error) {
+// An anonymous caller (empty userID) only sees approved, non-deleted comments.
+func (u *CommentUsecase) GetComment(ctx context.Context, id string, userID string) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.getCommentCached(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || (userID == "" && !isPubliclyVisible(comment)) {
+		return nil, fmt.Errorf("comment not found")
+	}
+	setRepliesDisabled([]*models.Comment{comment}, u.repliesDisabledLookup(ctx))
+
+	return comment, nil
+}
+
+// getCommentCached loads a comment through the read cache. Visibility checks
+// are left to the caller, so one cached copy serves every caller.
+func (u *CommentUsecase) getCommentCached(ctx context.Context, oid primitive.ObjectID) (*models.Comment, error) {
+	key := commentCacheKey(oid.Hex())
+	var cached models.Comment
+	if getCached(ctx, u.cache, key, &cached) {
+		return &cached, nil
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil || comment == nil {
+		return comment, err
+	}
+	setCached(ctx, u.cache, key, comment)
+	return comment, nil
+}
+
+// maxInlineReplies caps how many replies GetCommentWithReplies inlines
+const maxInlineReplies = 20
+
+// maxTreeComments caps how many comments GetCommentTree loads for one resource
+const maxTreeComments = 2000
+
+// MaxAdminTreeDepth caps how deep admins may look into a comment tree, past
+// the settings' max reply depth
+const MaxAdminTreeDepth = 50
+
+// SpamScoreMetadataKey is the metadata key a new comment's spam score is stored under
+const SpamScoreMetadataKey = "spamScore"
+
+// OwnerIDMetadataKey is the metadata key read for a resource's owner when a
+// new root comment doesn't name one
+const OwnerIDMetadataKey = "owner_id"
+
+// DeletedPlaceholderText replaces the content and author of a deleted comment
+// kept in a tree for its replies
+const DeletedPlaceholderText = "[deleted]"
+
+// GetCommentWithReplies retrieves a comment with up to n of its direct
+// replies, oldest first, for deep links
+func (u *CommentUsecase) GetCommentWithReplies(ctx context.Context, id, userID string, n int) (*models.CommentWithReplies, error) {
+	comment, err := u.GetComment(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > maxInlineReplies {
+		n = maxInlineReplies
+	}
+	var replies []*models.Comment
+	if n > 0 {
+		replies, _, _, err = u.commentRepo.GetReplies(ctx, comment.ID, userID, 1, n, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replies: %w", err)
+		}
+	}
+
+	return newCommentWithReplies(comment, replies, n), nil
+}
+
+// GetCommentForAdmin retrieves any comment, including admin-only fields
+func (u *CommentUsecase) GetCommentForAdmin(ctx context.Context, id string) (*models.AdminComment, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid comment ID")
@@ -206,7 +528,7 @@ func (u *CommentUsecase) GetComment(ctx context.Context, id string) (*models.Com
 		return nil, fmt.Errorf("comment not found")
 	}
 
-	return comment, nil
+	return &models.AdminComment{Comment: comment, RawContent: comment.RawContent}, nil
 }
 
 // UpdateComment updates a comment
@@ -240,40 +562,100 @@ func (u *CommentUsecase) UpdateComment(ctx context.Context, id string, req model
 		return nil, fmt.Errorf("failed to get settings: %w", err)
 	}
 
+	// Authors can't rewrite a comment long after others have read it
+	if !isAdmin {
+		if err := checkEditWindow(comment, settings, models.Now()); err != nil {
+			return nil, err
+		}
+		if err := checkEditLock(comment, settings); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate content length
 	if len(req.Content) > settings.MaxCommentLength {
 		return nil, fmt.Errorf("comment exceeds maximum length of %d characters", settings.MaxCommentLength)
 	}
 
+	if err := checkAttachmentURLs(req.Attachments, u.cfg.Moderation.RequireHTTPSURLs); err != nil {
+		return nil, err
+	}
+	if err := checkAttachmentSizes(req.Attachments, settings); err != nil {
+		return nil, err
+	}
+
+	// Run new content through the processing pipeline
+	processed, err := u.pipeline.Run(req.Content, settings)
+	if err != nil {
+		return nil, err
+	}
+
 	// Save edit history
 	editRecord := models.EditRecord{
 		Content:  comment.Content,
-		EditedAt: time.Now(),
+		EditedAt: models.Now(),
 		EditedBy: userID,
 	}
-	comment.EditHistory = append(comment.EditHistory, editRecord)
-
-	// Check for bad words in new content
-	flaggedWords := u.checkBadWords(req.Content, settings.CustomBadWords)
+	comment.EditHistory = appendEditRecord(comment.EditHistory, editRecord, u.cfg.Moderation.MaxEditHistory)
 
 	// Update fields
-	comment.Content = req.Content
+	comment.Content = processed.Content
+	comment.ContentHTML = processed.ContentHTML
+	comment.Snippet = processed.Snippet
+	comment.RawContent = u.rawContent(processed)
 	comment.Attachments = req.Attachments
+	comment.Mentions = extractMentions(processed.Content)
 	comment.IsEdited = true
-	comment.FlaggedWords = flaggedWords
+	comment.FlaggedWords = processed.FlaggedWords
+	language := checkLanguage(ctx, u.translator, processed.Content, settings)
+	comment.Language = language.Language
+	comment.ContentTranslated = language.Translated
 
 	// If bad words found, set back to pending
-	if len(flaggedWords) > 0 && settings.RequireApproval {
+	if len(processed.FlaggedWords) > 0 && settings.RequireApproval {
+		comment.Status = models.StatusPending
+	}
+	if processed.HoldForReview || language.Hold {
+		comment.Status = models.StatusPending
+	}
+	// The first sign-off was for the old content
+	if comment.Status == models.StatusPendingSecondApproval {
 		comment.Status = models.StatusPending
+		comment.ModerationApprovals = nil
 	}
+	trackEditSinceApproval(comment, settings)
 
-	if err := u.commentRepo.Update(ctx, comment); err != nil {
+	if err := u.commentRepo.UpdateWithEdit(ctx, comment, editRecord, u.cfg.Moderation.MaxEditHistory); err != nil {
 		return nil, fmt.Errorf("failed to update comment: %w", err)
 	}
+	invalidateCache(ctx, u.cache, comment)
+	dispatchWebhook(u.webhooks, u.settingsRepo.GetEffective, u.cfg.Notifier.MetadataKeys, WebhookCommentUpdated, comment)
 
 	return comment, nil
 }
 
+// GetEditHistory retrieves a comment's earlier versions, oldest first, with
+// the current content as the final entry. Only the author and admins may see it.
+func (u *CommentUsecase) GetEditHistory(ctx context.Context, id, userID string, isAdmin bool) ([]models.EditRecord, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || (comment.IsDeleted && !isAdmin) {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if !isAdmin && (userID == "" || comment.AuthorID != userID) {
+		return nil, fmt.Errorf("you can only view the history of your own comments")
+	}
+
+	return editHistoryWithCurrent(comment), nil
+}
+
 // DeleteComment soft deletes a comment
 func (u *CommentUsecase) DeleteComment(ctx context.Context, id string, userID string, isAdmin bool) error {
 	oid, err := primitive.ObjectIDFromHex(id)
@@ -294,177 +676,1769 @@ func (u *CommentUsecase) DeleteComment(ctx context.Context, id string, userID st
 		return fmt.Errorf("you can only delete your own comments")
 	}
 
+	// Authors can't make a reported comment disappear from review
+	reported := false
+	if !isAdmin {
+		pending, err := u.reportRepo.GetCommentsWithPendingReports(ctx, []primitive.ObjectID{oid})
+		if err != nil {
+			return fmt.Errorf("failed to check reports: %w", err)
+		}
+		reported = pending[oid]
+		if err := checkReportedDelete(u.cfg.Moderation.ReportedDeleteAction, reported); err != nil {
+			return err
+		}
+	}
+
 	if err := u.commentRepo.SoftDelete(ctx, oid, userID); err != nil {
 		return fmt.Errorf("failed to delete comment: %w", err)
 	}
 
-	// Decrement parent reply count
-	if comment.ParentID != nil {
-		if err := u.commentRepo.IncrementReplyCount(ctx, *comment.ParentID, -1); err != nil {
-			log.Printf("Failed to decrement reply count: %v", err)
+	// The soft-deleted comment stays available to moderators as a tombstone
+	if reported {
+		go u.sendReportedDeleteNotification(comment)
+	}
+
+	// Deleting an already deleted comment again mustn't count it twice
+	if !comment.IsDeleted {
+		u.releaseCounts(ctx, comment)
+		markDeleted([]*models.Comment{comment}, userID, models.Now())
+		dispatchWebhook(u.webhooks, u.settingsRepo.GetEffective, u.cfg.Notifier.MetadataKeys, WebhookCommentDeleted, comment)
+	}
+	invalidateCache(ctx, u.cache, comment)
+
+	settings, err := u.settingsRepo.GetOrCreate(ctx, comment.TenantID, comment.ResourceType)
+	if err != nil {
+		return fmt.Errorf("failed to get settings: %w", err)
+	}
+	if settings.CascadeDelete {
+		if err := u.cascadeDelete(ctx, comment, userID); err != nil {
+			return fmt.Errorf("failed to delete replies: %w", err)
 		}
 	}
 
 	return nil
 }
 
-// ListComments retrieves comments with filters
-func (u *CommentUsecase) ListComments(ctx context.Context, req models.ListCommentsRequest, userID string, isAdmin bool) (*models.ListCommentsResponse, error) {
-	// Non-admins can only see approved comments
-	if !isAdmin && req.Status == "" {
-		req.Status = models.StatusApproved
+// cascadeDelete soft-deletes every live reply below a deleted comment,
+// recording the same deleter
+func (u *CommentUsecase) cascadeDelete(ctx context.Context, comment *models.Comment, deletedBy string) error {
+	descendants, err := u.collectDescendants(ctx, comment.ID)
+	if err != nil {
+		return err
 	}
 
-	comments, total, err := u.commentRepo.List(ctx, req)
-	if err != nil {
-		return nil, err
+	deleted := markDeleted(descendants, deletedBy, models.Now())
+	if len(deleted) == 0 {
+		return nil
 	}
 
-	pageSize := req.PageSize
-	if pageSize < 1 {
-		pageSize = 20
+	ids := make([]primitive.ObjectID, len(deleted))
+	for i, reply := range deleted {
+		ids[i] = reply.ID
+	}
+	if err := u.commentRepo.SoftDeleteMany(ctx, ids, deletedBy); err != nil {
+		return err
 	}
 
-	totalPages := int(total) / pageSize
-	if int(total)%pageSize > 0 {
-		totalPages++
+	for _, reply := range deleted {
+		u.releaseCounts(ctx, reply)
 	}
+	invalidateCache(ctx, u.cache, deleted...)
 
-	return &models.ListCommentsResponse{
-		Comments:   comments,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
-	}, nil
+	return nil
 }
 
-// GetReplies retrieves replies for a comment
-func (u *CommentUsecase) GetReplies(ctx context.Context, commentID string, page, pageSize int) ([]*models.Comment, int64, error) {
-	oid, err := primitive.ObjectIDFromHex(commentID)
-	if err != nil {
-		return nil, 0, fmt.Errorf("invalid comment ID")
+// markDeleted soft-deletes the comments that aren't deleted yet in memory and
+// returns them
+func markDeleted(comments []*models.Comment, deletedBy string, now time.Time) []*models.Comment {
+	var deleted []*models.Comment
+	for _, comment := range comments {
+		if comment.IsDeleted {
+			continue
+		}
+		comment.IsDeleted = true
+		comment.DeletedBy = deletedBy
+		comment.DeletedAt = &now
+		deleted = append(deleted, comment)
 	}
-
-	return u.commentRepo.GetReplies(ctx, oid, page, pageSize)
+	return deleted
 }
 
-// ModerateComment approves or rejects a comment
-func (u *CommentUsecase) ModerateComment(ctx context.Context, id string, req models.ModerateCommentRequest, moderatorID string) (*models.Comment, error) {
+// HardDeleteComment permanently deletes a comment along with its reactions,
+// helpful votes and reports. Comments with live replies are refused, since
+// removing them would orphan the replies.
+func (u *CommentUsecase) HardDeleteComment(ctx context.Context, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid comment ID")
+		return fmt.Errorf("invalid comment ID")
 	}
 
 	comment, err := u.commentRepo.GetByID(ctx, oid)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if comment == nil {
-		return nil, fmt.Errorf("comment not found")
+		return fmt.Errorf("comment not found")
 	}
 
-	now := time.Now()
-	comment.Status = req.Status
-	comment.ModeratedBy = moderatorID
-	comment.ModeratedAt = &now
-
-	if req.Status == models.StatusRejected {
-		comment.RejectionReason = req.RejectionReason
+	replies, err := u.commentRepo.CountReplies(ctx, oid)
+	if err != nil {
+		return fmt.Errorf("failed to count replies: %w", err)
+	}
+	if replies > 0 {
+		return fmt.Errorf("comment has replies, soft delete it instead")
 	}
 
-	if err := u.commentRepo.Update(ctx, comment); err != nil {
-		return nil, fmt.Errorf("failed to moderate comment: %w", err)
+	if err := u.commentRepo.HardDelete(ctx, oid); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
 	}
+	u.deleteDependents(ctx, oid)
 
-	// Send notification to author
-	go u.sendModerationNotification(comment)
+	// A soft-deleted comment was already taken out of the counts
+	if !comment.IsDeleted {
+		u.releaseCounts(ctx, comment)
+	}
+	invalidateCache(ctx, u.cache, comment)
+	dispatchWebhook(u.webhooks, u.settingsRepo.GetEffective, u.cfg.Notifier.MetadataKeys, WebhookCommentDeleted, comment)
 
-	return comment, nil
+	return nil
 }
 
-// PinComment pins or unpins a comment
-func (u *CommentUsecase) PinComment(ctx context.Context, id string, isPinned bool, userID string) (*models.Comment, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, fmt.Errorf("invalid comment ID")
+// BulkDeleteComments deletes several comments as a moderator, soft or, with
+// hard, permanently (see HardDeleteComment). It returns the IDs that could not
+// be deleted.
+func (u *CommentUsecase) BulkDeleteComments(ctx context.Context, ids []string, hard bool, moderatorID string) []string {
+	failedIDs := []string{}
+
+	for _, id := range ids {
+		var err error
+		if hard {
+			err = u.HardDeleteComment(ctx, id)
+		} else {
+			err = u.DeleteComment(ctx, id, moderatorID, true)
+		}
+		if err != nil {
+			failedIDs = append(failedIDs, id)
+		}
 	}
 
-	comment, err := u.commentRepo.GetByID(ctx, oid)
-	if err != nil {
-		return nil, err
+	return failedIDs
+}
+
+// deleteDependents removes the reactions, helpful votes and reports of a
+// hard-deleted comment. Failures are only logged, since the comment itself is
+// already gone.
+func (u *CommentUsecase) deleteDependents(ctx context.Context, commentID primitive.ObjectID) {
+	if err := u.reactionRepo.DeleteByCommentID(ctx, commentID); err != nil {
+		log.Printf("Failed to delete reactions of comment %s: %v", commentID.Hex(), err)
 	}
-	if comment == nil {
-		return nil, fmt.Errorf("comment not found")
+	if err := u.voteRepo.DeleteByCommentID(ctx, commentID); err != nil {
+		log.Printf("Failed to delete helpful votes of comment %s: %v", commentID.Hex(), err)
 	}
+	if err := u.reportRepo.DeleteByCommentID(ctx, commentID); err != nil {
+		log.Printf("Failed to delete reports of comment %s: %v", commentID.Hex(), err)
+	}
+}
+
+// releaseCounts takes a comment that is going away out of its parent's reply
+// count and its resource's counter
+func (u *CommentUsecase) releaseCounts(ctx context.Context, comment *models.Comment) {
+	if comment.ParentID != nil {
+		if err := u.commentRepo.IncrementReplyCount(ctx, *comment.ParentID, -1); err != nil {
+			log.Printf("Failed to decrement reply count: %v", err)
+		}
+	}
+	u.adjustResourceCount(ctx, comment, -1)
+}
+
+// RestoreComment undoes a soft delete, recomputing reaction and reply counts
+// that may have drifted while the comment was deleted. Comments the TTL index
+// already purged are not found.
+func (u *CommentUsecase) RestoreComment(ctx context.Context, id string, adminID string) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if !comment.IsDeleted {
+		return nil, fmt.Errorf("comment is not deleted")
+	}
+
+	reactionCounts, likeCount, dislikeCount, err := countedReactions(ctx, u.reactionRepo, u.settingsRepo, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute reaction counts: %w", err)
+	}
+	replyCount, err := u.commentRepo.CountReplies(ctx, oid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute reply count: %w", err)
+	}
+
+	if err := u.commentRepo.Restore(ctx, oid); err != nil {
+		return nil, fmt.Errorf("failed to restore comment: %w", err)
+	}
+	u.adjustResourceCount(ctx, comment, 1)
+	if err := u.commentRepo.UpdateFields(ctx, oid, applyRecomputedCounts(comment, reactionCounts, likeCount, dislikeCount, int(replyCount))); err != nil {
+		return nil, fmt.Errorf("failed to update comment counts: %w", err)
+	}
+
+	// Increment parent reply count
+	if comment.ParentID != nil {
+		if err := u.commentRepo.IncrementReplyCount(ctx, *comment.ParentID, 1); err != nil {
+			log.Printf("Failed to increment reply count: %v", err)
+		}
+	}
+
+	markRestored(comment)
+	u.audit(ctx, AuditCommentRestored, comment, adminID)
+	invalidateCache(ctx, u.cache, comment)
+	return comment, nil
+}
+
+// markRestored clears a comment's soft-delete markers in memory, matching
+// CommentRepository.Restore
+func markRestored(comment *models.Comment) {
+	comment.IsDeleted = false
+	comment.DeletedAt = nil
+	comment.DeletedBy = ""
+}
+
+// ListComments retrieves comments with filters
+func (u *CommentUsecase) ListComments(ctx context.Context, req models.ListCommentsRequest, userID string, isAdmin bool) (*models.ListCommentsResponse, error) {
+	if err := checkDateRange(req); err != nil {
+		return nil, err
+	}
+
+	// Listing arbitrary sets of authors is for moderators and team dashboards
+	if len(req.AuthorIDs) > 0 {
+		if !isAdmin {
+			return nil, fmt.Errorf("only admins can list comments by several authors")
+		}
+		authorIDs, err := normalizeAuthorIDs(req.AuthorIDs)
+		if err != nil {
+			return nil, err
+		}
+		req.AuthorIDs = authorIDs
+	}
+
+	if !isAdmin {
+		restrictToOwnPending(&req, userID)
+	}
+	if userID == "" && !isAdmin {
+		restrictToPublic(&req)
+	}
+
+	var (
+		comments   []*models.Comment
+		total      int64
+		nextCursor string
+		err        error
+	)
+	if req.Cursor != "" {
+		req.Page = 1
+		comments, total, nextCursor, err = u.commentRepo.ListByCursor(ctx, req)
+	} else {
+		comments, total, err = u.listCached(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := models.NewPagination(total, req.Page, req.PageSize)
+
+	setListFlags(comments)
+
+	// Give replies in the flat view a reference to who they answer
+	if req.View == models.ViewFlat {
+		if err := u.annotateReplies(ctx, comments, req.PendingFor, isAdmin); err != nil {
+			return nil, fmt.Errorf("failed to load reply context: %w", err)
+		}
+	}
+
+	// Flag comments the caller hasn't seen yet
+	if req.UnreadFor != "" {
+		lastSeen, err := u.viewRepo.GetLastSeen(ctx, req.TenantID, req.ResourceType, req.ResourceID, req.UnreadFor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last seen: %w", err)
+		}
+		flagUnread(comments, lastSeen, req.UnreadFor)
+	}
+
+	repliesDisabled := u.repliesDisabledLookup(ctx)
+	setRepliesDisabled(comments, repliesDisabled)
+
+	return &models.ListCommentsResponse{
+		Comments:        comments,
+		Total:           pagination.Total,
+		Page:            pagination.Page,
+		PageSize:        pagination.PageSize,
+		TotalPages:      pagination.TotalPages,
+		NextCursor:      nextCursor,
+		RepliesDisabled: req.ResourceType != "" && repliesDisabled(req.TenantID, req.ResourceType),
+	}, nil
+}
+
+// listCached lists comments, serving the default first page of a resource
+// from the read cache
+func (u *CommentUsecase) listCached(ctx context.Context, req models.ListCommentsRequest) ([]*models.Comment, int64, error) {
+	if u.cache == nil || !isCacheableList(req) {
+		return u.list(ctx, req)
+	}
+
+	key := listCacheKey(req.TenantID, req.ResourceType, req.ResourceID, 1)
+	var cached cachedPage
+	if getCached(ctx, u.cache, key, &cached) {
+		return cached.Comments, cached.Total, nil
+	}
+
+	comments, total, err := u.list(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	setCached(ctx, u.cache, key, cachedPage{Comments: comments, Total: total})
+	return comments, total, nil
+}
+
+// checkDateRange rejects listings whose created_at range is inverted
+func checkDateRange(req models.ListCommentsRequest) error {
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && req.CreatedAfter.After(*req.CreatedBefore) {
+		return fmt.Errorf("createdAfter must not be after createdBefore")
+	}
+	return nil
+}
+
+// MaxListAuthorIDs caps how many authors one comment listing may cover
+const MaxListAuthorIDs = 100
+
+// normalizeAuthorIDs trims and dedupes a listing's author IDs, dropping
+// blanks, and enforces MaxListAuthorIDs
+func normalizeAuthorIDs(authorIDs []string) ([]string, error) {
+	normalized := make([]string, 0, len(authorIDs))
+	for _, id := range authorIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !slices.Contains(normalized, id) {
+			normalized = append(normalized, id)
+		}
+	}
+	if len(normalized) > MaxListAuthorIDs {
+		return nil, fmt.Errorf("at most %d author IDs can be listed at once", MaxListAuthorIDs)
+	}
+	return normalized, nil
+}
+
+// annotateReplies sets ReplyingToName on replies, fetching parents that
+// aren't already part of the page
+func (u *CommentUsecase) annotateReplies(ctx context.Context, comments []*models.Comment, pendingFor string, isAdmin bool) error {
+	parents := make(map[primitive.ObjectID]*models.Comment, len(comments))
+	for _, comment := range comments {
+		parents[comment.ID] = comment
+	}
+
+	var missing []primitive.ObjectID
+	for _, comment := range comments {
+		if comment.ParentID != nil && parents[*comment.ParentID] == nil {
+			missing = append(missing, *comment.ParentID)
+		}
+	}
+	if len(missing) > 0 {
+		fetched, err := u.commentRepo.GetByIDs(ctx, missing)
+		if err != nil {
+			return err
+		}
+		for id, parent := range fetched {
+			parents[id] = parent
+		}
+	}
+
+	setReplyingTo(comments, parents, pendingFor, isAdmin)
+	return nil
+}
+
+// GetCommentTree retrieves a resource's approved comments as a tree of roots
+// and nested replies, down to maxDepth (see treeDepth). With
+// IncludeDeletedPlaceholders, deleted comments with live replies stay in the
+// tree as placeholders.
+func (u *CommentUsecase) GetCommentTree(ctx context.Context, req models.ListCommentsRequest, maxDepth int, isAdmin bool) ([]*models.CommentWithReplies, error) {
+	if req.ResourceType == "" || req.ResourceID == "" {
+		return nil, fmt.Errorf("resource type and resource ID are required")
+	}
+
+	settings, err := u.settingsRepo.GetOrCreate(ctx, req.TenantID, req.ResourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	maxDepth = treeDepth(maxDepth, settings.MaxReplyDepth, isAdmin)
+
+	comments, err := u.commentRepo.GetResourceTree(ctx, req.TenantID, req.ResourceType, req.ResourceID, maxDepth, maxTreeComments, req.IncludeDeletedPlaceholders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	tree := buildCommentTree(comments)
+	if req.IncludeDeletedPlaceholders {
+		tree = pruneDeletedNodes(tree)
+	}
+	return tree, nil
+}
+
+// treeDepth resolves the depth a tree is loaded to. Readers are capped at the
+// settings' max reply depth, which 0 or less uses as is. Admins may go deeper,
+// up to MaxAdminTreeDepth, to see replies posted before the limit was lowered.
+func treeDepth(requested, settingsDepth int, isAdmin bool) int {
+	if requested <= 0 {
+		return settingsDepth
+	}
+	if isAdmin {
+		return min(requested, max(settingsDepth, MaxAdminTreeDepth))
+	}
+	return min(requested, settingsDepth)
+}
+
+// GetReplies retrieves a page of replies for a comment and whether more
+// follow. Counting the total costs an extra query, so it's optional.
+func (u *CommentUsecase) GetReplies(ctx context.Context, commentID, userID string, page, pageSize int, withTotal bool) ([]*models.Comment, int64, bool, error) {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("invalid comment ID")
+	}
+
+	replies, total, hasMore, err := u.commentRepo.GetReplies(ctx, oid, userID, page, pageSize, withTotal)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	setRepliesDisabled(replies, u.repliesDisabledLookup(ctx))
+	return replies, total, hasMore, nil
+}
+
+// GetThread retrieves a root comment and its whole reply subtree, shallowest
+// replies first. Any comment in the thread may be given.
+func (u *CommentUsecase) GetThread(ctx context.Context, commentID string) (*models.Comment, []*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comment == nil {
+		return nil, nil, fmt.Errorf("comment not found")
+	}
+
+	root := comment
+	if comment.RootID != nil {
+		root, err = u.commentRepo.GetByID(ctx, *comment.RootID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if root == nil || !isPubliclyVisible(root) {
+		return nil, nil, fmt.Errorf("comment not found")
+	}
+
+	replies, err := u.commentRepo.GetThread(ctx, root.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	return root, replies, nil
+}
+
+// GetNewerComments retrieves approved root comments on a resource created after
+// the given comment, for prepending in infinite-scroll views
+func (u *CommentUsecase) GetNewerComments(ctx context.Context, tenantID, resourceType, resourceID, afterID string, limit int) ([]*models.Comment, error) {
+	if resourceType == "" || resourceID == "" {
+		return nil, fmt.Errorf("resource type and resource ID are required")
+	}
+
+	oid, err := primitive.ObjectIDFromHex(afterID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	anchor, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if anchor == nil || anchor.TenantID != tenantID || anchor.ResourceType != resourceType || anchor.ResourceID != resourceID {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	comments, err := u.commentRepo.ListNewer(ctx, tenantID, resourceType, resourceID, anchor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get newer comments: %w", err)
+	}
+	if comments == nil {
+		comments = []*models.Comment{}
+	}
+
+	return comments, nil
+}
+
+// MarkResourceSeen records that a user has seen all current comments on a resource
+func (u *CommentUsecase) MarkResourceSeen(ctx context.Context, tenantID string, req models.MarkSeenRequest, userID string) error {
+	if req.ResourceType == "" || req.ResourceID == "" {
+		return fmt.Errorf("resource type and resource ID are required")
+	}
+
+	if err := u.viewRepo.MarkSeen(ctx, tenantID, req.ResourceType, req.ResourceID, userID, models.Now()); err != nil {
+		return fmt.Errorf("failed to mark resource as seen: %w", err)
+	}
+
+	return nil
+}
+
+// ModerateComment approves or rejects a comment
+func (u *CommentUsecase) ModerateComment(ctx context.Context, id string, req models.ModerateCommentRequest, moderatorID string) (*models.Comment, error) {
+	comment, wasApproved, err := u.moderate(ctx, id, req, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	go u.sendNotifications(u.moderatedNotifications(comment, wasApproved))
+
+	return comment, nil
+}
+
+// BulkModerateComments moderates several comments and notifies their authors
+// (and anyone newly visible mentions reach) in one batched notifier call. It
+// returns the IDs that could not be moderated.
+func (u *CommentUsecase) BulkModerateComments(ctx context.Context, ids []string, req models.ModerateCommentRequest, moderatorID string) []string {
+	failedIDs := []string{}
+	var notifications []NotificationRequest
+
+	for _, id := range ids {
+		comment, wasApproved, err := u.moderate(ctx, id, req, moderatorID)
+		if err != nil {
+			failedIDs = append(failedIDs, id)
+			continue
+		}
+		notifications = append(notifications, u.moderatedNotifications(comment, wasApproved)...)
+	}
+
+	go u.sendNotifications(notifications)
+
+	return failedIDs
+}
+
+// moderatedNotifications builds what a moderation tells people: the author,
+// once the moderation is final, and mentioned users, once the comment first
+// becomes visible
+func (u *CommentUsecase) moderatedNotifications(comment *models.Comment, wasApproved bool) []NotificationRequest {
+	var notifications []NotificationRequest
+	if comment.Status != models.StatusPendingSecondApproval {
+		notifications = append(notifications, u.moderationNotification(comment))
+	}
+	if !wasApproved && comment.Status == models.StatusApproved {
+		notifications = append(notifications, u.mentionNotifications(comment)...)
+	}
+	return notifications
+}
+
+// moderate sets a comment's moderation status and records it, reporting
+// whether the comment was approved before
+func (u *CommentUsecase) moderate(ctx context.Context, id string, req models.ModerateCommentRequest, moderatorID string) (*models.Comment, bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, false, err
+	}
+	if comment == nil {
+		return nil, false, fmt.Errorf("comment not found")
+	}
+
+	wasApproved := comment.Status == models.StatusApproved
+	if req.Status == models.StatusApproved {
+		settings, err := u.settingsRepo.GetOrCreate(ctx, comment.TenantID, comment.ResourceType)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get settings: %w", err)
+		}
+		if err := applyApproval(comment, moderatorID, settings.DualModeration); err != nil {
+			return nil, false, err
+		}
+	} else {
+		comment.Status = req.Status
+		comment.ModerationApprovals = nil
+	}
+
+	now := models.Now()
+	comment.ModeratedBy = moderatorID
+	comment.ModeratedAt = &now
+
+	if req.Status == models.StatusRejected {
+		comment.RejectionReason = req.RejectionReason
+	}
+	if comment.Status == models.StatusApproved {
+		comment.EditsSinceApproval = 0
+	}
+
+	if err := u.commentRepo.Update(ctx, comment); err != nil {
+		return nil, false, fmt.Errorf("failed to moderate comment: %w", err)
+	}
+	u.audit(ctx, AuditCommentModerated, comment, moderatorID)
+	invalidateCache(ctx, u.cache, comment)
+	dispatchWebhook(u.webhooks, u.settingsRepo.GetEffective, u.cfg.Notifier.MetadataKeys, WebhookCommentModerated, comment)
+
+	return comment, wasApproved, nil
+}
+
+// isPending reports whether a comment still awaits moderation
+func isPending(status models.CommentStatus) bool {
+	return slices.Contains(models.PendingStatuses, status)
+}
+
+// applyApproval records a moderator's approval. Under dual moderation the
+// first sign-off only moves the comment to pending_second_approval, and it
+// becomes approved once a second, different moderator signs off. Approvals
+// count only while the comment awaits its second one, so a comment that went
+// back to pending needs two fresh sign-offs.
+func applyApproval(comment *models.Comment, moderatorID string, dual bool) error {
+	if !dual {
+		comment.Status = models.StatusApproved
+		comment.ModerationApprovals = nil
+		return nil
+	}
+	if comment.Status == models.StatusApproved {
+		return nil
+	}
+
+	if comment.Status != models.StatusPendingSecondApproval {
+		comment.ModerationApprovals = nil
+	}
+	for _, approver := range comment.ModerationApprovals {
+		if approver == moderatorID {
+			return fmt.Errorf("you have already approved this comment")
+		}
+	}
+	comment.ModerationApprovals = append(comment.ModerationApprovals, moderatorID)
+
+	if len(comment.ModerationApprovals) >= 2 {
+		comment.Status = models.StatusApproved
+	} else {
+		comment.Status = models.StatusPendingSecondApproval
+	}
+	return nil
+}
+
+// PinComment pins or unpins a comment
+func (u *CommentUsecase) PinComment(ctx context.Context, id string, isPinned bool, userID string) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	now := models.Now()
+	comment.IsPinned = isPinned
+	if isPinned {
+		comment.PinnedBy = userID
+		comment.PinnedAt = &now
+	} else {
+		comment.PinnedBy = ""
+		comment.PinnedAt = nil
+	}
+
+	if err := u.commentRepo.Update(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to pin comment: %w", err)
+	}
+	invalidateCache(ctx, u.cache, comment)
+
+	return comment, nil
+}
+
+// LockReactions freezes or unfreezes reactions on a comment
+func (u *CommentUsecase) LockReactions(ctx context.Context, id string, isLocked bool) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	if err := u.commentRepo.UpdateFields(ctx, oid, bson.M{"reactions_locked": isLocked}); err != nil {
+		return nil, fmt.Errorf("failed to lock reactions: %w", err)
+	}
+
+	comment.ReactionsLocked = isLocked
+	invalidateCache(ctx, u.cache, comment)
+	return comment, nil
+}
+
+// SetSortWeight sets the manual sort weight curators use to hand-order comments
+func (u *CommentUsecase) SetSortWeight(ctx context.Context, id string, weight int) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+	if weight < 0 {
+		return nil, fmt.Errorf("sort weight cannot be negative")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	if err := u.commentRepo.SetSortWeight(ctx, oid, weight); err != nil {
+		return nil, fmt.Errorf("failed to set sort weight: %w", err)
+	}
+
+	comment.SortWeight = weight
+	invalidateCache(ctx, u.cache, comment)
+	return comment, nil
+}
+
+// AddLabels attaches moderation labels from the tenant's label vocabulary to a comment
+func (u *CommentUsecase) AddLabels(ctx context.Context, id string, labels []string) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+	labels = normalizeLabels(labels)
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("at least one label is required")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	settings, err := u.settingsRepo.GetOrCreate(ctx, comment.TenantID, comment.ResourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	if err := checkLabels(labels, settings.ModerationLabels); err != nil {
+		return nil, err
+	}
+
+	if err := u.commentRepo.AddLabels(ctx, oid, labels); err != nil {
+		return nil, fmt.Errorf("failed to add labels: %w", err)
+	}
+
+	comment.Labels = mergeLabels(comment.Labels, labels)
+	invalidateCache(ctx, u.cache, comment)
+	return comment, nil
+}
+
+// RemoveLabels detaches moderation labels from a comment. Labels no longer in
+// the vocabulary can still be removed.
+func (u *CommentUsecase) RemoveLabels(ctx context.Context, id string, labels []string) (*models.Comment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+	labels = normalizeLabels(labels)
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("at least one label is required")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	if err := u.commentRepo.RemoveLabels(ctx, oid, labels); err != nil {
+		return nil, fmt.Errorf("failed to remove labels: %w", err)
+	}
+
+	comment.Labels = dropLabels(comment.Labels, labels)
+	invalidateCache(ctx, u.cache, comment)
+	return comment, nil
+}
+
+// MergeThreads moves the source comment's replies under the target comment and
+// soft-deletes the source
+func (u *CommentUsecase) MergeThreads(ctx context.Context, req models.MergeCommentsRequest, moderatorID string) (*models.Comment, error) {
+	sourceID, err := primitive.ObjectIDFromHex(req.SourceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source comment ID")
+	}
+	targetID, err := primitive.ObjectIDFromHex(req.TargetID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target comment ID")
+	}
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a comment into itself")
+	}
+
+	source, err := u.commentRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("source comment not found")
+	}
+	target, err := u.commentRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("target comment not found")
+	}
+	if target.IsDeleted {
+		return nil, fmt.Errorf("cannot merge into a deleted comment")
+	}
+
+	settings, err := u.settingsRepo.GetOrCreate(ctx, target.TenantID, target.ResourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	descendants, err := u.collectDescendants(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load source thread: %w", err)
+	}
+	if err := planThreadMerge(source, target, descendants, settings.MaxReplyDepth); err != nil {
+		return nil, err
+	}
+
+	for _, reply := range descendants {
+		if err := u.commentRepo.UpdateFields(ctx, reply.ID, bson.M{
+			"parent_id": reply.ParentID,
+			"root_id":   reply.RootID,
+			"depth":     reply.Depth,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to move reply %s: %w", reply.ID.Hex(), err)
+		}
+	}
+
+	if !source.IsDeleted {
+		if err := u.commentRepo.SoftDelete(ctx, sourceID, moderatorID); err != nil {
+			return nil, fmt.Errorf("failed to delete source comment: %w", err)
+		}
+		u.adjustResourceCount(ctx, source, -1)
+		if source.ParentID != nil {
+			if err := u.commentRepo.IncrementReplyCount(ctx, *source.ParentID, -1); err != nil {
+				log.Printf("Failed to decrement reply count: %v", err)
+			}
+		}
+	}
+
+	// Recompute reply counts for both ends of the merge
+	for _, comment := range []*models.Comment{source, target} {
+		replyCount, err := u.commentRepo.CountReplies(ctx, comment.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recompute reply count: %w", err)
+		}
+		if err := u.commentRepo.UpdateFields(ctx, comment.ID, bson.M{"reply_count": int(replyCount)}); err != nil {
+			return nil, fmt.Errorf("failed to update reply count: %w", err)
+		}
+		comment.ReplyCount = int(replyCount)
+	}
+	invalidateCache(ctx, u.cache, append(descendants, source, target)...)
+
+	return target, nil
+}
+
+// collectDescendants loads every reply below a comment, breadth first
+func (u *CommentUsecase) collectDescendants(ctx context.Context, id primitive.ObjectID) ([]*models.Comment, error) {
+	var descendants []*models.Comment
+	level := []primitive.ObjectID{id}
+
+	for len(level) > 0 {
+		children, err := u.commentRepo.GetChildren(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+
+		level = level[:0]
+		for _, child := range children {
+			descendants = append(descendants, child)
+			level = append(level, child.ID)
+		}
+	}
+
+	return descendants, nil
+}
+
+// GetPendingComments retrieves comments pending moderation
+func (u *CommentUsecase) GetPendingComments(ctx context.Context, tenantID string, page, pageSize int) ([]*models.Comment, int64, error) {
+	return u.commentRepo.GetPending(ctx, tenantID, page, pageSize)
+}
+
+// GetCommentStats retrieves comment statistics
+func (u *CommentUsecase) GetCommentStats(ctx context.Context, tenantID, resourceType, resourceID string) (*models.CommentStats, error) {
+	stats, err := u.commentRepo.GetStats(ctx, tenantID, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The counter is kept in step with creates and deletes, so it's the
+	// authoritative total once it exists
+	if total, ok := u.resourceCount(ctx, tenantID, resourceType, resourceID); ok {
+		stats.TotalComments = total
+	}
+	return stats, nil
+}
+
+// GetStatusCounts gets a resource's comment counts by status
+func (u *CommentUsecase) GetStatusCounts(ctx context.Context, tenantID, resourceType, resourceID string) (*models.StatusCounts, error) {
+	if resourceType == "" || resourceID == "" {
+		return nil, fmt.Errorf("resource type and resource ID are required")
+	}
+
+	counts, total, err := u.commentRepo.CountByStatus(ctx, tenantID, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StatusCounts{Counts: counts, Total: total}, nil
+}
+
+// GetModerationHistory gets the status changes a comment has gone through,
+// oldest first, starting with the status it was created with
+func (u *CommentUsecase) GetModerationHistory(ctx context.Context, id string) ([]models.ModerationTransition, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	entries, err := u.auditRepo.GetByComment(ctx, oid, moderationActions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation history: %w", err)
+	}
+
+	return moderationHistory(entries), nil
+}
+
+// moderationHistory turns audit entries into moderation transitions
+func moderationHistory(entries []*models.AuditEntry) []models.ModerationTransition {
+	history := make([]models.ModerationTransition, 0, len(entries))
+	for _, entry := range entries {
+		history = append(history, models.ModerationTransition{
+			Action:      entry.Action,
+			Status:      entry.Status,
+			Reason:      entry.Reason,
+			ModeratorID: entry.ActorID,
+			OnBehalfOf:  entry.OnBehalfOf,
+			At:          entry.CreatedAt,
+		})
+	}
+	return history
+}
+
+// GetAuthorSummary gets an author's comment counts by status, approval rate
+// and the reports filed against them, running both aggregations in parallel
+func (u *CommentUsecase) GetAuthorSummary(ctx context.Context, tenantID, authorID, resourceType string) (*models.AuthorSummary, error) {
+	if authorID == "" {
+		return nil, fmt.Errorf("author ID is required")
+	}
+
+	var (
+		wg                    sync.WaitGroup
+		counts                map[string]int64
+		total, reports        int64
+		countsErr, reportsErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		counts, total, countsErr = u.commentRepo.CountAuthorByStatus(ctx, tenantID, authorID, resourceType)
+	}()
+	go func() {
+		defer wg.Done()
+		reports, reportsErr = u.commentRepo.SumAuthorReports(ctx, tenantID, authorID, resourceType)
+	}()
+	wg.Wait()
+
+	if countsErr != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", countsErr)
+	}
+	if reportsErr != nil {
+		return nil, fmt.Errorf("failed to count reports: %w", reportsErr)
+	}
+
+	return newAuthorSummary(authorID, resourceType, counts, total, reports), nil
+}
+
+// newAuthorSummary assembles an author summary. The approval rate only
+// counts comments a moderator (or the auto-approval rules) has decided on.
+func newAuthorSummary(authorID, resourceType string, counts map[string]int64, total, reports int64) *models.AuthorSummary {
+	if counts == nil {
+		counts = map[string]int64{}
+	}
+
+	summary := &models.AuthorSummary{
+		AuthorID:      authorID,
+		ResourceType:  resourceType,
+		Counts:        counts,
+		TotalComments: total,
+		ReportCount:   reports,
+	}
+	decided := total
+	for _, status := range models.PendingStatuses {
+		decided -= counts[string(status)]
+	}
+	if decided > 0 {
+		summary.ApprovalRate = float64(counts[string(models.StatusApproved)]) / float64(decided)
+	}
+	return summary
+}
+
+// SearchComments searches comments
+func (u *CommentUsecase) SearchComments(ctx context.Context, tenantID, query string, page, pageSize int) ([]*models.Comment, int64, error) {
+	return u.commentRepo.Search(ctx, tenantID, query, page, pageSize)
+}
+
+// audit records an action on a comment. Failures are logged, never returned.
+func (u *CommentUsecase) audit(ctx context.Context, action string, comment *models.Comment, userID string) {
+	if u.auditRepo == nil {
+		return
+	}
+	if err := u.auditRepo.Create(ctx, newAuditEntry(ctx, action, comment, userID)); err != nil {
+		log.Printf("Failed to record audit entry: %v", err)
+	}
+}
+
+// impersonatorKey is the context key WithImpersonator stores the acting admin under
+type impersonatorKey struct{}
+
+// WithImpersonator returns ctx recording that adminID is acting on behalf of
+// the request's user. An empty adminID leaves ctx as is.
+func WithImpersonator(ctx context.Context, adminID string) context.Context {
+	if adminID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, impersonatorKey{}, adminID)
+}
+
+// newAuditEntry attributes an action to the real caller. When an admin
+// impersonates userID (see WithImpersonator), the admin is the actor.
+func newAuditEntry(ctx context.Context, action string, comment *models.Comment, userID string) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		TenantID:  comment.TenantID,
+		Action:    action,
+		CommentID: comment.ID,
+		ActorID:   userID,
+		Status:    comment.Status,
+	}
+	if comment.Status == models.StatusRejected {
+		entry.Reason = comment.RejectionReason
+	}
+	if admin, _ := ctx.Value(impersonatorKey{}).(string); admin != "" {
+		entry.ActorID = admin
+		entry.OnBehalfOf = userID
+	}
+	return entry
+}
+
+// rawContent returns the unprocessed input to store, if enabled
+func (u *CommentUsecase) rawContent(processed *ProcessedContent) string {
+	if !u.cfg.Moderation.StoreRawContent {
+		return ""
+	}
+	return processed.Original
+}
+
+// applyRecomputedCounts overwrites a comment's stored counts with freshly
+// computed ones and returns the fields to persist
+func applyRecomputedCounts(comment *models.Comment, reactionCounts map[string]int, likeCount, dislikeCount, replyCount int) bson.M {
+	comment.ReactionCounts = reactionCounts
+	comment.TotalReactions = models.SumReactionCounts(reactionCounts)
+	comment.LikeCount = likeCount
+	comment.DislikeCount = dislikeCount
+	comment.ReplyCount = replyCount
+
+	return bson.M{
+		"reaction_counts": reactionCounts,
+		"total_reactions": comment.TotalReactions,
+		"like_count":      likeCount,
+		"dislike_count":   dislikeCount,
+		"reply_count":     replyCount,
+	}
+}
+
+// checkAttachmentURLs rejects attachments whose URL isn't an absolute web
+// URL, so javascript:, data: and relative URLs never reach a renderer. With
+// requireHTTPS, plain http:// is rejected too.
+func checkAttachmentURLs(attachments []models.Attachment, requireHTTPS bool) error {
+	for _, attachment := range attachments {
+		if !isAllowedURL(attachment.URL, requireHTTPS) {
+			if requireHTTPS {
+				return fmt.Errorf("attachment URL must be an absolute https:// URL")
+			}
+			return fmt.Errorf("attachment URL must be an absolute http(s):// URL")
+		}
+	}
+	return nil
+}
+
+// initialStatus decides whether a new comment is approved straight away:
+// when approval isn't required, or when the settings trust verified authors
+// and nothing was flagged
+func initialStatus(settings *models.CommentSettings, flaggedWords []string, isVerified bool) models.CommentStatus {
+	if !settings.RequireApproval {
+		return models.StatusApproved
+	}
+	if len(flaggedWords) > 0 {
+		return models.StatusPending // Force pending if bad words detected
+	}
+	if settings.AutoApproveVerified && isVerified {
+		return models.StatusApproved
+	}
+	return models.StatusPending
+}
+
+// checkAttachmentSizes applies the per-attachment and per-comment size caps,
+// so several files that each fit can't add up to an oversized comment
+func checkAttachmentSizes(attachments []models.Attachment, settings *models.CommentSettings) error {
+	var total int64
+	for _, attachment := range attachments {
+		if attachment.Size < 0 {
+			return fmt.Errorf("attachment size cannot be negative")
+		}
+		if settings.MaxAttachmentSize > 0 && attachment.Size > settings.MaxAttachmentSize {
+			return fmt.Errorf("attachment %s exceeds the maximum size of %d bytes", attachment.Filename, settings.MaxAttachmentSize)
+		}
+		total += attachment.Size
+	}
+	if settings.MaxTotalAttachmentSize > 0 && total > settings.MaxTotalAttachmentSize {
+		return fmt.Errorf("attachments exceed the maximum total size of %d bytes", settings.MaxTotalAttachmentSize)
+	}
+	return nil
+}
+
+// allowedAvatar returns the avatar URL, or "" when it isn't allowed. The
+// avatar comes from the identity provider rather than the commenter, so a bad
+// one is dropped instead of failing the comment.
+func allowedAvatar(avatar string, requireHTTPS bool) string {
+	if avatar == "" || !isAllowedURL(avatar, requireHTTPS) {
+		return ""
+	}
+	return avatar
+}
 
-	now := time.Now()
-	comment.IsPinned = isPinned
-	if isPinned {
-		comment.PinnedBy = userID
-		comment.PinnedAt = &now
-	} else {
-		comment.PinnedBy = ""
-		comment.PinnedAt = nil
+// isAllowedURL reports whether raw is an absolute https URL, or http when
+// requireHTTPS is off
+func isAllowedURL(raw string, requireHTTPS bool) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return false
 	}
 
-	if err := u.commentRepo.Update(ctx, comment); err != nil {
-		return nil, fmt.Errorf("failed to pin comment: %w", err)
+	switch strings.ToLower(parsed.Scheme) {
+	case "https":
+		return true
+	case "http":
+		return !requireHTTPS
 	}
+	return false
+}
 
-	return comment, nil
+// checkKillSwitch rejects new comments while an operator has switched
+// creation off. A failed lookup lets the comment through rather than turning
+// a database hiccup into an outage.
+func checkKillSwitch(ctx context.Context, checker KillSwitchChecker, tenantID string) error {
+	if checker == nil {
+		return nil
+	}
+	disabled, err := checker.CommentingDisabled(ctx, tenantID)
+	if err != nil {
+		log.Printf("Failed to check kill switch: %v", err)
+		return nil
+	}
+	if disabled {
+		return fmt.Errorf("commenting temporarily disabled")
+	}
+	return nil
 }
 
-// GetPendingComments retrieves comments pending moderation
-func (u *CommentUsecase) GetPendingComments(ctx context.Context, tenantID string, page, pageSize int) ([]*models.Comment, int64, error) {
-	return u.commentRepo.GetPending(ctx, tenantID, page, pageSize)
+// checkResourceExists rejects comments on resources the resource type's
+// validator doesn't know. Types without a validator accept any resource ID,
+// and validator errors fail open so an outage there can't block commenting.
+func checkResourceExists(ctx context.Context, validator ResourceValidator, tenantID, resourceID string) error {
+	if validator == nil {
+		return nil
+	}
+
+	exists, err := validator.ResourceExists(ctx, tenantID, resourceID)
+	if err != nil {
+		log.Printf("Failed to validate resource %s: %v", resourceID, err)
+		return nil
+	}
+	if !exists {
+		return fmt.Errorf("resource not found")
+	}
+	return nil
 }
 
-// GetCommentStats retrieves comment statistics
-func (u *CommentUsecase) GetCommentStats(ctx context.Context, tenantID, resourceType, resourceID string) (*models.CommentStats, error) {
-	return u.commentRepo.GetStats(ctx, tenantID, resourceType, resourceID)
+// checkGeoRestriction rejects comments from countries the tenant has blocked,
+// or from outside its allow list. Resolver failures fail open.
+func checkGeoRestriction(ctx context.Context, resolver GeoResolver, ipAddress string, settings *models.CommentSettings) error {
+	if resolver == nil || (len(settings.BlockedCountries) == 0 && len(settings.AllowedCountries) == 0) {
+		return nil
+	}
+
+	country, err := resolver.CountryForIP(ctx, ipAddress)
+	if err != nil {
+		log.Printf("Failed to resolve country for IP %s: %v", ipAddress, err)
+		return nil
+	}
+	if country == "" {
+		return nil
+	}
+
+	for _, blocked := range settings.BlockedCountries {
+		if strings.EqualFold(blocked, country) {
+			return fmt.Errorf("comments are not allowed from your region")
+		}
+	}
+
+	if len(settings.AllowedCountries) > 0 {
+		for _, allowed := range settings.AllowedCountries {
+			if strings.EqualFold(allowed, country) {
+				return nil
+			}
+		}
+		return fmt.Errorf("comments are not allowed from your region")
+	}
+
+	return nil
 }
 
-// SearchComments searches comments
-func (u *CommentUsecase) SearchComments(ctx context.Context, tenantID, query string, page, pageSize int) ([]*models.Comment, int64, error) {
-	return u.commentRepo.Search(ctx, tenantID, query, page, pageSize)
+// languageCheck is what checkLanguage found out about a comment's content
+type languageCheck struct {
+	Language   string // detected language, empty when unknown
+	Translated string // content in the expected language, if translated
+	Hold       bool   // the comment should wait for review
+}
+
+// checkLanguage compares content against the settings' expected language.
+// Content in another language is held for review, or translated when the
+// mismatch action is translate. Provider failures fail open.
+func checkLanguage(ctx context.Context, provider TranslationProvider, content string, settings *models.CommentSettings) languageCheck {
+	if provider == nil || settings.ExpectedLanguage == "" {
+		return languageCheck{}
+	}
+
+	language, err := provider.DetectLanguage(ctx, content)
+	if err != nil {
+		log.Printf("Failed to detect comment language: %v", err)
+		return languageCheck{}
+	}
+	result := languageCheck{Language: strings.ToLower(language)}
+	if language == "" || strings.EqualFold(language, settings.ExpectedLanguage) {
+		return result
+	}
+
+	if settings.LanguageMismatchAction != models.ActionTranslate {
+		result.Hold = true
+		return result
+	}
+
+	translated, err := provider.Translate(ctx, content, settings.ExpectedLanguage)
+	if err != nil {
+		log.Printf("Failed to translate comment to %s: %v", settings.ExpectedLanguage, err)
+		return result
+	}
+	result.Translated = translated
+	return result
+}
+
+// checkOrigin rejects comments whose origin isn't one of the settings'
+// allowed origins. A missing origin is rejected too, so clients can't dodge
+// the check by leaving the headers off. No allowed origins disables it.
+func checkOrigin(origin string, settings *models.CommentSettings) error {
+	if len(settings.AllowedOrigins) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(origin))
+	if err == nil && parsed.Host != "" {
+		for _, allowed := range settings.AllowedOrigins {
+			if originMatches(parsed, allowed) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("comments are not allowed from this origin")
+}
+
+// originMatches compares a request origin to an allowed entry: a full origin
+// such as https://shop.example.com must match scheme and host, a bare host
+// matches any scheme, and *.example.com matches subdomains of example.com
+func originMatches(origin *url.URL, allowed string) bool {
+	allowed = strings.ToLower(strings.TrimRight(strings.TrimSpace(allowed), "/"))
+	if allowed == "" {
+		return false
+	}
+
+	host := strings.ToLower(origin.Host)
+	if scheme, rest, ok := strings.Cut(allowed, "://"); ok {
+		if scheme != strings.ToLower(origin.Scheme) {
+			return false
+		}
+		allowed = rest
+	}
+
+	if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == allowed
+}
+
+// buildCommentTree nests comments under their parents. Roots come back pinned
+// first and otherwise in input order; replies whose parent isn't in comments
+// are dropped.
+func buildCommentTree(comments []*models.Comment) []*models.CommentWithReplies {
+	nodes := make(map[primitive.ObjectID]*models.CommentWithReplies, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &models.CommentWithReplies{Comment: comment}
+	}
+
+	roots := []*models.CommentWithReplies{}
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if comment.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].Comment.IsPinned && !roots[j].Comment.IsPinned
+	})
+	return roots
+}
+
+// pruneDeletedNodes drops deleted comments from a tree unless a reply below
+// them is still live, in which case they are replaced with a placeholder
+func pruneDeletedNodes(nodes []*models.CommentWithReplies) []*models.CommentWithReplies {
+	kept := nodes[:0]
+	for _, node := range nodes {
+		node.Replies = pruneDeletedNodes(node.Replies)
+		if node.Comment.IsDeleted {
+			if len(node.Replies) == 0 {
+				continue
+			}
+			node.Comment = deletedPlaceholder(node.Comment)
+		}
+		kept = append(kept, node)
+	}
+	return kept
+}
+
+// deletedPlaceholder copies a deleted comment without its content or anything
+// identifying its author, keeping its place in the thread
+func deletedPlaceholder(comment *models.Comment) *models.Comment {
+	placeholder := *comment
+	placeholder.Content = DeletedPlaceholderText
+	placeholder.AuthorName = DeletedPlaceholderText
+	placeholder.AuthorID = ""
+	placeholder.AuthorEmail = ""
+	placeholder.AuthorAvatar = ""
+	placeholder.ContentHTML = ""
+	placeholder.Snippet = ""
+	placeholder.RawContent = ""
+	placeholder.Attachments = nil
+	placeholder.Mentions = nil
+	placeholder.EditHistory = nil
+	placeholder.Metadata = nil
+	placeholder.IPAddress = ""
+	placeholder.UserAgent = ""
+	placeholder.DeletedBy = ""
+	return &placeholder
+}
+
+// newCommentWithReplies nests up to n replies under a comment
+func newCommentWithReplies(comment *models.Comment, replies []*models.Comment, n int) *models.CommentWithReplies {
+	if len(replies) > n {
+		replies = replies[:n]
+	}
+
+	result := &models.CommentWithReplies{Comment: comment}
+	for _, reply := range replies {
+		result.Replies = append(result.Replies, &models.CommentWithReplies{Comment: reply})
+	}
+	return result
+}
+
+// replyPlacement returns the parent, root and depth of a new comment under
+// parent. A nil parent places it at the root.
+func replyPlacement(parent *models.Comment) (parentID, rootID *primitive.ObjectID, depth int) {
+	if parent == nil {
+		return nil, nil, 0
+	}
+
+	pid := parent.ID
+	root := pid
+	if parent.RootID != nil {
+		root = *parent.RootID
+	}
+	return &pid, &root, parent.Depth + 1
+}
+
+// planThreadMerge re-homes the source's descendants under the target, updating
+// their parent, root and depth in place. It rejects merges across resources,
+// into the source's own subtree, or past the maximum reply depth.
+func planThreadMerge(source, target *models.Comment, descendants []*models.Comment, maxDepth int) error {
+	if source.TenantID != target.TenantID || source.ResourceType != target.ResourceType || source.ResourceID != target.ResourceID {
+		return fmt.Errorf("comments belong to different resources")
+	}
+
+	for _, reply := range descendants {
+		if reply.ID == target.ID {
+			return fmt.Errorf("cannot merge a comment into its own reply")
+		}
+	}
+
+	rootID := target.ID
+	if target.RootID != nil {
+		rootID = *target.RootID
+	}
+	shift := target.Depth - source.Depth
+
+	for _, reply := range descendants {
+		if reply.Depth+shift > maxDepth {
+			return fmt.Errorf("merge would exceed maximum reply depth of %d", maxDepth)
+		}
+	}
+
+	for _, reply := range descendants {
+		if reply.ParentID != nil && *reply.ParentID == source.ID {
+			parentID := target.ID
+			reply.ParentID = &parentID
+		}
+		root := rootID
+		reply.RootID = &root
+		reply.Depth += shift
+	}
+
+	return nil
+}
+
+// checkPendingLimit rejects a new pending comment once the author already
+// has the tenant's maximum awaiting review
+func checkPendingLimit(pending int64, settings *models.CommentSettings) error {
+	if settings.MaxPendingPerAuthor > 0 && pending >= int64(settings.MaxPendingPerAuthor) {
+		return fmt.Errorf("too many comments awaiting review")
+	}
+	return nil
+}
+
+// checkRootLimit applies the resource's root comment cap. Past the cap a new
+// root is rejected outright, or reported as one to store already closed.
+func checkRootLimit(roots int64, settings *models.CommentSettings) (bool, error) {
+	if settings.MaxRootComments <= 0 || roots < int64(settings.MaxRootComments) {
+		return false, nil
+	}
+	if settings.MaxRootCommentsAction == models.ActionClose {
+		return true, nil
+	}
+	return false, fmt.Errorf("this resource has reached its maximum number of comments")
+}
+
+// normalizeLabels trims labels and drops empty and repeated ones
+func normalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	out := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		out = append(out, label)
+	}
+	return out
+}
+
+// checkLabels rejects labels outside the tenant's moderation label vocabulary
+func checkLabels(labels, vocabulary []string) error {
+	allowed := make(map[string]bool, len(vocabulary))
+	for _, label := range vocabulary {
+		allowed[label] = true
+	}
+	for _, label := range labels {
+		if !allowed[label] {
+			return fmt.Errorf("label %q is not in the moderation label vocabulary", label)
+		}
+	}
+	return nil
+}
+
+// mergeLabels adds labels a comment doesn't have yet, mirroring $addToSet
+func mergeLabels(existing, labels []string) []string {
+	for _, label := range labels {
+		if !slices.Contains(existing, label) {
+			existing = append(existing, label)
+		}
+	}
+	return existing
+}
+
+// dropLabels removes labels from a comment's labels, mirroring $pull
+func dropLabels(existing, labels []string) []string {
+	out := make([]string, 0, len(existing))
+	for _, label := range existing {
+		if !slices.Contains(labels, label) {
+			out = append(out, label)
+		}
+	}
+	return out
+}
+
+// checkReportedDelete applies the reported-delete policy to an author deleting
+// their own comment. Only the "block" action refuses the delete.
+func checkReportedDelete(action string, hasPendingReports bool) error {
+	if hasPendingReports && action == ReportedDeleteBlock {
+		return fmt.Errorf("comment has unresolved reports and cannot be deleted yet")
+	}
+	return nil
+}
+
+// checkEditWindow rejects an edit made more than the tenant's edit window
+// after the comment was posted
+func checkEditWindow(comment *models.Comment, settings *models.CommentSettings, now time.Time) error {
+	if settings.EditWindowSeconds <= 0 {
+		return nil
+	}
+	if now.Sub(comment.CreatedAt) > time.Duration(settings.EditWindowSeconds)*time.Second {
+		return fmt.Errorf("the edit window for this comment has expired")
+	}
+	return nil
+}
+
+// checkEditLock stops authors from swapping content while a comment awaits
+// moderation, or rewriting it under its replies. ReplyCount only tracks live
+// replies, so a comment whose replies were all deleted can be edited again.
+func checkEditLock(comment *models.Comment, settings *models.CommentSettings) error {
+	if settings.LockEditWhilePending && isPending(comment.Status) {
+		return fmt.Errorf("comments awaiting moderation can't be edited")
+	}
+	if settings.LockEditsAfterReply && comment.ReplyCount > 0 {
+		return fmt.Errorf("comments with replies can no longer be edited")
+	}
+	return nil
+}
+
+// editHistoryWithCurrent returns a comment's edit history followed by its
+// current content, dated from the last edit (or creation if never edited)
+func editHistoryWithCurrent(comment *models.Comment) []models.EditRecord {
+	current := models.EditRecord{
+		Content:  comment.Content,
+		EditedAt: comment.CreatedAt,
+		EditedBy: comment.AuthorID,
+	}
+	if n := len(comment.EditHistory); n > 0 {
+		current.EditedAt = comment.EditHistory[n-1].EditedAt
+		current.EditedBy = comment.EditHistory[n-1].EditedBy
+	}
+
+	history := make([]models.EditRecord, 0, len(comment.EditHistory)+1)
+	history = append(history, comment.EditHistory...)
+	return append(history, current)
+}
+
+// appendEditRecord adds an edit to a comment's history, dropping the oldest
+// records beyond keep (0 keeps all). It mirrors the $slice applied in storage.
+func appendEditRecord(history []models.EditRecord, record models.EditRecord, keep int) []models.EditRecord {
+	history = append(history, record)
+	if keep > 0 && len(history) > keep {
+		history = append([]models.EditRecord(nil), history[len(history)-keep:]...)
+	}
+	return history
+}
+
+// trackEditSinceApproval counts an edit and sends an approved comment back to
+// moderation once it has been edited more times than the tenant allows
+func trackEditSinceApproval(comment *models.Comment, settings *models.CommentSettings) {
+	comment.EditsSinceApproval++
+	if settings.ReModerateAfterEdits > 0 &&
+		comment.EditsSinceApproval > settings.ReModerateAfterEdits &&
+		comment.Status == models.StatusApproved {
+		comment.Status = models.StatusPending
+	}
+}
+
+// anonymousDisplayName picks the name shown on an anonymous comment. Tenants
+// may let anonymous commenters choose a name, or require one.
+func anonymousDisplayName(requested string, settings *models.CommentSettings) (string, error) {
+	name := strings.TrimSpace(requested)
+	if name == "" {
+		if settings.AnonymousRequireName {
+			return "", fmt.Errorf("a display name is required for anonymous comments")
+		}
+		return "Anonymous", nil
+	}
+	if !settings.AnonymousAllowName && !settings.AnonymousRequireName {
+		return "Anonymous", nil
+	}
+	return name, nil
+}
+
+// setReplyingTo names the parent author on each reply whose parent the
+// caller may see (see canSeeParent). Deleted parents are left unnamed.
+func setReplyingTo(comments []*models.Comment, parents map[primitive.ObjectID]*models.Comment, pendingFor string, isAdmin bool) {
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			continue
+		}
+		if parent := parents[*comment.ParentID]; parent != nil && !parent.IsDeleted && canSeeParent(parent, pendingFor, isAdmin) {
+			comment.ReplyingToName = parent.AuthorName
+		}
+	}
+}
+
+// canSeeParent reports whether a listing's caller may see a parent comment:
+// admins see any, others approved ones and their own pending ones
+func canSeeParent(parent *models.Comment, pendingFor string, isAdmin bool) bool {
+	if isAdmin || parent.Status == models.StatusApproved {
+		return true
+	}
+	return pendingFor != "" && parent.AuthorID == pendingFor && isPending(parent.Status)
+}
+
+// isPubliclyVisible reports whether an anonymous reader may see a comment
+func isPubliclyVisible(comment *models.Comment) bool {
+	return comment.Status == models.StatusApproved && !comment.IsDeleted
+}
+
+// restrictToPublic limits a listing to what anonymous readers may see and
+// drops any per-user enrichment
+func restrictToPublic(req *models.ListCommentsRequest) {
+	req.Status = models.StatusApproved
+	req.IncludeDeleted = false
+	req.UnreadFor = ""
+	req.PendingFor = ""
+}
+
+// restrictToOwnPending limits a non-admin's listing to approved comments plus
+// their own pending ones, whatever status was asked for
+func restrictToOwnPending(req *models.ListCommentsRequest, userID string) {
+	req.Status = models.StatusApproved
+	req.PendingFor = userID
 }
 
-// checkBadWords checks content for bad words
-func (u *CommentUsecase) checkBadWords(content string, customBadWords []string) []string {
-	var flagged []string
+// inheritParentResource forces a reply onto its parent's tenant and resource,
+// ignoring whatever the client sent
+func inheritParentResource(req *models.CreateCommentRequest, parent *models.Comment) {
+	req.TenantID = parent.TenantID
+	req.ResourceType = parent.ResourceType
+	req.ResourceID = parent.ResourceID
+}
 
-	// Check with default regex
-	if u.badWordsRegex != nil {
-		matches := u.badWordsRegex.FindAllString(content, -1)
-		flagged = append(flagged, matches...)
+// setListFlags derives the flags thread headers show without loading each
+// comment in full
+func setListFlags(comments []*models.Comment) {
+	for _, comment := range comments {
+		comment.HasAttachments = len(comment.Attachments) > 0
 	}
+}
 
-	// Check custom bad words
-	if len(customBadWords) > 0 {
-		pattern := "(?i)\\b(" + strings.Join(customBadWords, "|") + ")\\b"
-		if customRegex, err := regexp.Compile(pattern); err == nil {
-			matches := customRegex.FindAllString(content, -1)
-			flagged = append(flagged, matches...)
+// repliesDisabledLookup returns a check of whether current settings turn
+// replies off for a tenant's resource type, loading each type's settings once.
+// Settings that fail to load count as replies allowed; CreateComment still
+// enforces the setting.
+func (u *CommentUsecase) repliesDisabledLookup(ctx context.Context) func(tenantID, resourceType string) bool {
+	disabled := map[[2]string]bool{}
+	return func(tenantID, resourceType string) bool {
+		key := [2]string{tenantID, resourceType}
+		if off, ok := disabled[key]; ok {
+			return off
+		}
+		settings, err := u.settingsRepo.GetEffective(ctx, tenantID, resourceType)
+		if err != nil {
+			log.Printf("Failed to get settings for reply flags: %v", err)
+			return false
 		}
+		disabled[key] = !settings.AllowReplies
+		return disabled[key]
+	}
+}
+
+// setRepliesDisabled flags the comments that can't be replied to
+func setRepliesDisabled(comments []*models.Comment, repliesDisabled func(tenantID, resourceType string) bool) {
+	for _, comment := range comments {
+		comment.RepliesDisabled = repliesDisabled(comment.TenantID, comment.ResourceType)
 	}
+}
+
+// withSpamScore copies metadata with the comment's spam score added, leaving
+// the request's map untouched
+func withSpamScore(metadata map[string]any, score int) map[string]any {
+	result := make(map[string]any, len(metadata)+1)
+	maps.Copy(result, metadata)
+	result[SpamScoreMetadataKey] = score
+	return result
+}
 
-	// Remove duplicates
-	seen := make(map[string]bool)
-	unique := []string{}
-	for _, word := range flagged {
-		lower := strings.ToLower(word)
-		if !seen[lower] {
-			seen[lower] = true
-			unique = append(unique, word)
+// flagUnread marks comments created after lastSeen as unread, ignoring the user's own comments
+func flagUnread(comments []*models.Comment, lastSeen *time.Time, userID string) {
+	for _, comment := range comments {
+		if comment.AuthorID == userID {
+			continue
 		}
+		comment.IsUnread = lastSeen == nil || comment.CreatedAt.After(*lastSeen)
 	}
+}
 
-	return unique
+// newCommentRecipient returns who to tell about a new comment: the parent's
+// author for replies, and the resource owner for root comments
+func newCommentRecipient(req models.CreateCommentRequest, parent *models.Comment) string {
+	if parent != nil {
+		return parent.AuthorID
+	}
+	if req.ResourceOwnerID != "" {
+		return req.ResourceOwnerID
+	}
+	ownerID, _ := req.Metadata[OwnerIDMetadataKey].(string)
+	return ownerID
 }
 
-// sendNewCommentNotification sends notification for new comments
-func (u *CommentUsecase) sendNewCommentNotification(comment *models.Comment, settings *models.CommentSettings) {
+// sendNewCommentNotification sends notification for new comments to
+// recipient, or to moderators while the comment awaits approval. Authors are
+// never told about their own comments.
+func (u *CommentUsecase) sendNewCommentNotification(comment *models.Comment, settings *models.CommentSettings, recipient string) {
 	if u.notifier == nil || !u.cfg.Notifier.Enabled {
 		return
 	}
@@ -489,13 +2463,18 @@ func (u *CommentUsecase) sendNewCommentNotification(comment *models.Comment, set
 		title = "New Reply to Your Comment"
 	}
 	if comment.Status == models.StatusPending {
+		// Unapproved content only goes to moderators
 		title = "Comment Pending Approval"
 		notificationType = "comment.pending"
+		recipient = "moderators"
+	}
+	if recipient == "" || recipient == comment.AuthorID {
+		return
 	}
 
 	notification := NotificationRequest{
 		Type:       notificationType,
-		Recipients: []string{"admin"}, // Will be replaced with actual admin IDs
+		Recipients: []string{recipient},
 		Title:      title,
 		Body:       truncateString(comment.Content, 100),
 		Data: map[string]string{
@@ -506,9 +2485,14 @@ func (u *CommentUsecase) sendNewCommentNotification(comment *models.Comment, set
 			"author_id":     comment.AuthorID,
 			"status":        string(comment.Status),
 		},
+		Actor: comment.AuthorID,
 	}
+	if comment.ParentID != nil {
+		addAuthorLinkData(notification.Data, comment, u.cfg.Notifier.DeepLinkTemplate)
+	}
+	addNotificationMetadata(notification.Data, comment.Metadata, u.cfg.Notifier.MetadataKeys)
 
-	if err := u.notifier.SendNotification(ctx, notification); err != nil {
+	if err := dispatchNotification(ctx, u.notifier, u.cfg.Notifier, notification); err != nil {
 		log.Printf("Failed to send notification: %v", err)
 	}
 }
@@ -522,6 +2506,14 @@ func (u *CommentUsecase) sendModerationNotification(comment *models.Comment) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if err := dispatchNotification(ctx, u.notifier, u.cfg.Notifier, u.moderationNotification(comment)); err != nil {
+		log.Printf("Failed to send moderation notification: %v", err)
+	}
+}
+
+// moderationNotification builds the notification telling an author how their
+// comment was moderated
+func (u *CommentUsecase) moderationNotification(comment *models.Comment) NotificationRequest {
 	title := "Your Comment Was Approved"
 	body := "Your comment has been approved and is now visible."
 	if comment.Status == models.StatusRejected {
@@ -541,16 +2533,139 @@ func (u *CommentUsecase) sendModerationNotification(comment *models.Comment) {
 			"comment_id": comment.ID.Hex(),
 			"status":     string(comment.Status),
 		},
+		Actor: comment.ModeratedBy,
 	}
+	addNotificationMetadata(notification.Data, comment.Metadata, u.cfg.Notifier.MetadataKeys)
+	return notification
+}
 
-	if err := u.notifier.SendNotification(ctx, notification); err != nil {
-		log.Printf("Failed to send moderation notification: %v", err)
+// sendMentionNotifications notifies each user mentioned in a comment once
+func (u *CommentUsecase) sendMentionNotifications(comment *models.Comment) {
+	u.sendNotifications(u.mentionNotifications(comment))
+}
+
+// mentionNotifications builds one notification per user mentioned in a comment
+func (u *CommentUsecase) mentionNotifications(comment *models.Comment) []NotificationRequest {
+	notifications := make([]NotificationRequest, 0, len(comment.Mentions))
+	for _, username := range comment.Mentions {
+		notification := NotificationRequest{
+			Type:       "comment.mention",
+			Recipients: []string{username},
+			Title:      fmt.Sprintf("%s Mentioned You", comment.AuthorName),
+			Body:       truncateString(comment.Content, 100),
+			Data: map[string]string{
+				"comment_id":    comment.ID.Hex(),
+				"tenant_id":     comment.TenantID,
+				"resource_type": comment.ResourceType,
+				"resource_id":   comment.ResourceID,
+				"author_id":     comment.AuthorID,
+			},
+			Actor: comment.AuthorID,
+		}
+		addAuthorLinkData(notification.Data, comment, u.cfg.Notifier.DeepLinkTemplate)
+		addNotificationMetadata(notification.Data, comment.Metadata, u.cfg.Notifier.MetadataKeys)
+		notifications = append(notifications, notification)
+	}
+	return notifications
+}
+
+// sendNotifications sends several notifications, in one call when the
+// notifier supports batches and one at a time otherwise
+func (u *CommentUsecase) sendNotifications(notifications []NotificationRequest) {
+	if u.notifier == nil || !u.cfg.Notifier.Enabled {
+		return
+	}
+	notifications = dropSelfNotifications(notifications, u.cfg.Notifier)
+	if len(notifications) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if batcher, ok := u.notifier.(BatchNotifierClient); ok {
+		if err := batcher.SendNotifications(ctx, notifications); err != nil {
+			log.Printf("Failed to send %d notifications: %v", len(notifications), err)
+		}
+		return
+	}
+
+	for _, notification := range notifications {
+		if err := u.notifier.SendNotification(ctx, notification); err != nil {
+			log.Printf("Failed to send %s notification: %v", notification.Type, err)
+		}
+	}
+}
+
+// addAuthorLinkData adds the author's avatar and a deep link to the comment
+// to notification data, when there is one to add
+func addAuthorLinkData(data map[string]string, comment *models.Comment, deepLinkTemplate string) {
+	if comment.AuthorAvatar != "" {
+		data["author_avatar"] = comment.AuthorAvatar
+	}
+	if link := deepLink(deepLinkTemplate, comment); link != "" {
+		data["deep_link"] = link
+	}
+}
+
+// deepLink fills a link template with the comment's path-escaped IDs
+func deepLink(template string, comment *models.Comment) string {
+	if template == "" {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{comment_id}", comment.ID.Hex(),
+		"{resource_type}", url.PathEscape(comment.ResourceType),
+		"{resource_id}", url.PathEscape(comment.ResourceID),
+		"{tenant_id}", url.PathEscape(comment.TenantID),
+	).Replace(template)
+}
+
+// addNotificationMetadata copies allowlisted comment metadata into notification
+// data under a "metadata_" prefix. Keys not on the allowlist are never sent.
+func addNotificationMetadata(data map[string]string, metadata map[string]any, allowlist []string) {
+	for _, key := range allowlist {
+		if value, ok := metadata[key]; ok && value != nil {
+			data["metadata_"+key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// sendReportedDeleteNotification tells moderators a reported comment was
+// deleted by its author
+func (u *CommentUsecase) sendReportedDeleteNotification(comment *models.Comment) {
+	if u.notifier == nil || !u.cfg.Notifier.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	notification := NotificationRequest{
+		Type:       "comment.reported_deleted",
+		Recipients: []string{"moderators"},
+		Title:      "Reported Comment Deleted by Author",
+		Body:       truncateString(comment.Content, 100),
+		Data: map[string]string{
+			"comment_id":    comment.ID.Hex(),
+			"tenant_id":     comment.TenantID,
+			"resource_type": comment.ResourceType,
+			"resource_id":   comment.ResourceID,
+			"author_id":     comment.AuthorID,
+		},
+		Actor: comment.AuthorID,
+	}
+	addNotificationMetadata(notification.Data, comment.Metadata, u.cfg.Notifier.MetadataKeys)
+
+	if err := dispatchNotification(ctx, u.notifier, u.cfg.Notifier, notification); err != nil {
+		log.Printf("Failed to send reported delete notification: %v", err)
 	}
 }
 
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return string(runes[:maxLen-3]) + "..."
 }
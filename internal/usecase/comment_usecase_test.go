@@ -0,0 +1,1394 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListCommentsByAuthors(t *testing.T) {
+	t.Run("Normalize", func(t *testing.T) {
+		authorIDs, err := normalizeAuthorIDs([]string{" alice", "bob", "", "alice ", "carol"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob", "carol"}, authorIDs)
+	})
+
+	t.Run("Too Many", func(t *testing.T) {
+		authorIDs := make([]string, MaxListAuthorIDs+1)
+		for i := range authorIDs {
+			authorIDs[i] = fmt.Sprintf("user-%d", i)
+		}
+		_, err := normalizeAuthorIDs(authorIDs)
+		assert.EqualError(t, err, "at most 100 author IDs can be listed at once")
+	})
+
+	t.Run("Admins Only", func(t *testing.T) {
+		u := &CommentUsecase{}
+		req := models.ListCommentsRequest{TenantID: "shop", AuthorIDs: []string{"alice", "bob"}}
+		_, err := u.ListComments(context.Background(), req, "alice", false)
+		assert.EqualError(t, err, "only admins can list comments by several authors")
+	})
+
+	t.Run("Never Cached", func(t *testing.T) {
+		req := models.ListCommentsRequest{TenantID: "shop", ResourceType: "product", ResourceID: "p1", Status: models.StatusApproved}
+		assert.True(t, isCacheableList(req))
+		req.AuthorIDs = []string{"alice"}
+		assert.False(t, isCacheableList(req))
+	})
+}
+
+func TestCheckDateRange(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	next := day.Add(24 * time.Hour)
+
+	assert.NoError(t, checkDateRange(models.ListCommentsRequest{CreatedAfter: &day, CreatedBefore: &next}))
+	assert.NoError(t, checkDateRange(models.ListCommentsRequest{CreatedAfter: &day, CreatedBefore: &day}), "an empty range is allowed")
+	assert.NoError(t, checkDateRange(models.ListCommentsRequest{CreatedBefore: &day}))
+
+	u := &CommentUsecase{}
+	_, err := u.ListComments(context.Background(), models.ListCommentsRequest{CreatedAfter: &next, CreatedBefore: &day}, "", true)
+	assert.EqualError(t, err, "createdAfter must not be after createdBefore")
+}
+
+func TestSetListFlags(t *testing.T) {
+	withFiles := &models.Comment{IsPinned: true, Attachments: []models.Attachment{{URL: "https://cdn.example.com/a.png"}}}
+	plain := &models.Comment{}
+
+	setListFlags([]*models.Comment{withFiles, plain})
+
+	assert.True(t, withFiles.HasAttachments)
+	assert.False(t, plain.HasAttachments)
+
+	// Thread headers rely on the flags being present even when false
+	data, err := json.Marshal(plain)
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, false, fields["isPinned"])
+	assert.Equal(t, false, fields["hasAttachments"])
+
+	data, err = json.Marshal(withFiles)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, true, fields["isPinned"])
+	assert.Equal(t, true, fields["hasAttachments"])
+}
+
+func TestBulkDeleteInvalidIDs(t *testing.T) {
+	u := &CommentUsecase{}
+	for _, hard := range []bool{false, true} {
+		failed := u.BulkDeleteComments(context.Background(), []string{"not-an-id", ""}, hard, "mod-1")
+		assert.Equal(t, []string{"not-an-id", ""}, failed)
+	}
+}
+
+func TestMarkDeletedCascade(t *testing.T) {
+	// root <- reply <- nested reply, plus a sibling reply deleted earlier
+	root := &models.Comment{ID: primitive.NewObjectID()}
+	reply := &models.Comment{ID: primitive.NewObjectID(), ParentID: &root.ID, RootID: &root.ID, Depth: 1}
+	nested := &models.Comment{ID: primitive.NewObjectID(), ParentID: &reply.ID, RootID: &root.ID, Depth: 2}
+	earlier := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	gone := &models.Comment{ID: primitive.NewObjectID(), ParentID: &root.ID, RootID: &root.ID, Depth: 1, IsDeleted: true, DeletedBy: "alice", DeletedAt: &earlier}
+
+	now := models.Now()
+	deleted := markDeleted([]*models.Comment{reply, gone, nested}, "mod-1", now)
+
+	assert.Equal(t, []*models.Comment{reply, nested}, deleted)
+	for _, c := range []*models.Comment{reply, nested} {
+		assert.True(t, c.IsDeleted)
+		assert.Equal(t, "mod-1", c.DeletedBy)
+		assert.Equal(t, &now, c.DeletedAt)
+	}
+	assert.Equal(t, "alice", gone.DeletedBy, "already deleted replies keep their deleter")
+	assert.Equal(t, &earlier, gone.DeletedAt)
+}
+
+func TestMarkRestored(t *testing.T) {
+	comment := &models.Comment{ID: primitive.NewObjectID(), Status: models.StatusApproved}
+	markDeleted([]*models.Comment{comment}, "alice", models.Now())
+	require.True(t, comment.IsDeleted)
+
+	markRestored(comment)
+	assert.False(t, comment.IsDeleted)
+	assert.Nil(t, comment.DeletedAt)
+	assert.Empty(t, comment.DeletedBy)
+	assert.Equal(t, models.StatusApproved, comment.Status, "restoring keeps the moderation status")
+}
+
+func TestSetRepliesDisabled(t *testing.T) {
+	settings := map[string]*models.CommentSettings{
+		"product": {AllowReplies: false},
+		"article": {AllowReplies: true},
+	}
+	lookup := func(_, resourceType string) bool { return !settings[resourceType].AllowReplies }
+
+	// Replies already posted to a product stay listed, but can't be answered
+	existing := &models.Comment{TenantID: "shop", ResourceType: "product", Depth: 1}
+	article := &models.Comment{TenantID: "shop", ResourceType: "article"}
+	setRepliesDisabled([]*models.Comment{existing, article}, lookup)
+	assert.True(t, existing.RepliesDisabled)
+	assert.False(t, article.RepliesDisabled)
+
+	// Turning replies back on clears the flag
+	settings["product"].AllowReplies = true
+	setRepliesDisabled([]*models.Comment{existing}, lookup)
+	assert.False(t, existing.RepliesDisabled)
+}
+
+func TestWithSpamScore(t *testing.T) {
+	metadata := map[string]any{"order_id": "A-1"}
+
+	result := withSpamScore(metadata, 2)
+
+	assert.Equal(t, map[string]any{"order_id": "A-1", SpamScoreMetadataKey: 2}, result)
+	assert.NotContains(t, metadata, SpamScoreMetadataKey, "the request's metadata is not modified")
+	assert.Equal(t, map[string]any{SpamScoreMetadataKey: 0}, withSpamScore(nil, 0))
+}
+
+func TestApproximatePosition(t *testing.T) {
+	root := &models.Comment{Status: models.StatusApproved}
+	parentID := primitive.NewObjectID()
+	reply := &models.Comment{ParentID: &parentID, Status: models.StatusApproved}
+
+	// Newest first with nothing weighted or pinned: the new root leads
+	assert.Equal(t, 1, approximatePosition(root, 0, 0))
+	// Behind two pinned or weighted roots
+	assert.Equal(t, 3, approximatePosition(root, 2, 0))
+	// Replies are oldest first and the count includes the new reply
+	assert.Equal(t, 4, approximatePosition(reply, 0, 4))
+
+	pending := &models.Comment{Status: models.StatusPending}
+	assert.Equal(t, 1, approximatePosition(pending, 0, 0), "authors see their own pending comments")
+	spam := &models.Comment{Status: models.StatusSpam}
+	assert.Zero(t, approximatePosition(spam, 0, 0))
+}
+
+func TestInitialStatus(t *testing.T) {
+	settings := &models.CommentSettings{RequireApproval: true, AutoApproveVerified: true}
+
+	assert.Equal(t, models.StatusApproved, initialStatus(settings, nil, true))
+	assert.Equal(t, models.StatusPending, initialStatus(settings, nil, false))
+	assert.Equal(t, models.StatusPending, initialStatus(settings, []string{"spam"}, true), "flagged words still need review")
+
+	settings.AutoApproveVerified = false
+	assert.Equal(t, models.StatusPending, initialStatus(settings, nil, true))
+
+	settings.RequireApproval = false
+	assert.Equal(t, models.StatusApproved, initialStatus(settings, nil, false))
+}
+
+func TestFlagUnread(t *testing.T) {
+	now := time.Now()
+	newComments := func() []*models.Comment {
+		return []*models.Comment{
+			{AuthorID: "alice", CreatedAt: now.Add(-2 * time.Hour)},
+			{AuthorID: "bob", CreatedAt: now.Add(-time.Minute)},
+			{AuthorID: "owner", CreatedAt: now.Add(-time.Minute)},
+		}
+	}
+
+	t.Run("Never Seen", func(t *testing.T) {
+		comments := newComments()
+		flagUnread(comments, nil, "owner")
+
+		assert.True(t, comments[0].IsUnread)
+		assert.True(t, comments[1].IsUnread)
+		assert.False(t, comments[2].IsUnread, "own comments are never unread")
+	})
+
+	t.Run("Seen Before Newer Comment", func(t *testing.T) {
+		comments := newComments()
+		lastSeen := now.Add(-time.Hour)
+		flagUnread(comments, &lastSeen, "owner")
+
+		assert.False(t, comments[0].IsUnread)
+		assert.True(t, comments[1].IsUnread)
+	})
+
+	t.Run("Marked Seen", func(t *testing.T) {
+		comments := newComments()
+		lastSeen := now
+		flagUnread(comments, &lastSeen, "owner")
+
+		for _, comment := range comments {
+			assert.False(t, comment.IsUnread)
+		}
+	})
+}
+
+func TestInheritParentResource(t *testing.T) {
+	parent := &models.Comment{
+		TenantID:     "shop",
+		ResourceType: "product",
+		ResourceID:   "product-123",
+	}
+
+	req := models.CreateCommentRequest{
+		TenantID:     "blog",
+		ResourceType: "article",
+		ResourceID:   "article-999",
+		ParentID:     "507f1f77bcf86cd799439011",
+		Content:      "Reply with mismatched resource",
+	}
+	inheritParentResource(&req, parent)
+
+	assert.Equal(t, "shop", req.TenantID)
+	assert.Equal(t, "product", req.ResourceType)
+	assert.Equal(t, "product-123", req.ResourceID)
+	assert.Equal(t, "Reply with mismatched resource", req.Content)
+}
+
+func TestApplyRecomputedCounts(t *testing.T) {
+	// Counts as stored when the comment was soft-deleted
+	comment := &models.Comment{
+		IsDeleted:      true,
+		LikeCount:      3,
+		DislikeCount:   1,
+		ReplyCount:     2,
+		ReactionCounts: map[string]int{"like": 3, "dislike": 1, "love": 2},
+	}
+
+	// Meanwhile two likes and the love reactions were removed, one reply deleted
+	fields := applyRecomputedCounts(comment, map[string]int{"like": 1, "dislike": 1}, 1, 1, 1)
+
+	assert.Equal(t, 1, comment.LikeCount)
+	assert.Equal(t, 1, comment.DislikeCount)
+	assert.Equal(t, 1, comment.ReplyCount)
+	assert.Equal(t, map[string]int{"like": 1, "dislike": 1}, comment.ReactionCounts)
+	assert.Equal(t, 1, fields["like_count"])
+	assert.Equal(t, 1, fields["dislike_count"])
+	assert.Equal(t, 1, fields["reply_count"])
+	assert.Equal(t, map[string]int{"like": 1, "dislike": 1}, fields["reaction_counts"])
+	assert.Equal(t, 2, comment.TotalReactions)
+	assert.Equal(t, 2, fields["total_reactions"])
+}
+
+type fakeGeoResolver map[string]string
+
+func (f fakeGeoResolver) CountryForIP(_ context.Context, ip string) (string, error) {
+	country, ok := f[ip]
+	if !ok {
+		return "", errors.New("geo service unavailable")
+	}
+	return country, nil
+}
+
+type fakeResourceValidator map[string]bool
+
+func (f fakeResourceValidator) ResourceExists(_ context.Context, _ string, resourceID string) (bool, error) {
+	exists, ok := f[resourceID]
+	if !ok {
+		return false, errors.New("catalog unavailable")
+	}
+	return exists, nil
+}
+
+func TestCheckResourceExists(t *testing.T) {
+	ctx := context.Background()
+	validator := fakeResourceValidator{"product-1": true, "product-deleted": false}
+
+	assert.NoError(t, checkResourceExists(ctx, validator, "shop", "product-1"))
+	assert.EqualError(t, checkResourceExists(ctx, validator, "shop", "product-deleted"), "resource not found")
+
+	t.Run("Validator Failure Fails Open", func(t *testing.T) {
+		assert.NoError(t, checkResourceExists(ctx, validator, "shop", "product-unknown"))
+	})
+
+	t.Run("Resource Type Without Validator", func(t *testing.T) {
+		validators := map[string]ResourceValidator{"product": validator}
+		assert.NoError(t, checkResourceExists(ctx, validators["ticket"], "shop", "product-deleted"))
+	})
+}
+
+// fakeTranslator knows the language of the texts in detected and can't detect
+// any other; translating fails with failTranslate
+type fakeTranslator struct {
+	detected      map[string]string
+	failTranslate bool
+}
+
+func (f fakeTranslator) DetectLanguage(_ context.Context, text string) (string, error) {
+	language, ok := f.detected[text]
+	if !ok {
+		return "", errors.New("translation service unavailable")
+	}
+	return language, nil
+}
+
+func (f fakeTranslator) Translate(_ context.Context, text, targetLanguage string) (string, error) {
+	if f.failTranslate {
+		return "", errors.New("translation service unavailable")
+	}
+	return "[" + targetLanguage + "] " + text, nil
+}
+
+func TestCheckLanguage(t *testing.T) {
+	ctx := context.Background()
+	translator := fakeTranslator{detected: map[string]string{
+		"Great product, works well": "en",
+		"Sehr gutes Produkt":        "DE",
+	}}
+
+	t.Run("Expected Language", func(t *testing.T) {
+		settings := &models.CommentSettings{ExpectedLanguage: "en"}
+		result := checkLanguage(ctx, translator, "Great product, works well", settings)
+		assert.Equal(t, languageCheck{Language: "en"}, result)
+	})
+
+	t.Run("Foreign Language Held", func(t *testing.T) {
+		settings := &models.CommentSettings{ExpectedLanguage: "en"}
+		result := checkLanguage(ctx, translator, "Sehr gutes Produkt", settings)
+		assert.Equal(t, languageCheck{Language: "de", Hold: true}, result)
+	})
+
+	t.Run("Foreign Language Translated", func(t *testing.T) {
+		settings := &models.CommentSettings{ExpectedLanguage: "en", LanguageMismatchAction: models.ActionTranslate}
+		result := checkLanguage(ctx, translator, "Sehr gutes Produkt", settings)
+		assert.Equal(t, languageCheck{Language: "de", Translated: "[en] Sehr gutes Produkt"}, result)
+	})
+
+	t.Run("Provider Failures Fail Open", func(t *testing.T) {
+		settings := &models.CommentSettings{ExpectedLanguage: "en", LanguageMismatchAction: models.ActionTranslate}
+		assert.Equal(t, languageCheck{}, checkLanguage(ctx, translator, "???", settings))
+
+		broken := fakeTranslator{detected: translator.detected, failTranslate: true}
+		assert.Equal(t, languageCheck{Language: "de"}, checkLanguage(ctx, broken, "Sehr gutes Produkt", settings))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert.Equal(t, languageCheck{}, checkLanguage(ctx, translator, "Sehr gutes Produkt", &models.CommentSettings{}))
+		assert.Equal(t, languageCheck{}, checkLanguage(ctx, nil, "Sehr gutes Produkt", &models.CommentSettings{ExpectedLanguage: "en"}))
+	})
+}
+
+func TestCheckGeoRestriction(t *testing.T) {
+	ctx := context.Background()
+	resolver := fakeGeoResolver{"1.1.1.1": "US", "2.2.2.2": "KP", "3.3.3.3": "DE"}
+
+	t.Run("Blocked Country", func(t *testing.T) {
+		settings := &models.CommentSettings{BlockedCountries: []string{"kp"}}
+
+		err := checkGeoRestriction(ctx, resolver, "2.2.2.2", settings)
+		assert.EqualError(t, err, "comments are not allowed from your region")
+		assert.NoError(t, checkGeoRestriction(ctx, resolver, "1.1.1.1", settings))
+	})
+
+	t.Run("Allowed Countries", func(t *testing.T) {
+		settings := &models.CommentSettings{AllowedCountries: []string{"US", "CA"}}
+
+		assert.NoError(t, checkGeoRestriction(ctx, resolver, "1.1.1.1", settings))
+		assert.Error(t, checkGeoRestriction(ctx, resolver, "3.3.3.3", settings))
+	})
+
+	t.Run("Resolver Failure Fails Open", func(t *testing.T) {
+		settings := &models.CommentSettings{AllowedCountries: []string{"US"}}
+		assert.NoError(t, checkGeoRestriction(ctx, resolver, "9.9.9.9", settings))
+	})
+
+	t.Run("No Resolver", func(t *testing.T) {
+		settings := &models.CommentSettings{BlockedCountries: []string{"KP"}}
+		assert.NoError(t, checkGeoRestriction(ctx, nil, "2.2.2.2", settings))
+	})
+}
+
+func TestCheckOrigin(t *testing.T) {
+	settings := &models.CommentSettings{AllowedOrigins: []string{"https://shop.example.com", "blog.example.org", "*.example.net"}}
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"Exact Origin", "https://shop.example.com", true},
+		{"Case Insensitive", "HTTPS://Shop.Example.com", true},
+		{"Referer With Path", "https://shop.example.com/products/42?ref=x", true},
+		{"Wrong Scheme", "http://shop.example.com", false},
+		{"Bare Host Any Scheme", "http://blog.example.org", true},
+		{"Wildcard Subdomain", "https://a.b.example.net", true},
+		{"Wildcard Excludes Apex", "https://example.net", false},
+		{"Lookalike Domain", "https://shop.example.com.evil.io", false},
+		{"Suffix Lookalike", "https://evilexample.net", false},
+		{"Different Port", "https://shop.example.com:8443", false},
+		{"Missing Origin", "", false},
+		{"Opaque Origin", "null", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOrigin(tt.origin, settings)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, "comments are not allowed from this origin")
+			}
+		})
+	}
+
+	t.Run("No Allowed Origins", func(t *testing.T) {
+		assert.NoError(t, checkOrigin("", &models.CommentSettings{}))
+		assert.NoError(t, checkOrigin("https://anywhere.example", &models.CommentSettings{}))
+	})
+}
+
+func TestPlanThreadMerge(t *testing.T) {
+	newThreads := func() (*models.Comment, *models.Comment, []*models.Comment) {
+		// target (root) and source (root) are duplicate top-level threads
+		target := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", ResourceType: "product", ResourceID: "p1"}
+		source := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", ResourceType: "product", ResourceID: "p1"}
+
+		reply := &models.Comment{ID: primitive.NewObjectID(), ParentID: &source.ID, RootID: &source.ID, Depth: 1}
+		nested := &models.Comment{ID: primitive.NewObjectID(), ParentID: &reply.ID, RootID: &source.ID, Depth: 2}
+		for _, c := range []*models.Comment{reply, nested} {
+			c.TenantID, c.ResourceType, c.ResourceID = "shop", "product", "p1"
+		}
+		return source, target, []*models.Comment{reply, nested}
+	}
+
+	t.Run("Merge Two Threads", func(t *testing.T) {
+		source, target, descendants := newThreads()
+		replyID := descendants[0].ID
+
+		assert.NoError(t, planThreadMerge(source, target, descendants, 5))
+
+		assert.Equal(t, target.ID, *descendants[0].ParentID)
+		assert.Equal(t, target.ID, *descendants[0].RootID)
+		assert.Equal(t, 1, descendants[0].Depth)
+
+		assert.Equal(t, replyID, *descendants[1].ParentID, "nested replies keep their parent")
+		assert.Equal(t, target.ID, *descendants[1].RootID)
+		assert.Equal(t, 2, descendants[1].Depth)
+	})
+
+	t.Run("Into Nested Target", func(t *testing.T) {
+		source, target, descendants := newThreads()
+		rootID := primitive.NewObjectID()
+		target.RootID = &rootID
+		target.Depth = 2
+
+		assert.NoError(t, planThreadMerge(source, target, descendants, 5))
+		assert.Equal(t, rootID, *descendants[0].RootID)
+		assert.Equal(t, 3, descendants[0].Depth)
+		assert.Equal(t, 4, descendants[1].Depth)
+	})
+
+	t.Run("Exceeds Max Depth", func(t *testing.T) {
+		source, target, descendants := newThreads()
+		target.Depth = 2
+
+		err := planThreadMerge(source, target, descendants, 3)
+		assert.EqualError(t, err, "merge would exceed maximum reply depth of 3")
+		assert.Equal(t, source.ID, *descendants[0].ParentID, "nothing is changed on failure")
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		source, _, descendants := newThreads()
+
+		err := planThreadMerge(source, descendants[1], descendants, 5)
+		assert.EqualError(t, err, "cannot merge a comment into its own reply")
+	})
+
+	t.Run("Different Resource", func(t *testing.T) {
+		source, target, descendants := newThreads()
+		target.ResourceID = "p2"
+
+		err := planThreadMerge(source, target, descendants, 5)
+		assert.EqualError(t, err, "comments belong to different resources")
+	})
+}
+
+func TestIsPubliclyVisible(t *testing.T) {
+	assert.True(t, isPubliclyVisible(&models.Comment{Status: models.StatusApproved}))
+	assert.False(t, isPubliclyVisible(&models.Comment{Status: models.StatusPending}))
+	assert.False(t, isPubliclyVisible(&models.Comment{Status: models.StatusApproved, IsDeleted: true}))
+}
+
+func TestRestrictToPublic(t *testing.T) {
+	req := models.ListCommentsRequest{
+		Status:         models.StatusPending,
+		IncludeDeleted: true,
+		UnreadFor:      "alice",
+		PendingFor:     "alice",
+	}
+	restrictToPublic(&req)
+
+	assert.Equal(t, models.StatusApproved, req.Status)
+	assert.False(t, req.IncludeDeleted)
+	assert.Empty(t, req.UnreadFor)
+	assert.Empty(t, req.PendingFor)
+}
+
+func TestRestrictToOwnPending(t *testing.T) {
+	// A non-admin asking for the pending queue still only gets their own
+	for _, status := range []models.CommentStatus{"", models.StatusPending, models.StatusSpam, models.StatusRejected} {
+		req := models.ListCommentsRequest{Status: status}
+		restrictToOwnPending(&req, "alice")
+
+		assert.Equal(t, models.StatusApproved, req.Status, "status %q", status)
+		assert.Equal(t, "alice", req.PendingFor, "status %q", status)
+	}
+}
+
+func TestAnonymousDisplayName(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowName   bool
+		requireName bool
+		requested   string
+		want        string
+		wantErr     bool
+	}{
+		{"Default Ignores Name", false, false, "Guest123", "Anonymous", false},
+		{"Default Without Name", false, false, "", "Anonymous", false},
+		{"Allowed Name Used", true, false, " Guest123 ", "Guest123", false},
+		{"Allowed Name Missing", true, false, "   ", "Anonymous", false},
+		{"Required Name Used", false, true, "Guest123", "Guest123", false},
+		{"Required Name Missing", false, true, "", "", true},
+		{"Allowed And Required", true, true, "Guest123", "Guest123", false},
+		{"Allowed And Required Missing", true, true, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &models.CommentSettings{AnonymousAllowName: tt.allowName, AnonymousRequireName: tt.requireName}
+
+			got, err := anonymousDisplayName(tt.requested, settings)
+			if tt.wantErr {
+				assert.EqualError(t, err, "a display name is required for anonymous comments")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRawContent(t *testing.T) {
+	processed, err := NewContentPipeline(config.ModerationConfig{}).Run("  <b>Hello</b>\r\n\r\n\r\nworld  ", &models.CommentSettings{})
+	assert.NoError(t, err)
+
+	disabled := &CommentUsecase{cfg: &config.Config{}}
+	assert.Empty(t, disabled.rawContent(processed))
+
+	enabled := &CommentUsecase{cfg: &config.Config{Moderation: config.ModerationConfig{StoreRawContent: true}}}
+	comment := &models.Comment{Content: processed.Content, RawContent: enabled.rawContent(processed)}
+	assert.Equal(t, "  <b>Hello</b>\r\n\r\n\r\nworld  ", comment.RawContent)
+
+	// Hidden from regular responses, shown to moderators
+	public, err := json.Marshal(comment)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(public), "rawContent")
+
+	admin, err := json.Marshal(&models.AdminComment{Comment: comment, RawContent: comment.RawContent})
+	assert.NoError(t, err)
+	var fields map[string]any
+	assert.NoError(t, json.Unmarshal(admin, &fields))
+	assert.Equal(t, "  <b>Hello</b>\r\n\r\n\r\nworld  ", fields["rawContent"])
+	assert.Equal(t, "<b>Hello</b>\n\nworld", fields["content"])
+}
+
+func TestTrackEditSinceApproval(t *testing.T) {
+	settings := &models.CommentSettings{ReModerateAfterEdits: 3}
+	comment := &models.Comment{Status: models.StatusApproved}
+
+	for i := 1; i <= 3; i++ {
+		trackEditSinceApproval(comment, settings)
+		assert.Equal(t, models.StatusApproved, comment.Status, "edit %d is within the threshold", i)
+	}
+
+	trackEditSinceApproval(comment, settings)
+	assert.Equal(t, models.StatusPending, comment.Status)
+	assert.Equal(t, 4, comment.EditsSinceApproval)
+
+	t.Run("Disabled", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusApproved, EditsSinceApproval: 50}
+		trackEditSinceApproval(comment, &models.CommentSettings{})
+		assert.Equal(t, models.StatusApproved, comment.Status)
+	})
+
+	t.Run("Rejected Comments Stay Rejected", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusRejected, EditsSinceApproval: 3}
+		trackEditSinceApproval(comment, settings)
+		assert.Equal(t, models.StatusRejected, comment.Status)
+	})
+}
+
+func TestApplyApproval(t *testing.T) {
+	comment := &models.Comment{Status: models.StatusPending}
+
+	require.NoError(t, applyApproval(comment, "mod-1", true))
+	assert.Equal(t, models.StatusPendingSecondApproval, comment.Status)
+
+	err := applyApproval(comment, "mod-1", true)
+	assert.EqualError(t, err, "you have already approved this comment")
+	assert.Equal(t, models.StatusPendingSecondApproval, comment.Status, "the same moderator can't sign off twice")
+
+	require.NoError(t, applyApproval(comment, "mod-2", true))
+	assert.Equal(t, models.StatusApproved, comment.Status)
+	assert.Equal(t, []string{"mod-1", "mod-2"}, comment.ModerationApprovals)
+
+	t.Run("Single Moderation", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusPending}
+		require.NoError(t, applyApproval(comment, "mod-1", false))
+		assert.Equal(t, models.StatusApproved, comment.Status)
+		assert.Empty(t, comment.ModerationApprovals)
+	})
+
+	t.Run("Requeued Comments Need Fresh Approvals", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusPending, ModerationApprovals: []string{"mod-1", "mod-2"}}
+		require.NoError(t, applyApproval(comment, "mod-1", true))
+		assert.Equal(t, models.StatusPendingSecondApproval, comment.Status)
+		assert.Equal(t, []string{"mod-1"}, comment.ModerationApprovals)
+	})
+}
+
+func TestSetReplyingTo(t *testing.T) {
+	alice := &models.Comment{ID: primitive.NewObjectID(), AuthorName: "Alice", Status: models.StatusApproved}
+	bob := &models.Comment{ID: primitive.NewObjectID(), AuthorName: "Bob", Status: models.StatusApproved}
+	carolToAlice := &models.Comment{ID: primitive.NewObjectID(), AuthorName: "Carol", ParentID: &alice.ID}
+	gone := &models.Comment{ID: primitive.NewObjectID(), AuthorName: "Dave", IsDeleted: true}
+	toGone := &models.Comment{ID: primitive.NewObjectID(), AuthorName: "Erin", ParentID: &gone.ID}
+	toMissing := &models.Comment{ID: primitive.NewObjectID(), AuthorName: "Frank", ParentID: &bob.ID}
+
+	// Chronological page: a reply to Alice sits between two roots
+	feed := []*models.Comment{alice, carolToAlice, bob, toGone, toMissing}
+	parents := map[primitive.ObjectID]*models.Comment{
+		alice.ID: alice, bob.ID: bob, carolToAlice.ID: carolToAlice, gone.ID: gone,
+	}
+	setReplyingTo(feed, parents, "", false)
+
+	assert.Empty(t, alice.ReplyingToName)
+	assert.Equal(t, "Alice", carolToAlice.ReplyingToName)
+	assert.Empty(t, bob.ReplyingToName)
+	assert.Empty(t, toGone.ReplyingToName, "deleted parents stay unnamed")
+	assert.Equal(t, "Bob", toMissing.ReplyingToName)
+
+	t.Run("Hidden Parents", func(t *testing.T) {
+		pending := &models.Comment{ID: primitive.NewObjectID(), AuthorID: "grace", AuthorName: "Grace", Status: models.StatusPending}
+		rejected := &models.Comment{ID: primitive.NewObjectID(), AuthorID: "heidi", AuthorName: "Heidi", Status: models.StatusRejected}
+		parents := map[primitive.ObjectID]*models.Comment{pending.ID: pending, rejected.ID: rejected}
+		annotate := func(pendingFor string, isAdmin bool) (string, string) {
+			toPending := &models.Comment{ParentID: &pending.ID}
+			toRejected := &models.Comment{ParentID: &rejected.ID}
+			setReplyingTo([]*models.Comment{toPending, toRejected}, parents, pendingFor, isAdmin)
+			return toPending.ReplyingToName, toRejected.ReplyingToName
+		}
+
+		toPending, toRejected := annotate("", false)
+		assert.Empty(t, toPending, "readers can't learn who wrote a pending comment")
+		assert.Empty(t, toRejected)
+
+		toPending, toRejected = annotate("grace", false)
+		assert.Equal(t, "Grace", toPending, "authors see their own pending comments")
+		assert.Empty(t, toRejected)
+
+		toPending, toRejected = annotate("", true)
+		assert.Equal(t, "Grace", toPending)
+		assert.Equal(t, "Heidi", toRejected)
+	})
+}
+
+type recordingNotifier struct {
+	sent []NotificationRequest
+}
+
+func (n *recordingNotifier) SendNotification(_ context.Context, notification NotificationRequest) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+type batchingNotifier struct {
+	recordingNotifier
+	batches [][]NotificationRequest
+}
+
+func (n *batchingNotifier) SendNotifications(_ context.Context, notifications []NotificationRequest) error {
+	n.batches = append(n.batches, notifications)
+	return nil
+}
+
+func TestBulkModerationBatchesNotifications(t *testing.T) {
+	cfg := &config.Config{Notifier: config.NotifierConfig{Enabled: true}}
+	comments := []*models.Comment{
+		{ID: primitive.NewObjectID(), AuthorID: "alice", Status: models.StatusApproved},
+		{ID: primitive.NewObjectID(), AuthorID: "bob", Status: models.StatusApproved, Mentions: []string{"carol"}},
+		{ID: primitive.NewObjectID(), AuthorID: "dave", Status: models.StatusRejected, RejectionReason: "spam"},
+	}
+
+	notify := func(u *CommentUsecase) {
+		var notifications []NotificationRequest
+		for _, comment := range comments {
+			notifications = append(notifications, u.moderatedNotifications(comment, false)...)
+		}
+		u.sendNotifications(notifications)
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		notifier := &batchingNotifier{}
+		notify(&CommentUsecase{notifier: notifier, cfg: cfg})
+
+		require.Len(t, notifier.batches, 1, "one notifier call for the whole bulk operation")
+		assert.Empty(t, notifier.sent)
+
+		batch := notifier.batches[0]
+		require.Len(t, batch, 4)
+		assert.Equal(t, []string{"alice"}, batch[0].Recipients)
+		assert.Equal(t, "comment.mention", batch[2].Type)
+		assert.Equal(t, []string{"carol"}, batch[2].Recipients)
+		assert.Equal(t, "Your comment has been rejected. Reason: spam", batch[3].Body)
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		notifier := &recordingNotifier{}
+		notify(&CommentUsecase{notifier: notifier, cfg: cfg})
+
+		assert.Len(t, notifier.sent, 4, "notifiers without batches get one call each")
+	})
+}
+
+func TestSelfNotificationsSuppressed(t *testing.T) {
+	cfg := &config.Config{Notifier: config.NotifierConfig{Enabled: true, SuppressSelfNotifications: true}}
+
+	t.Run("Moderating Own Comment", func(t *testing.T) {
+		notifier := &recordingNotifier{}
+		u := &CommentUsecase{notifier: notifier, cfg: cfg}
+		comment := &models.Comment{ID: primitive.NewObjectID(), AuthorID: "alice", ModeratedBy: "alice", Status: models.StatusApproved}
+
+		u.sendModerationNotification(comment)
+		assert.Empty(t, notifier.sent)
+
+		comment.ModeratedBy = "mod-1"
+		u.sendModerationNotification(comment)
+		require.Len(t, notifier.sent, 1)
+		assert.Equal(t, []string{"alice"}, notifier.sent[0].Recipients)
+	})
+
+	t.Run("Batched Mention Of Self", func(t *testing.T) {
+		notifier := &batchingNotifier{}
+		u := &CommentUsecase{notifier: notifier, cfg: cfg}
+		comment := &models.Comment{ID: primitive.NewObjectID(), AuthorID: "alice", Mentions: []string{"alice", "bob"}}
+
+		u.sendMentionNotifications(comment)
+		require.Len(t, notifier.batches, 1)
+		require.Len(t, notifier.batches[0], 1)
+		assert.Equal(t, []string{"bob"}, notifier.batches[0][0].Recipients)
+	})
+
+	t.Run("Suppression Off", func(t *testing.T) {
+		notifier := &recordingNotifier{}
+		u := &CommentUsecase{notifier: notifier, cfg: &config.Config{Notifier: config.NotifierConfig{Enabled: true}}}
+		u.sendModerationNotification(&models.Comment{ID: primitive.NewObjectID(), AuthorID: "alice", ModeratedBy: "alice"})
+		assert.Len(t, notifier.sent, 1)
+	})
+
+	t.Run("Group Recipients", func(t *testing.T) {
+		assert.False(t, isSelfNotification(NotificationRequest{Recipients: []string{"moderators"}, Actor: "alice"}))
+		assert.False(t, isSelfNotification(NotificationRequest{Recipients: []string{"alice", "bob"}, Actor: "alice"}))
+		assert.False(t, isSelfNotification(NotificationRequest{Recipients: []string{"alice"}}))
+	})
+}
+
+func TestNewCommentNotificationRecipients(t *testing.T) {
+	// Self-notification suppression is off to show the explicit skip
+	cfg := &config.Config{Notifier: config.NotifierConfig{Enabled: true}}
+	settings := &models.CommentSettings{NotifyOnNewComment: true, NotifyOnReply: true}
+	parent := &models.Comment{ID: primitive.NewObjectID(), AuthorID: "alice"}
+
+	send := func(comment *models.Comment, recipient string) []NotificationRequest {
+		notifier := &recordingNotifier{}
+		u := &CommentUsecase{notifier: notifier, cfg: cfg}
+		comment.ID = primitive.NewObjectID()
+		u.sendNewCommentNotification(comment, settings, recipient)
+		return notifier.sent
+	}
+
+	t.Run("Reply Notifies Parent Author", func(t *testing.T) {
+		reply := &models.Comment{AuthorID: "bob", ParentID: &parent.ID, Status: models.StatusApproved}
+		sent := send(reply, newCommentRecipient(models.CreateCommentRequest{}, parent))
+
+		require.Len(t, sent, 1)
+		assert.Equal(t, "comment.reply", sent[0].Type)
+		assert.Equal(t, []string{"alice"}, sent[0].Recipients)
+	})
+
+	t.Run("Self Reply", func(t *testing.T) {
+		reply := &models.Comment{AuthorID: "alice", ParentID: &parent.ID, Status: models.StatusApproved}
+		assert.Empty(t, send(reply, newCommentRecipient(models.CreateCommentRequest{}, parent)))
+	})
+
+	t.Run("Root Notifies Resource Owner", func(t *testing.T) {
+		req := models.CreateCommentRequest{ResourceOwnerID: "owner-1", Metadata: map[string]any{"owner_id": "owner-2"}}
+		assert.Equal(t, "owner-1", newCommentRecipient(req, nil))
+
+		req.ResourceOwnerID = ""
+		assert.Equal(t, "owner-2", newCommentRecipient(req, nil), "falls back to metadata")
+
+		sent := send(&models.Comment{AuthorID: "bob", Status: models.StatusApproved}, newCommentRecipient(req, nil))
+		require.Len(t, sent, 1)
+		assert.Equal(t, "comment.new", sent[0].Type)
+		assert.Equal(t, []string{"owner-2"}, sent[0].Recipients)
+	})
+
+	t.Run("Owner Commenting On Own Resource", func(t *testing.T) {
+		assert.Empty(t, send(&models.Comment{AuthorID: "owner-1", Status: models.StatusApproved}, "owner-1"))
+	})
+
+	t.Run("No Known Recipient", func(t *testing.T) {
+		req := models.CreateCommentRequest{Metadata: map[string]any{"owner_id": 42}}
+		assert.Empty(t, newCommentRecipient(req, nil), "non-string owner IDs are ignored")
+		assert.Empty(t, send(&models.Comment{AuthorID: "bob", Status: models.StatusApproved}, ""))
+	})
+
+	t.Run("Pending Goes To Moderators", func(t *testing.T) {
+		reply := &models.Comment{AuthorID: "alice", ParentID: &parent.ID, Status: models.StatusPending}
+		sent := send(reply, "alice")
+
+		require.Len(t, sent, 1)
+		assert.Equal(t, "comment.pending", sent[0].Type)
+		assert.Equal(t, []string{"moderators"}, sent[0].Recipients)
+	})
+}
+
+func TestMentionNotificationAuthorLinkData(t *testing.T) {
+	cfg := &config.Config{Notifier: config.NotifierConfig{DeepLinkTemplate: "https://example.com/{resource_type}/{resource_id}#comment-{comment_id}"}}
+	u := &CommentUsecase{cfg: cfg}
+	comment := &models.Comment{
+		ID:           primitive.NewObjectID(),
+		ResourceType: "article",
+		ResourceID:   "how to/42",
+		AuthorID:     "alice",
+		AuthorAvatar: "https://cdn.example.com/alice.png",
+		Mentions:     []string{"bob"},
+	}
+
+	notifications := u.mentionNotifications(comment)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "https://cdn.example.com/alice.png", notifications[0].Data["author_avatar"])
+	assert.Equal(t, "https://example.com/article/how%20to%2F42#comment-"+comment.ID.Hex(), notifications[0].Data["deep_link"])
+
+	// Anonymous comments store no avatar, and no template sends no link
+	comment.AuthorAvatar = ""
+	u.cfg.Notifier.DeepLinkTemplate = ""
+	notifications = u.mentionNotifications(comment)
+	assert.NotContains(t, notifications[0].Data, "author_avatar")
+	assert.NotContains(t, notifications[0].Data, "deep_link")
+}
+
+func TestModerationNotificationMetadata(t *testing.T) {
+	notifier := &recordingNotifier{}
+	cfg := &config.Config{Notifier: config.NotifierConfig{Enabled: true, MetadataKeys: []string{"order_id", "channel"}}}
+	u := &CommentUsecase{notifier: notifier, cfg: cfg}
+
+	comment := &models.Comment{
+		ID:       primitive.NewObjectID(),
+		AuthorID: "user-1",
+		Status:   models.StatusApproved,
+		Metadata: map[string]any{"order_id": 1042, "email": "jane@example.com"},
+	}
+	u.sendModerationNotification(comment)
+
+	require.Len(t, notifier.sent, 1)
+	data := notifier.sent[0].Data
+	assert.Equal(t, "1042", data["metadata_order_id"])
+	assert.NotContains(t, data, "metadata_channel", "absent keys are skipped")
+	assert.NotContains(t, data, "metadata_email", "keys outside the allowlist are never sent")
+	assert.Equal(t, comment.ID.Hex(), data["comment_id"])
+}
+
+func TestReplyPlacementDeepThread(t *testing.T) {
+	// Build root -> level1 -> level2 -> level3 the way CreateComment does
+	root := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", ResourceType: "product", ResourceID: "p1"}
+	thread := []*models.Comment{root}
+	for i := 1; i <= 3; i++ {
+		parentID, rootID, depth := replyPlacement(thread[i-1])
+		thread = append(thread, &models.Comment{
+			ID: primitive.NewObjectID(), ParentID: parentID, RootID: rootID, Depth: depth,
+			TenantID: "shop", ResourceType: "product", ResourceID: "p1",
+		})
+	}
+
+	assert.Nil(t, root.RootID)
+	assert.Zero(t, root.Depth)
+	for i, c := range thread[1:] {
+		assert.Equal(t, i+1, c.Depth)
+		assert.Equal(t, root.ID, *c.RootID)
+		assert.Equal(t, thread[i].ID, *c.ParentID)
+	}
+
+	// Merging level1 under a depth-2 reply of another thread keeps the moved
+	// subtree's depth and root consistent with its new position
+	otherRoot := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", ResourceType: "product", ResourceID: "p1"}
+	pid, rid, depth := replyPlacement(otherRoot)
+	otherReply := &models.Comment{ID: primitive.NewObjectID(), ParentID: pid, RootID: rid, Depth: depth}
+	pid, rid, depth = replyPlacement(otherReply)
+	target := &models.Comment{
+		ID: primitive.NewObjectID(), ParentID: pid, RootID: rid, Depth: depth,
+		TenantID: "shop", ResourceType: "product", ResourceID: "p1",
+	}
+
+	require.NoError(t, planThreadMerge(thread[1], target, thread[2:], 5))
+	assert.Equal(t, target.ID, *thread[2].ParentID)
+	for i, c := range thread[2:] {
+		assert.Equal(t, target.Depth+i+1, c.Depth)
+		assert.Equal(t, otherRoot.ID, *c.RootID)
+	}
+}
+
+func TestCheckEditWindow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	settings := &models.CommentSettings{EditWindowSeconds: 300}
+
+	old := &models.Comment{CreatedAt: now.Add(-10 * time.Minute)}
+	assert.EqualError(t, checkEditWindow(old, settings, now), "the edit window for this comment has expired")
+
+	recent := &models.Comment{CreatedAt: now.Add(-2 * time.Minute)}
+	assert.NoError(t, checkEditWindow(recent, settings, now))
+
+	assert.NoError(t, checkEditWindow(old, &models.CommentSettings{}, now), "0 disables the window")
+}
+
+func TestCheckEditLock(t *testing.T) {
+	settings := &models.CommentSettings{LockEditsAfterReply: true}
+
+	replied := &models.Comment{ReplyCount: 2}
+	assert.EqualError(t, checkEditLock(replied, settings), "comments with replies can no longer be edited")
+	assert.NoError(t, checkEditLock(replied, &models.CommentSettings{}), "off by default")
+
+	// Soft-deleting the replies brings ReplyCount back to zero
+	replied.ReplyCount = 0
+	assert.NoError(t, checkEditLock(replied, settings))
+
+	t.Run("Pending", func(t *testing.T) {
+		pending := &models.Comment{Status: models.StatusPending}
+		locked := &models.CommentSettings{LockEditWhilePending: true}
+
+		assert.EqualError(t, checkEditLock(pending, locked), "comments awaiting moderation can't be edited")
+		assert.NoError(t, checkEditLock(pending, settings), "pending edits are allowed without the lock")
+		assert.NoError(t, checkEditLock(&models.Comment{Status: models.StatusApproved}, locked))
+	})
+}
+
+func TestEditHistoryWithCurrent(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	edited := created.Add(time.Hour)
+
+	t.Run("Edited", func(t *testing.T) {
+		comment := &models.Comment{
+			AuthorID:    "alice",
+			Content:     "second draft",
+			CreatedAt:   created,
+			EditHistory: []models.EditRecord{{Content: "first draft", EditedAt: edited, EditedBy: "alice"}},
+		}
+
+		history := editHistoryWithCurrent(comment)
+		require.Len(t, history, 2)
+		assert.Equal(t, "first draft", history[0].Content)
+		assert.Equal(t, models.EditRecord{Content: "second draft", EditedAt: edited, EditedBy: "alice"}, history[1])
+		assert.Len(t, comment.EditHistory, 1, "the comment's history is not modified")
+	})
+
+	t.Run("Never Edited", func(t *testing.T) {
+		comment := &models.Comment{AuthorID: "alice", Content: "only draft", CreatedAt: created}
+
+		assert.Equal(t, []models.EditRecord{{Content: "only draft", EditedAt: created, EditedBy: "alice"}}, editHistoryWithCurrent(comment))
+	})
+}
+
+func TestAppendEditRecord(t *testing.T) {
+	const keep = 3
+	var history []models.EditRecord
+	for i := 0; i < keep+5; i++ {
+		history = appendEditRecord(history, models.EditRecord{Content: fmt.Sprintf("v%d", i)}, keep)
+	}
+
+	require.Len(t, history, keep)
+	assert.Equal(t, "v5", history[0].Content)
+	assert.Equal(t, "v7", history[keep-1].Content)
+
+	unlimited := appendEditRecord(history, models.EditRecord{Content: "v8"}, 0)
+	assert.Len(t, unlimited, keep+1)
+}
+
+func TestReportedDelete(t *testing.T) {
+	t.Run("Block", func(t *testing.T) {
+		assert.EqualError(t, checkReportedDelete(ReportedDeleteBlock, true),
+			"comment has unresolved reports and cannot be deleted yet")
+		assert.NoError(t, checkReportedDelete(ReportedDeleteBlock, false))
+	})
+
+	t.Run("Tombstone", func(t *testing.T) {
+		assert.NoError(t, checkReportedDelete(ReportedDeleteTombstone, true))
+
+		notifier := &recordingNotifier{}
+		u := &CommentUsecase{notifier: notifier, cfg: &config.Config{Notifier: config.NotifierConfig{Enabled: true}}}
+		comment := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", AuthorID: "alice", Content: "reported text"}
+		u.sendReportedDeleteNotification(comment)
+
+		require.Len(t, notifier.sent, 1)
+		assert.Equal(t, "comment.reported_deleted", notifier.sent[0].Type)
+		assert.Equal(t, []string{"moderators"}, notifier.sent[0].Recipients)
+		assert.Equal(t, comment.ID.Hex(), notifier.sent[0].Data["comment_id"])
+	})
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	comment := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop"}
+
+	entry := newAuditEntry(context.Background(), AuditCommentCreated, comment, "alice")
+	assert.Equal(t, "alice", entry.ActorID)
+	assert.Empty(t, entry.OnBehalfOf)
+
+	// Handlers record the real admin when impersonating
+	ctx := WithImpersonator(context.Background(), "support-admin")
+	entry = newAuditEntry(ctx, AuditCommentCreated, comment, "alice")
+	assert.Equal(t, "support-admin", entry.ActorID, "the real admin is the actor")
+	assert.Equal(t, "alice", entry.OnBehalfOf)
+	assert.Equal(t, comment.ID, entry.CommentID)
+	assert.Equal(t, "shop", entry.TenantID)
+}
+
+func TestNewCommentWithReplies(t *testing.T) {
+	comment := &models.Comment{ID: primitive.NewObjectID(), Content: "deep-linked"}
+	replies := make([]*models.Comment, 5)
+	for i := range replies {
+		replies[i] = &models.Comment{ID: primitive.NewObjectID(), ParentID: &comment.ID, Content: fmt.Sprintf("reply %d", i)}
+	}
+
+	result := newCommentWithReplies(comment, replies, 3)
+	assert.Same(t, comment, result.Comment)
+	require.Len(t, result.Replies, 3)
+	for i, reply := range result.Replies {
+		assert.Same(t, replies[i], reply.Comment)
+		assert.Empty(t, reply.Replies)
+	}
+
+	assert.Empty(t, newCommentWithReplies(comment, nil, 3).Replies)
+}
+
+func TestCheckPendingLimit(t *testing.T) {
+	settings := &models.CommentSettings{MaxPendingPerAuthor: 3}
+
+	// Create comments until the author's pending count reaches the cap
+	var pending int64
+	for ; pending < 3; pending++ {
+		require.NoError(t, checkPendingLimit(pending, settings), "comment %d", pending+1)
+	}
+	assert.EqualError(t, checkPendingLimit(pending, settings), "too many comments awaiting review")
+
+	assert.NoError(t, checkPendingLimit(100, &models.CommentSettings{}), "0 disables the cap")
+}
+
+func TestCheckRootLimit(t *testing.T) {
+	settings := &models.CommentSettings{MaxRootComments: 3}
+
+	// Create root comments until the resource reaches the cap
+	var roots int64
+	for ; roots < 3; roots++ {
+		closed, err := checkRootLimit(roots, settings)
+		require.NoError(t, err, "root %d", roots+1)
+		assert.False(t, closed)
+	}
+	_, err := checkRootLimit(roots, settings)
+	assert.EqualError(t, err, "this resource has reached its maximum number of comments")
+
+	t.Run("Close", func(t *testing.T) {
+		closing := &models.CommentSettings{MaxRootComments: 3, MaxRootCommentsAction: models.ActionClose}
+		closed, err := checkRootLimit(3, closing)
+		assert.NoError(t, err)
+		assert.True(t, closed)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		closed, err := checkRootLimit(1000, &models.CommentSettings{})
+		assert.NoError(t, err)
+		assert.False(t, closed)
+	})
+}
+
+func TestModerationLabels(t *testing.T) {
+	vocabulary := []string{"off-topic", "needs-source", "duplicate"}
+
+	t.Run("Normalize", func(t *testing.T) {
+		assert.Equal(t, []string{"off-topic", "duplicate"}, normalizeLabels([]string{" off-topic", "", "duplicate", "off-topic "}))
+		assert.Empty(t, normalizeLabels([]string{" ", ""}))
+	})
+
+	t.Run("Vocabulary", func(t *testing.T) {
+		assert.NoError(t, checkLabels([]string{"off-topic", "duplicate"}, vocabulary))
+		assert.EqualError(t, checkLabels([]string{"off-topic", "spam"}, vocabulary), `label "spam" is not in the moderation label vocabulary`)
+		assert.Error(t, checkLabels([]string{"off-topic"}, nil), "no vocabulary allows no labels")
+	})
+
+	t.Run("Add And Remove", func(t *testing.T) {
+		comment := &models.Comment{}
+
+		comment.Labels = mergeLabels(comment.Labels, []string{"off-topic"})
+		comment.Labels = mergeLabels(comment.Labels, []string{"needs-source", "off-topic"})
+		assert.Equal(t, []string{"off-topic", "needs-source"}, comment.Labels)
+
+		comment.Labels = dropLabels(comment.Labels, []string{"off-topic", "duplicate"})
+		assert.Equal(t, []string{"needs-source"}, comment.Labels)
+	})
+}
+
+func TestBuildCommentTree(t *testing.T) {
+	at := func(minutes int) time.Time { return time.Date(2025, 1, 1, 12, minutes, 0, 0, time.UTC) }
+	reply := func(parent *models.Comment, minutes int) *models.Comment {
+		pid := parent.ID
+		return &models.Comment{ID: primitive.NewObjectID(), ParentID: &pid, Depth: parent.Depth + 1, CreatedAt: at(minutes)}
+	}
+
+	first := &models.Comment{ID: primitive.NewObjectID(), CreatedAt: at(0)}
+	pinned := &models.Comment{ID: primitive.NewObjectID(), IsPinned: true, CreatedAt: at(5)}
+	third := &models.Comment{ID: primitive.NewObjectID(), CreatedAt: at(10)}
+	firstReply := reply(first, 1)
+	nestedReply := reply(firstReply, 2)
+	secondReply := reply(first, 3)
+	orphan := reply(&models.Comment{ID: primitive.NewObjectID()}, 4)
+
+	// Oldest first, as the repository returns them
+	tree := buildCommentTree([]*models.Comment{first, firstReply, nestedReply, secondReply, orphan, pinned, third})
+
+	require.Len(t, tree, 3)
+	assert.Equal(t, pinned, tree[0].Comment, "pinned roots come first")
+	assert.Equal(t, first, tree[1].Comment)
+	assert.Equal(t, third, tree[2].Comment)
+
+	require.Len(t, tree[1].Replies, 2)
+	assert.Equal(t, firstReply, tree[1].Replies[0].Comment)
+	assert.Equal(t, secondReply, tree[1].Replies[1].Comment)
+	require.Len(t, tree[1].Replies[0].Replies, 1)
+	assert.Equal(t, nestedReply, tree[1].Replies[0].Replies[0].Comment)
+	assert.Empty(t, tree[2].Replies)
+
+	assert.Empty(t, buildCommentTree(nil))
+}
+
+func TestTreeDepth(t *testing.T) {
+	// Settings display three levels of replies
+	assert.Equal(t, 3, treeDepth(0, 3, false))
+	assert.Equal(t, 2, treeDepth(2, 3, false))
+	assert.Equal(t, 3, treeDepth(10, 3, false), "readers are capped at the settings depth")
+
+	assert.Equal(t, 3, treeDepth(0, 3, true), "admins get the settings depth unless they ask")
+	assert.Equal(t, 10, treeDepth(10, 3, true), "admins can look past the display depth")
+	assert.Equal(t, MaxAdminTreeDepth, treeDepth(1000, 3, true), "up to the system limit")
+}
+
+func TestPruneDeletedNodes(t *testing.T) {
+	reply := func(parent *models.Comment) *models.Comment {
+		pid := parent.ID
+		return &models.Comment{ID: primitive.NewObjectID(), ParentID: &pid, Depth: parent.Depth + 1, Content: "reply", AuthorID: "bob", AuthorName: "Bob"}
+	}
+
+	// root -> deleted middle -> live leaf, plus a deleted root with only deleted replies
+	root := &models.Comment{ID: primitive.NewObjectID(), Content: "root", AuthorID: "alice", AuthorName: "Alice"}
+	middle := reply(root)
+	middle.IsDeleted = true
+	middle.AuthorEmail = "bob@example.com"
+	middle.Attachments = []models.Attachment{{URL: "https://cdn.example.com/a.png"}}
+	leaf := reply(middle)
+	deadRoot := &models.Comment{ID: primitive.NewObjectID(), IsDeleted: true, Content: "gone"}
+	deadReply := reply(deadRoot)
+	deadReply.IsDeleted = true
+
+	tree := pruneDeletedNodes(buildCommentTree([]*models.Comment{root, middle, leaf, deadRoot, deadReply}))
+
+	require.Len(t, tree, 1, "deleted subtrees without live replies are dropped")
+	assert.Equal(t, root, tree[0].Comment)
+
+	require.Len(t, tree[0].Replies, 1)
+	placeholder := tree[0].Replies[0].Comment
+	assert.Equal(t, middle.ID, placeholder.ID)
+	assert.True(t, placeholder.IsDeleted)
+	assert.Equal(t, DeletedPlaceholderText, placeholder.Content)
+	assert.Equal(t, DeletedPlaceholderText, placeholder.AuthorName)
+	assert.Empty(t, placeholder.AuthorID)
+	assert.Empty(t, placeholder.AuthorEmail)
+	assert.Nil(t, placeholder.Attachments)
+	assert.Equal(t, "Bob", middle.AuthorName, "the stored comment is not modified")
+
+	require.Len(t, tree[0].Replies[0].Replies, 1)
+	assert.Equal(t, leaf, tree[0].Replies[0].Replies[0].Comment)
+
+	assert.Empty(t, pruneDeletedNodes(buildCommentTree(nil)))
+}
+
+func TestNewAuthorSummary(t *testing.T) {
+	// 6 approved, 2 rejected, 1 spam and 3 pending
+	counts := map[string]int64{
+		string(models.StatusApproved): 6,
+		string(models.StatusRejected): 2,
+		string(models.StatusSpam):     1,
+		string(models.StatusPending):  3,
+	}
+
+	summary := newAuthorSummary("alice", "product", counts, 12, 7)
+	assert.Equal(t, "alice", summary.AuthorID)
+	assert.Equal(t, "product", summary.ResourceType)
+	assert.Equal(t, int64(12), summary.TotalComments)
+	assert.Equal(t, int64(7), summary.ReportCount)
+	assert.InDelta(t, 6.0/9.0, summary.ApprovalRate, 0.0001, "pending comments don't count against the rate")
+
+	t.Run("Only Pending", func(t *testing.T) {
+		summary := newAuthorSummary("bob", "", map[string]int64{string(models.StatusPending): 2}, 2, 0)
+		assert.Equal(t, 0.0, summary.ApprovalRate)
+	})
+
+	t.Run("No Comments", func(t *testing.T) {
+		summary := newAuthorSummary("carol", "", nil, 0, 0)
+		assert.NotNil(t, summary.Counts)
+		assert.Equal(t, 0.0, summary.ApprovalRate)
+	})
+}
+
+func TestCheckAttachmentURLs(t *testing.T) {
+	attach := func(url string) []models.Attachment { return []models.Attachment{{URL: url}} }
+
+	tests := []struct {
+		name string
+		url  string
+		ok   bool
+	}{
+		{"HTTPS", "https://cdn.example.com/a.png", true},
+		{"HTTPS Upper Case Scheme", "HTTPS://cdn.example.com/a.png", true},
+		{"HTTP", "http://cdn.example.com/a.png", false},
+		{"JavaScript", "javascript:alert(1)", false},
+		{"JavaScript With Slashes", "javascript://cdn.example.com/%0Aalert(1)", false},
+		{"Data", "data:image/png;base64,AAAA", false},
+		{"Relative", "/uploads/a.png", false},
+		{"Scheme Relative", "//cdn.example.com/a.png", false},
+		{"Empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAttachmentURLs(attach(tt.url), true)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, "attachment URL must be an absolute https:// URL")
+			}
+		})
+	}
+
+	t.Run("HTTP Allowed When Not Required", func(t *testing.T) {
+		assert.NoError(t, checkAttachmentURLs(attach("http://cdn.example.com/a.png"), false))
+		assert.Error(t, checkAttachmentURLs(attach("javascript:alert(1)"), false))
+	})
+
+	t.Run("One Bad Attachment Rejects All", func(t *testing.T) {
+		attachments := []models.Attachment{{URL: "https://cdn.example.com/a.png"}, {URL: "http://cdn.example.com/b.png"}}
+		assert.Error(t, checkAttachmentURLs(attachments, true))
+	})
+}
+
+func TestAllowedAvatar(t *testing.T) {
+	assert.Equal(t, "https://cdn.example.com/alice.png", allowedAvatar("https://cdn.example.com/alice.png", true))
+	assert.Empty(t, allowedAvatar("http://cdn.example.com/alice.png", true))
+	assert.Equal(t, "http://cdn.example.com/alice.png", allowedAvatar("http://cdn.example.com/alice.png", false))
+	assert.Empty(t, allowedAvatar("javascript:alert(1)", false))
+	assert.Empty(t, allowedAvatar("", true))
+}
+
+func TestCheckAttachmentSizes(t *testing.T) {
+	const mb = 1 << 20
+	settings := &models.CommentSettings{MaxAttachmentSize: 5 * mb, MaxTotalAttachmentSize: 10 * mb}
+
+	t.Run("Within Both Caps", func(t *testing.T) {
+		attachments := []models.Attachment{{Filename: "a.png", Size: 4 * mb}, {Filename: "b.png", Size: 4 * mb}}
+		assert.NoError(t, checkAttachmentSizes(attachments, settings))
+	})
+
+	t.Run("Each Fits But Together Too Large", func(t *testing.T) {
+		attachments := []models.Attachment{
+			{Filename: "a.png", Size: 4 * mb},
+			{Filename: "b.png", Size: 4 * mb},
+			{Filename: "c.png", Size: 4 * mb},
+		}
+		assert.EqualError(t, checkAttachmentSizes(attachments, settings), "attachments exceed the maximum total size of 10485760 bytes")
+	})
+
+	t.Run("One Too Large", func(t *testing.T) {
+		attachments := []models.Attachment{{Filename: "huge.mov", Size: 6 * mb}}
+		assert.EqualError(t, checkAttachmentSizes(attachments, settings), "attachment huge.mov exceeds the maximum size of 5242880 bytes")
+	})
+
+	t.Run("Negative Size", func(t *testing.T) {
+		attachments := []models.Attachment{{Filename: "a.png", Size: 9 * mb}, {Filename: "b.png", Size: -8 * mb}}
+		assert.EqualError(t, checkAttachmentSizes(attachments, &models.CommentSettings{MaxTotalAttachmentSize: 10 * mb}), "attachment size cannot be negative")
+	})
+
+	t.Run("Caps Disabled", func(t *testing.T) {
+		attachments := []models.Attachment{{Size: 50 * mb}, {Size: 50 * mb}}
+		assert.NoError(t, checkAttachmentSizes(attachments, &models.CommentSettings{}))
+	})
+}
+
+func TestMentionNotifications(t *testing.T) {
+	notifier := &recordingNotifier{}
+	u := &CommentUsecase{notifier: notifier, cfg: &config.Config{Notifier: config.NotifierConfig{Enabled: true}}}
+
+	content := "@alice see this, @bob too. @alice you especially (mail me at me@example.com)"
+	comment := &models.Comment{
+		ID:         primitive.NewObjectID(),
+		AuthorID:   "user-1",
+		AuthorName: "Jane",
+		Content:    content,
+		Mentions:   extractMentions(content),
+	}
+	u.sendMentionNotifications(comment)
+
+	require.Len(t, notifier.sent, 2, "each mentioned user is notified once")
+	assert.Equal(t, "comment.mention", notifier.sent[0].Type)
+	assert.Equal(t, []string{"alice"}, notifier.sent[0].Recipients)
+	assert.Equal(t, []string{"bob"}, notifier.sent[1].Recipients)
+	assert.Equal(t, comment.ID.Hex(), notifier.sent[0].Data["comment_id"])
+}
+
+func TestModerationHistory(t *testing.T) {
+	ctx := context.Background()
+	comment := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", Status: models.StatusPending}
+
+	// Created pending, rejected, then approved on appeal, as ModerateComment audits it
+	var entries []*models.AuditEntry
+	record := func(action, actor string, at time.Time) {
+		entry := newAuditEntry(ctx, action, comment, actor)
+		entry.CreatedAt = at
+		entries = append(entries, entry)
+	}
+	start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	record(AuditCommentCreated, "alice", start)
+	comment.Status = models.StatusRejected
+	comment.RejectionReason = "Off topic"
+	record(AuditCommentModerated, "mod-1", start.Add(time.Hour))
+	comment.Status = models.StatusApproved
+	record(AuditCommentModerated, "mod-2", start.Add(2*time.Hour))
+
+	history := moderationHistory(entries)
+	require.Len(t, history, 3)
+
+	assert.Equal(t, models.StatusPending, history[0].Status)
+	assert.Equal(t, "alice", history[0].ModeratorID)
+
+	assert.Equal(t, AuditCommentModerated, history[1].Action)
+	assert.Equal(t, models.StatusRejected, history[1].Status)
+	assert.Equal(t, "Off topic", history[1].Reason)
+	assert.Equal(t, "mod-1", history[1].ModeratorID)
+	assert.Equal(t, start.Add(time.Hour), history[1].At)
+
+	assert.Equal(t, models.StatusApproved, history[2].Status)
+	assert.Empty(t, history[2].Reason, "a stale rejection reason isn't carried into approval")
+	assert.Equal(t, "mod-2", history[2].ModeratorID)
+
+	assert.NotNil(t, moderationHistory(nil))
+}
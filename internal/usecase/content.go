@@ -0,0 +1,443 @@
+package usecase
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+)
+
+// snippetLength is the maximum number of characters kept in a comment snippet
+const snippetLength = 100
+
+// defaultParentEchoThreshold is the word overlap at which a reply counts as a
+// copy of its parent when the settings don't set one
+const defaultParentEchoThreshold = 0.9
+
+// scriptTolerance is the share of letters allowed outside a tenant's allowed
+// scripts, so the odd foreign name or loanword doesn't reject a comment
+const scriptTolerance = 0.1
+
+var (
+	urlRegex       = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+	linkRegex      = regexp.MustCompile(`(?i)\bhttps?://(?:[^\s<&]|&amp;)+`) // stops at escaped markup and quotes
+	blankLineRegex = regexp.MustCompile(`\n{3,}`)
+	// mentionRegex needs the @ at the start or after a character that can't
+	// end a username or email local part, so user@example.com isn't a mention
+	mentionRegex = regexp.MustCompile(`(?:^|[^a-zA-Z0-9_.+\-@])@([a-zA-Z0-9_]+)`)
+)
+
+// isLowInformation reports whether content has no letters or digits once
+// URLs, emojis, punctuation and whitespace are stripped
+func isLowInformation(content string) bool {
+	stripped := urlRegex.ReplaceAllString(content, "")
+	for _, r := range stripped {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkLowInformation applies the tenant's low-information policy, returning
+// true when the comment should be held for review instead of rejected
+func checkLowInformation(content string, settings *models.CommentSettings) (bool, error) {
+	if !settings.RejectLowInfoComments || !isLowInformation(content) {
+		return false, nil
+	}
+	if settings.LowInfoAction == models.ActionPending {
+		return true, nil
+	}
+	return false, fmt.Errorf("comment has no meaningful content")
+}
+
+// spamScore counts the spam heuristics content matches: too many URLs, a long
+// run of one character, and shouting in all caps past a minimum length
+func spamScore(content string, cfg config.ModerationConfig) int {
+	score := 0
+	if len(urlRegex.FindAllStringIndex(content, -1)) > cfg.SpamMaxURLs {
+		score++
+	}
+	if longestRun(content) > cfg.SpamMaxRepeatedChars {
+		score++
+	}
+	if isShouting(content, cfg.SpamCapsMinLength) {
+		score++
+	}
+	return score
+}
+
+// longestRun returns the length of the longest run of one repeated
+// non-space character
+func longestRun(content string) int {
+	longest, run := 0, 0
+	var prev rune
+	for _, r := range content {
+		if unicode.IsSpace(r) {
+			run = 0
+			continue
+		}
+		if run > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// isShouting reports whether content has at least minLength upper case
+// letters and no lower case ones
+func isShouting(content string, minLength int) bool {
+	upper := 0
+	for _, r := range content {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return upper > 0 && upper >= minLength
+}
+
+// isDisposableEmail reports whether email's domain is one of domains or a
+// subdomain of one, ignoring case
+func isDisposableEmail(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := normalizeEmailDomain(email[at+1:])
+	if domain == "" {
+		return false
+	}
+
+	for _, disposable := range domains {
+		disposable = normalizeEmailDomain(strings.TrimLeft(strings.TrimSpace(disposable), "@."))
+		if disposable == "" {
+			continue
+		}
+		if domain == disposable || strings.HasSuffix(domain, "."+disposable) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEmailDomain lowercases a domain and drops surrounding whitespace,
+// angle brackets and a trailing root dot
+func normalizeEmailDomain(domain string) string {
+	domain = strings.TrimSpace(domain)
+	domain = strings.TrimRight(domain, ">")
+	domain = strings.TrimSuffix(domain, ".")
+	return strings.ToLower(domain)
+}
+
+// extractMentions returns the distinct @usernames in content, in order of
+// first appearance
+func extractMentions(content string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range mentionRegex.FindAllStringSubmatch(content, -1) {
+		if username := match[1]; !seen[username] {
+			seen[username] = true
+			mentions = append(mentions, username)
+		}
+	}
+	return mentions
+}
+
+// echoWords lowercases content and splits it into a set of words, ignoring
+// punctuation, so formatting differences don't hide a copy
+func echoWords(content string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// echoSimilarity returns the Jaccard overlap of two contents' word sets, from
+// 0 (nothing shared) to 1 (same words)
+func echoSimilarity(a, b string) float64 {
+	wordsA, wordsB := echoWords(a), echoWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// checkParentEcho applies the tenant's policy for replies that only repeat
+// their parent, returning true when the reply should be held for review
+// instead of rejected
+func checkParentEcho(content string, parent *models.Comment, settings *models.CommentSettings) (bool, error) {
+	if !settings.RejectParentEchoes || parent == nil {
+		return false, nil
+	}
+
+	threshold := settings.ParentEchoThreshold
+	if threshold <= 0 {
+		threshold = defaultParentEchoThreshold
+	}
+	if echoSimilarity(content, parent.Content) < threshold {
+		return false, nil
+	}
+
+	if settings.ParentEchoAction == models.ActionPending {
+		return true, nil
+	}
+	return false, fmt.Errorf("reply only repeats the comment it replies to")
+}
+
+// normalizeProcessor unifies line endings, drops control characters and
+// trims surrounding and excess blank-line whitespace
+type normalizeProcessor struct{}
+
+func (normalizeProcessor) Name() string { return StageNormalize }
+
+func (normalizeProcessor) Process(content *ProcessedContent, _ *models.CommentSettings) error {
+	text := strings.ReplaceAll(content.Content, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+	text = blankLineRegex.ReplaceAllString(text, "\n\n")
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return fmt.Errorf("comment content is empty")
+	}
+
+	content.Content = text
+	return nil
+}
+
+// lowInfoProcessor applies the tenant's low-information comment policy
+type lowInfoProcessor struct{}
+
+func (lowInfoProcessor) Name() string { return StageLowInfo }
+
+func (lowInfoProcessor) Process(content *ProcessedContent, settings *models.CommentSettings) error {
+	hold, err := checkLowInformation(content.Content, settings)
+	if err != nil {
+		return err
+	}
+	if hold {
+		content.HoldForReview = true
+	}
+	return nil
+}
+
+// checkAllowedScripts rejects content whose letters mostly fall outside the
+// tenant's allowed Unicode scripts. Only letters are counted, so digits,
+// punctuation and emoji never count against a comment. Unknown script names
+// are ignored; an empty list allows everything.
+func checkAllowedScripts(content string, settings *models.CommentSettings) error {
+	if len(settings.AllowedScripts) == 0 {
+		return nil
+	}
+
+	tables := make([]*unicode.RangeTable, 0, len(settings.AllowedScripts))
+	for _, name := range settings.AllowedScripts {
+		if table, ok := unicode.Scripts[name]; ok {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	letters, outside := 0, 0
+	for _, r := range content {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if !unicode.In(r, tables...) {
+			outside++
+		}
+	}
+
+	if letters > 0 && float64(outside)/float64(letters) > scriptTolerance {
+		return fmt.Errorf("comment contains unsupported characters")
+	}
+	return nil
+}
+
+// scriptsProcessor applies the tenant's allowed-scripts restriction
+type scriptsProcessor struct{}
+
+func (scriptsProcessor) Name() string { return StageScripts }
+
+func (scriptsProcessor) Process(content *ProcessedContent, settings *models.CommentSettings) error {
+	return checkAllowedScripts(content.Content, settings)
+}
+
+// badWordsProcessor flags words from the global and tenant bad-word lists
+type badWordsProcessor struct {
+	regex *regexp.Regexp
+}
+
+func newBadWordsProcessor(cfg config.ModerationConfig) badWordsProcessor {
+	var regex *regexp.Regexp
+	if cfg.BadWordsEnabled && len(cfg.BadWordsList) > 0 {
+		regex = wordListRegex(cfg.BadWordsList)
+	}
+	return badWordsProcessor{regex: regex}
+}
+
+func (badWordsProcessor) Name() string { return StageBadWords }
+
+func (p badWordsProcessor) Process(content *ProcessedContent, settings *models.CommentSettings) error {
+	var flagged []string
+
+	// Check with default regex
+	if p.regex != nil {
+		flagged = append(flagged, p.regex.FindAllString(content.Content, -1)...)
+	}
+
+	// Check custom bad words
+	if len(settings.CustomBadWords) > 0 {
+		if customRegex := wordListRegex(settings.CustomBadWords); customRegex != nil {
+			flagged = append(flagged, customRegex.FindAllString(content.Content, -1)...)
+		}
+	}
+
+	// Remove duplicates
+	seen := make(map[string]bool)
+	unique := []string{}
+	for _, word := range content.FlaggedWords {
+		seen[strings.ToLower(word)] = true
+		unique = append(unique, word)
+	}
+	for _, word := range flagged {
+		lower := strings.ToLower(word)
+		if !seen[lower] {
+			seen[lower] = true
+			unique = append(unique, word)
+		}
+	}
+
+	content.FlaggedWords = unique
+	return nil
+}
+
+// wordListRegex builds a case-insensitive whole-word matcher for a word list
+func wordListRegex(words []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+
+	regex, err := regexp.Compile("(?i)\\b(" + strings.Join(quoted, "|") + ")\\b")
+	if err != nil {
+		return nil
+	}
+	return regex
+}
+
+// blockedPatternsProcessor rejects content matching any operator-defined regex
+type blockedPatternsProcessor struct {
+	patterns []*regexp.Regexp
+}
+
+func newBlockedPatternsProcessor(patterns []string) blockedPatternsProcessor {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("Invalid blocked pattern %q: %v", pattern, err)
+			continue
+		}
+		compiled = append(compiled, regex)
+	}
+	return blockedPatternsProcessor{patterns: compiled}
+}
+
+func (blockedPatternsProcessor) Name() string { return StageBlockedPatterns }
+
+func (p blockedPatternsProcessor) Process(content *ProcessedContent, _ *models.CommentSettings) error {
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(content.Content) {
+			return fmt.Errorf("comment contains blocked content")
+		}
+	}
+	return nil
+}
+
+// sanitizeProcessor renders plain content as escaped HTML with line breaks.
+// Every character of the content is escaped, so the only markup in the
+// result is the <br> and links this pipeline adds itself. Tenants with
+// RenderHTML off get no HTML at all.
+type sanitizeProcessor struct{}
+
+func (sanitizeProcessor) Name() string { return StageSanitize }
+
+func (sanitizeProcessor) Process(content *ProcessedContent, settings *models.CommentSettings) error {
+	if !settings.RenderHTML {
+		content.ContentHTML = ""
+		return nil
+	}
+
+	escaped := html.EscapeString(content.Content)
+	content.ContentHTML = strings.ReplaceAll(escaped, "\n", "<br>")
+	return nil
+}
+
+// autoLinkProcessor turns http(s) URLs in the sanitized HTML into links.
+// It only runs on sanitized output, never on raw content.
+type autoLinkProcessor struct{}
+
+func (autoLinkProcessor) Name() string { return StageAutoLink }
+
+func (autoLinkProcessor) Process(content *ProcessedContent, _ *models.CommentSettings) error {
+	if content.ContentHTML == "" {
+		return nil
+	}
+
+	content.ContentHTML = linkRegex.ReplaceAllStringFunc(content.ContentHTML, func(match string) string {
+		url := strings.TrimRight(match, ".,!?;:)")
+		trailing := match[len(url):]
+		return `<a href="` + url + `" rel="nofollow noopener noreferrer" target="_blank">` + url + `</a>` + trailing
+	})
+	return nil
+}
+
+// snippetProcessor stores a short single-line preview of the content
+type snippetProcessor struct {
+	maxLen int
+}
+
+func (snippetProcessor) Name() string { return StageSnippet }
+
+func (p snippetProcessor) Process(content *ProcessedContent, _ *models.CommentSettings) error {
+	content.Snippet = truncateString(strings.Join(strings.Fields(content.Content), " "), p.maxLen)
+	return nil
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"log"
+	"strings"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+)
+
+// Content processor stage names, usable in MODERATION_CONTENT_PIPELINE
+const (
+	StageNormalize       = "normalize"
+	StageLowInfo         = "low_info"
+	StageScripts         = "scripts"
+	StageBadWords        = "bad_words"
+	StageBlockedPatterns = "blocked_patterns"
+	StageSanitize        = "sanitize"
+	StageAutoLink        = "auto_link"
+	StageSnippet         = "snippet"
+)
+
+// DefaultContentPipeline is the stage order used when none is configured
+var DefaultContentPipeline = []string{
+	StageNormalize,
+	StageLowInfo,
+	StageScripts,
+	StageBadWords,
+	StageBlockedPatterns,
+	StageSanitize,
+	StageAutoLink,
+	StageSnippet,
+}
+
+// ProcessedContent carries comment content through the pipeline and collects
+// what each stage found
+type ProcessedContent struct {
+	Original      string
+	Content       string
+	ContentHTML   string
+	Snippet       string
+	FlaggedWords  []string
+	HoldForReview bool // a stage wants the comment pending regardless of settings
+}
+
+// ContentProcessor is a single pipeline stage. Returning an error rejects the comment.
+type ContentProcessor interface {
+	Name() string
+	Process(content *ProcessedContent, settings *models.CommentSettings) error
+}
+
+// ContentPipeline runs content through an ordered list of processors
+type ContentPipeline struct {
+	processors []ContentProcessor
+}
+
+// NewContentPipeline builds a pipeline from the configured stage names
+func NewContentPipeline(cfg config.ModerationConfig) *ContentPipeline {
+	stages := cfg.ContentPipeline
+	if len(stages) == 0 {
+		stages = DefaultContentPipeline
+	}
+
+	available := map[string]ContentProcessor{
+		StageNormalize:       normalizeProcessor{},
+		StageLowInfo:         lowInfoProcessor{},
+		StageScripts:         scriptsProcessor{},
+		StageBadWords:        newBadWordsProcessor(cfg),
+		StageBlockedPatterns: newBlockedPatternsProcessor(cfg.BlockedPatterns),
+		StageSanitize:        sanitizeProcessor{},
+		StageAutoLink:        autoLinkProcessor{},
+		StageSnippet:         snippetProcessor{maxLen: snippetLength},
+	}
+
+	processors := make([]ContentProcessor, 0, len(stages))
+	for _, name := range stages {
+		processor, ok := available[strings.TrimSpace(name)]
+		if !ok {
+			log.Printf("Unknown content pipeline stage %q, skipping", name)
+			continue
+		}
+		processors = append(processors, processor)
+	}
+
+	return &ContentPipeline{processors: processors}
+}
+
+// Run passes content through every stage in order
+func (p *ContentPipeline) Run(content string, settings *models.CommentSettings) (*ProcessedContent, error) {
+	result := &ProcessedContent{
+		Original: content,
+		Content:  content,
+	}
+
+	for _, processor := range p.processors {
+		if err := processor.Process(result, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
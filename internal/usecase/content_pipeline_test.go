@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContentPipeline(t *testing.T) {
+	pipeline := NewContentPipeline(config.ModerationConfig{})
+	names := make([]string, 0, len(pipeline.processors))
+	for _, processor := range pipeline.processors {
+		names = append(names, processor.Name())
+	}
+	assert.Equal(t, DefaultContentPipeline, names)
+
+	pipeline = NewContentPipeline(config.ModerationConfig{
+		ContentPipeline: []string{"snippet", " normalize ", "unknown"},
+	})
+	if assert.Len(t, pipeline.processors, 2) {
+		assert.Equal(t, StageSnippet, pipeline.processors[0].Name())
+		assert.Equal(t, StageNormalize, pipeline.processors[1].Name())
+	}
+}
+
+func TestContentPipelineRun(t *testing.T) {
+	cfg := config.ModerationConfig{
+		BadWordsEnabled: true,
+		BadWordsList:    []string{"spam"},
+		BlockedPatterns: []string{`(?i)free\s+money`},
+	}
+	settings := &models.CommentSettings{RejectLowInfoComments: true, LowInfoAction: models.ActionPending, RenderHTML: true}
+
+	t.Run("Default Stages", func(t *testing.T) {
+		result, err := NewContentPipeline(cfg).Run("  No spam <b>here</b>\r\nhttps://example.com  ", settings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "  No spam <b>here</b>\r\nhttps://example.com  ", result.Original)
+		assert.Equal(t, "No spam <b>here</b>\nhttps://example.com", result.Content)
+		assert.Equal(t,
+			`No spam &lt;b&gt;here&lt;/b&gt;<br><a href="https://example.com" rel="nofollow noopener noreferrer" target="_blank">https://example.com</a>`,
+			result.ContentHTML)
+		assert.Equal(t, "No spam <b>here</b> https://example.com", result.Snippet)
+		assert.Equal(t, []string{"spam"}, result.FlaggedWords)
+		assert.False(t, result.HoldForReview)
+	})
+
+	t.Run("Blocked Content", func(t *testing.T) {
+		_, err := NewContentPipeline(cfg).Run("Get FREE money today", settings)
+		assert.EqualError(t, err, "comment contains blocked content")
+	})
+
+	t.Run("Low Information Held", func(t *testing.T) {
+		result, err := NewContentPipeline(cfg).Run("👍👍", settings)
+		assert.NoError(t, err)
+		assert.True(t, result.HoldForReview)
+	})
+
+	t.Run("Disabled Stages", func(t *testing.T) {
+		cfg := cfg
+		cfg.ContentPipeline = []string{StageNormalize, StageSanitize}
+
+		result, err := NewContentPipeline(cfg).Run("spam and free money", settings)
+		assert.NoError(t, err)
+		assert.Empty(t, result.FlaggedWords)
+		assert.Equal(t, "spam and free money", result.ContentHTML)
+		assert.Empty(t, result.Snippet)
+	})
+}
@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpamScore(t *testing.T) {
+	cfg := config.ModerationConfig{SpamMaxURLs: 3, SpamMaxRepeatedChars: 10, SpamCapsMinLength: 20}
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"Normal Comment", "Great product, arrived on time. See https://example.com for photos", 0},
+		{"Many URLs", "https://a.example https://b.example www.c.example https://d.example", 1},
+		{"Repeated Characters", "Best deal ever!!!!!!!!!!!!", 1},
+		{"Spaces Don't Count", "Indented" + strings.Repeat(" ", 20) + "text", 0},
+		{"All Caps", "THIS IS THE BEST PRODUCT EVER MADE", 1},
+		{"Short All Caps", "LOL OK", 0},
+		{"Caps With Lower Case", "THIS IS THE BEST PRODUCT EVER, honestly", 0},
+		{"Caseless Script", "این بهترین محصولی است که تا به حال خریده‌ام", 0},
+		{"Everything", "BUY NOW!!!!!!!!!!!! HTTPS://A.EXAMPLE HTTPS://B.EXAMPLE HTTPS://C.EXAMPLE HTTPS://D.EXAMPLE", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, spamScore(tt.content, cfg))
+		})
+	}
+}
+
+func TestIsDisposableEmail(t *testing.T) {
+	domains := []string{"mailinator.com", " @YopMail.com "}
+
+	tests := []struct {
+		name  string
+		email string
+		want  bool
+	}{
+		{"Disposable", "throwaway@mailinator.com", true},
+		{"Mixed Case", "Throwaway@MailInator.COM", true},
+		{"Subdomain", "x@eu.mailinator.com", true},
+		{"Padded List Entry", "x@yopmail.com", true},
+		{"Trailing Dot", "x@mailinator.com.", true},
+		{"Normal Domain", "alice@example.com", false},
+		{"Lookalike Domain", "alice@notmailinator.com", false},
+		{"Disposable Local Part", "mailinator.com@example.com", false},
+		{"Quoted Local Part With At", `"a@mailinator.com"@example.com`, false},
+		{"No Email", "", false},
+		{"No Domain", "alice@", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDisposableEmail(tt.email, domains))
+		})
+	}
+}
+
+func TestIsLowInformation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"Emoji Only", "😂😂🔥 👍", true},
+		{"Punctuation Only", "?!... ---", true},
+		{"URL Only", "https://example.com/some/path?x=1", true},
+		{"URL With Emoji", "www.example.com 🔥", true},
+		{"Normal Comment", "Great product, arrived on time!", false},
+		{"Text With URL", "See https://example.com for details", false},
+		{"Number", "10/10", false},
+		{"Non-Latin Text", "عالی بود", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isLowInformation(tt.content))
+		})
+	}
+}
+
+func TestCheckLowInformation(t *testing.T) {
+	settings := &models.CommentSettings{RejectLowInfoComments: true, LowInfoAction: models.ActionReject}
+
+	_, err := checkLowInformation("👍", settings)
+	assert.EqualError(t, err, "comment has no meaningful content")
+
+	settings.LowInfoAction = models.ActionPending
+	pending, err := checkLowInformation("👍", settings)
+	assert.NoError(t, err)
+	assert.True(t, pending)
+
+	pending, err = checkLowInformation("Thanks, that helped", settings)
+	assert.NoError(t, err)
+	assert.False(t, pending)
+
+	settings.RejectLowInfoComments = false
+	pending, err = checkLowInformation("👍", settings)
+	assert.NoError(t, err)
+	assert.False(t, pending)
+}
+
+func TestCheckParentEcho(t *testing.T) {
+	parent := &models.Comment{Content: "Does this charger work with the older model?"}
+	settings := &models.CommentSettings{RejectParentEchoes: true, ParentEchoAction: models.ActionReject}
+
+	_, err := checkParentEcho(parent.Content, parent, settings)
+	assert.EqualError(t, err, "reply only repeats the comment it replies to")
+
+	// Case and punctuation don't hide a copy
+	_, err = checkParentEcho("does this charger work with the older model", parent, settings)
+	assert.Error(t, err)
+
+	settings.ParentEchoAction = models.ActionPending
+	pending, err := checkParentEcho(parent.Content, parent, settings)
+	assert.NoError(t, err)
+	assert.True(t, pending)
+
+	pending, err = checkParentEcho("Yes, it works with the older model too.", parent, settings)
+	assert.NoError(t, err)
+	assert.False(t, pending)
+
+	// Root comments have nothing to echo
+	pending, err = checkParentEcho(parent.Content, nil, settings)
+	assert.NoError(t, err)
+	assert.False(t, pending)
+
+	settings.RejectParentEchoes = false
+	pending, err = checkParentEcho(parent.Content, parent, settings)
+	assert.NoError(t, err)
+	assert.False(t, pending)
+}
+
+func TestEchoSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, echoSimilarity("Great product!", "great, PRODUCT"))
+	assert.Equal(t, 0.0, echoSimilarity("Great product", "Terrible service"))
+	assert.Equal(t, 0.0, echoSimilarity("👍", "👍"))
+	assert.InDelta(t, 0.5, echoSimilarity("great product", "great"), 0.001)
+}
+
+func TestNormalizeProcessor(t *testing.T) {
+	content := &ProcessedContent{Content: "  Hello\r\nworld\x00\n\n\n\nBye  "}
+	err := normalizeProcessor{}.Process(content, &models.CommentSettings{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello\nworld\n\nBye", content.Content)
+
+	err = normalizeProcessor{}.Process(&ProcessedContent{Content: " \r\n\t "}, &models.CommentSettings{})
+	assert.EqualError(t, err, "comment content is empty")
+}
+
+func TestLowInfoProcessor(t *testing.T) {
+	settings := &models.CommentSettings{RejectLowInfoComments: true, LowInfoAction: models.ActionPending}
+
+	content := &ProcessedContent{Content: "🔥🔥"}
+	assert.NoError(t, lowInfoProcessor{}.Process(content, settings))
+	assert.True(t, content.HoldForReview)
+
+	settings.LowInfoAction = models.ActionReject
+	assert.Error(t, lowInfoProcessor{}.Process(&ProcessedContent{Content: "🔥🔥"}, settings))
+}
+
+func TestCheckAllowedScripts(t *testing.T) {
+	latin := &models.CommentSettings{AllowedScripts: []string{"Latin"}}
+
+	tests := []struct {
+		name     string
+		content  string
+		settings *models.CommentSettings
+		wantErr  bool
+	}{
+		{"Allowed Script", "Great product, works as described!", latin, false},
+		{"Punctuation And Emoji", "10/10 would buy again 🔥👍 :-)", latin, false},
+		{"Within Tolerance", "We loved the ramen at Ichiran 一蘭 in Tokyo, highly recommended", latin, false},
+		{"Disallowed Script", "这个产品非常好，推荐购买", latin, true},
+		{"Mostly Disallowed", "buy 便宜的手表在这里购买", latin, true},
+		{"Multiple Scripts Allowed", "Привет, hello", &models.CommentSettings{AllowedScripts: []string{"Latin", "Cyrillic"}}, false},
+		{"Default Allows All", "这个产品非常好", &models.CommentSettings{}, false},
+		{"Unknown Script Ignored", "这个产品非常好", &models.CommentSettings{AllowedScripts: []string{"Klingon"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAllowedScripts(tt.content, tt.settings)
+			if tt.wantErr {
+				assert.EqualError(t, err, "comment contains unsupported characters")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBadWordsProcessor(t *testing.T) {
+	processor := newBadWordsProcessor(config.ModerationConfig{
+		BadWordsEnabled: true,
+		BadWordsList:    []string{"spam", "casino"},
+	})
+	settings := &models.CommentSettings{CustomBadWords: []string{"scam", "c++"}}
+
+	content := &ProcessedContent{Content: "Spam here, casino there, spam again, total scam in c++"}
+	assert.NoError(t, processor.Process(content, settings))
+	assert.Equal(t, []string{"Spam", "casino", "scam"}, content.FlaggedWords)
+
+	disabled := newBadWordsProcessor(config.ModerationConfig{BadWordsList: []string{"spam"}})
+	content = &ProcessedContent{Content: "spam"}
+	assert.NoError(t, disabled.Process(content, &models.CommentSettings{}))
+	assert.Empty(t, content.FlaggedWords)
+}
+
+func TestBlockedPatternsProcessor(t *testing.T) {
+	processor := newBlockedPatternsProcessor([]string{`(?i)buy\s+followers`, "", "[invalid"})
+	assert.Len(t, processor.patterns, 1)
+
+	err := processor.Process(&ProcessedContent{Content: "Buy   followers now"}, &models.CommentSettings{})
+	assert.EqualError(t, err, "comment contains blocked content")
+
+	assert.NoError(t, processor.Process(&ProcessedContent{Content: "Nice post"}, &models.CommentSettings{}))
+}
+
+func TestSanitizeProcessor(t *testing.T) {
+	settings := &models.CommentSettings{RenderHTML: true}
+
+	content := &ProcessedContent{Content: "<script>alert(1)</script>\nline & more"}
+	assert.NoError(t, sanitizeProcessor{}.Process(content, settings))
+	assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt;<br>line &amp; more", content.ContentHTML)
+
+	t.Run("Unbalanced Tags", func(t *testing.T) {
+		content := &ProcessedContent{Content: "<b>bold <i>never closed</div>"}
+		assert.NoError(t, sanitizeProcessor{}.Process(content, settings))
+		assert.Equal(t, "&lt;b&gt;bold &lt;i&gt;never closed&lt;/div&gt;", content.ContentHTML)
+	})
+
+	t.Run("Rendering Disabled", func(t *testing.T) {
+		content := &ProcessedContent{Content: "Hello\nworld", ContentHTML: "stale"}
+		assert.NoError(t, sanitizeProcessor{}.Process(content, &models.CommentSettings{}))
+		assert.Empty(t, content.ContentHTML)
+	})
+}
+
+func TestRenderedHTMLIsSafe(t *testing.T) {
+	pipeline := NewContentPipeline(config.ModerationConfig{})
+	settings := &models.CommentSettings{RenderHTML: true}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"JavaScript URL Is Not Linked",
+			"click javascript:alert(document.cookie)",
+			"click javascript:alert(document.cookie)",
+		},
+		{
+			"JavaScript URL In Markup",
+			`<a href="javascript:alert(1)">x</a>`,
+			"&lt;a href=&#34;javascript:alert(1)&#34;&gt;x&lt;/a&gt;",
+		},
+		{
+			"Quote Can't Break Out Of A Link",
+			`https://example.com/"onmouseover="alert(1)`,
+			`<a href="https://example.com/" rel="nofollow noopener noreferrer" target="_blank">https://example.com/</a>&#34;onmouseover=&#34;alert(1)`,
+		},
+		{
+			"Escaped Ampersand Stays In A Link",
+			"https://example.com/?a=1&b=2",
+			`<a href="https://example.com/?a=1&amp;b=2" rel="nofollow noopener noreferrer" target="_blank">https://example.com/?a=1&amp;b=2</a>`,
+		},
+		{
+			"Script After A Link",
+			"https://example.com<script>alert(1)</script>",
+			`<a href="https://example.com" rel="nofollow noopener noreferrer" target="_blank">https://example.com</a>&lt;script&gt;alert(1)&lt;/script&gt;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := pipeline.Run(tt.content, settings)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, processed.ContentHTML)
+			assert.NotContains(t, processed.ContentHTML, "<script")
+		})
+	}
+}
+
+func TestAutoLinkProcessor(t *testing.T) {
+	content := &ProcessedContent{ContentHTML: "See https://example.com/a?b=1. Thanks"}
+	assert.NoError(t, autoLinkProcessor{}.Process(content, &models.CommentSettings{}))
+	assert.Equal(t,
+		`See <a href="https://example.com/a?b=1" rel="nofollow noopener noreferrer" target="_blank">https://example.com/a?b=1</a>. Thanks`,
+		content.ContentHTML)
+
+	raw := &ProcessedContent{Content: "https://example.com"}
+	assert.NoError(t, autoLinkProcessor{}.Process(raw, &models.CommentSettings{}))
+	assert.Empty(t, raw.ContentHTML, "raw content is never linked")
+}
+
+func TestSnippetProcessor(t *testing.T) {
+	content := &ProcessedContent{Content: "First line\n\nsecond   line"}
+	assert.NoError(t, snippetProcessor{maxLen: 100}.Process(content, &models.CommentSettings{}))
+	assert.Equal(t, "First line second line", content.Snippet)
+
+	content = &ProcessedContent{Content: "ایناستطولانی"}
+	assert.NoError(t, snippetProcessor{maxLen: 8}.Process(content, &models.CommentSettings{}))
+	assert.Equal(t, "ایناس...", content.Snippet)
+}
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"Single", "@alice thanks!", []string{"alice"}},
+		{"Several In Order", "cc @bob, @carol_99 and (@dave)", []string{"bob", "carol_99", "dave"}},
+		{"Duplicates Once", "@alice @bob @alice again @alice", []string{"alice", "bob"}},
+		{"Email Is Not A Mention", "mail user@example.com or first.last@example.com", nil},
+		{"Bare At", "meet @ noon", nil},
+		{"Double At", "@@alice", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extractMentions(tt.content))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PII modes for admin exports
+const (
+	PIIModeFull   = "full"
+	PIIModeMasked = "masked"
+	PIIModeNone   = "none"
+)
+
+// ExportComments writes the matching comments to w as newline-delimited JSON,
+// applying the configured PII mode to author emails and IPs. One call returns
+// at most the configured maximum; req.AfterID continues from a previous call.
+func (u *CommentUsecase) ExportComments(ctx context.Context, req models.ExportCommentsRequest, w io.Writer) error {
+	if req.TenantID == "" {
+		return fmt.Errorf("tenant ID is required")
+	}
+
+	var after *models.Comment
+	if req.AfterID != "" {
+		afterID, err := primitive.ObjectIDFromHex(req.AfterID)
+		if err != nil {
+			return fmt.Errorf("invalid comment ID")
+		}
+		after, err = u.commentRepo.GetByID(ctx, afterID)
+		if err != nil {
+			return err
+		}
+		if after == nil || after.TenantID != req.TenantID {
+			return fmt.Errorf("comment not found")
+		}
+	}
+	req.Limit = streamLimit(req.Limit, u.cfg.Export.MaxComments)
+
+	mode := u.cfg.Export.PIIMode
+	encoder := json.NewEncoder(w)
+	written := 0
+
+	err := u.commentRepo.Stream(ctx, req, after, func(comment *models.Comment) error {
+		if err := encoder.Encode(exportComment(comment, mode)); err != nil {
+			return err
+		}
+		written++
+		if written%streamFlushEvery == 0 {
+			return flushStream(w)
+		}
+		return nil
+	})
+	if err == nil {
+		err = flushStream(w)
+	}
+	return err
+}
+
+// streamFlushEvery is how many streamed comments are buffered between flushes.
+// Flushing is also how a stream learns the client has gone away.
+const streamFlushEvery = 100
+
+// StreamComments writes every comment an admin listing matches to w as
+// newline-delimited JSON in the standard comment shape, up to limit comments
+// (capped by the configured maximum), and returns how many were written
+func (u *CommentUsecase) StreamComments(ctx context.Context, req models.ListCommentsRequest, limit int, w io.Writer) (int, error) {
+	if req.TenantID == "" {
+		return 0, fmt.Errorf("tenant ID is required")
+	}
+	if err := checkDateRange(req); err != nil {
+		return 0, err
+	}
+	if len(req.AuthorIDs) > 0 {
+		authorIDs, err := normalizeAuthorIDs(req.AuthorIDs)
+		if err != nil {
+			return 0, err
+		}
+		req.AuthorIDs = authorIDs
+	}
+
+	write, written := commentStreamWriter(w)
+	err := u.commentRepo.StreamList(ctx, req, streamLimit(limit, u.cfg.Export.StreamListMax), write)
+	if err == nil {
+		err = flushStream(w)
+	}
+	return *written, err
+}
+
+// streamLimit applies the configured maximum to a requested stream size; no
+// request, or one past the maximum, gets the maximum
+func streamLimit(requested, maxLimit int) int {
+	if requested <= 0 || requested > maxLimit {
+		return maxLimit
+	}
+	return requested
+}
+
+// commentStreamWriter returns a callback encoding each comment to w as one
+// JSON line, flushing every streamFlushEvery comments, and the running count
+func commentStreamWriter(w io.Writer) (func(*models.Comment) error, *int) {
+	encoder := json.NewEncoder(w)
+	written := 0
+
+	return func(comment *models.Comment) error {
+		setListFlags([]*models.Comment{comment})
+		if err := encoder.Encode(comment); err != nil {
+			return err
+		}
+		written++
+		if written%streamFlushEvery == 0 {
+			return flushStream(w)
+		}
+		return nil
+	}, &written
+}
+
+// flushStream pushes buffered output to the client when w buffers it
+func flushStream(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// exportComment converts a comment to its export form under the given PII mode.
+// Unknown modes are treated as masked.
+func exportComment(comment *models.Comment, piiMode string) models.ExportedComment {
+	exported := models.ExportedComment{
+		ID:           comment.ID.Hex(),
+		TenantID:     comment.TenantID,
+		ResourceType: comment.ResourceType,
+		ResourceID:   comment.ResourceID,
+		AuthorID:     comment.AuthorID,
+		AuthorName:   comment.AuthorName,
+		IsAnonymous:  comment.IsAnonymous,
+		Content:      comment.Content,
+		Status:       comment.Status,
+		IsDeleted:    comment.IsDeleted,
+		CreatedAt:    comment.CreatedAt,
+	}
+	if comment.ParentID != nil {
+		exported.ParentID = comment.ParentID.Hex()
+	}
+
+	switch piiMode {
+	case PIIModeFull:
+		exported.AuthorEmail = comment.AuthorEmail
+		exported.IPAddress = comment.IPAddress
+	case PIIModeNone:
+	default:
+		exported.AuthorEmail = maskEmail(comment.AuthorEmail)
+		exported.IPAddress = maskIP(comment.IPAddress)
+	}
+
+	return exported
+}
+
+// maskEmail keeps the first character of the local part and the domain,
+// e.g. john@example.com becomes j***@example.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		if email == "" {
+			return ""
+		}
+		return "***"
+	}
+	local := []rune(email[:at])
+	return string(local[0]) + "***" + email[at:]
+}
+
+// maskIP hides the host part of an address: the last IPv4 octet, or
+// everything after the first three IPv6 groups
+func maskIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		if ip == "" {
+			return ""
+		}
+		return "***"
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.***", v4[0], v4[1], v4[2])
+	}
+
+	groups := strings.SplitN(parsed.String(), ":", 4)
+	if len(groups) < 4 {
+		return "***"
+	}
+	return strings.Join(groups[:3], ":") + ":***"
+}
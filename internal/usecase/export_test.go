@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "j***@example.com", maskEmail("john@example.com"))
+	assert.Equal(t, "a***@example.com", maskEmail("a@example.com"))
+	assert.Equal(t, "ж***@пример.рф", maskEmail("жора@пример.рф"))
+	assert.Equal(t, "***", maskEmail("not-an-email"))
+	assert.Equal(t, "***", maskEmail("@example.com"))
+	assert.Equal(t, "", maskEmail(""))
+}
+
+func TestMaskIP(t *testing.T) {
+	assert.Equal(t, "192.168.1.***", maskIP("192.168.1.42"))
+	assert.Equal(t, "2001:db8:85a3:***", maskIP("2001:db8:85a3::8a2e:370:7334"))
+	assert.Equal(t, "***", maskIP("garbage"))
+	assert.Equal(t, "", maskIP(""))
+}
+
+func TestExportComment(t *testing.T) {
+	parentID := primitive.NewObjectID()
+	comment := &models.Comment{
+		ID:          primitive.NewObjectID(),
+		ParentID:    &parentID,
+		AuthorID:    "user-1",
+		AuthorName:  "John",
+		AuthorEmail: "john@example.com",
+		IPAddress:   "10.0.0.7",
+		Content:     "Nice",
+	}
+
+	full := exportComment(comment, PIIModeFull)
+	assert.Equal(t, "john@example.com", full.AuthorEmail)
+	assert.Equal(t, "10.0.0.7", full.IPAddress)
+	assert.Equal(t, parentID.Hex(), full.ParentID)
+
+	masked := exportComment(comment, PIIModeMasked)
+	assert.Equal(t, "j***@example.com", masked.AuthorEmail)
+	assert.Equal(t, "10.0.0.***", masked.IPAddress)
+
+	none := exportComment(comment, PIIModeNone)
+	assert.Empty(t, none.AuthorEmail)
+	assert.Empty(t, none.IPAddress)
+	assert.Equal(t, "Nice", none.Content)
+
+	unknown := exportComment(comment, "bogus")
+	assert.Equal(t, "j***@example.com", unknown.AuthorEmail, "unknown modes fall back to masking")
+}
+
+func TestCommentStreamWriter(t *testing.T) {
+	comments := make([]*models.Comment, 250)
+	for i := range comments {
+		comments[i] = &models.Comment{ID: primitive.NewObjectID(), Content: fmt.Sprintf("comment %d", i)}
+	}
+	comments[7].Attachments = []models.Attachment{{URL: "https://cdn.example.com/a.png"}}
+
+	t.Run("All Matching Comments", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		write, written := commentStreamWriter(w)
+		for _, comment := range comments {
+			require.NoError(t, write(comment))
+		}
+		require.NoError(t, flushStream(w))
+		assert.Equal(t, len(comments), *written)
+
+		decoder := json.NewDecoder(&buf)
+		var streamed []models.Comment
+		for decoder.More() {
+			var comment models.Comment
+			require.NoError(t, decoder.Decode(&comment))
+			streamed = append(streamed, comment)
+		}
+		require.Len(t, streamed, len(comments))
+		for i, comment := range streamed {
+			assert.Equal(t, comments[i].ID, comment.ID)
+			assert.Equal(t, comments[i].Content, comment.Content)
+		}
+		assert.True(t, streamed[7].HasAttachments, "list flags are set as in List")
+	})
+
+	t.Run("Client Gone", func(t *testing.T) {
+		// A disconnected client fails the first flush and stops the stream
+		w := bufio.NewWriterSize(failingWriter{}, 1<<20)
+		write, written := commentStreamWriter(w)
+
+		var err error
+		for _, comment := range comments {
+			if err = write(comment); err != nil {
+				break
+			}
+		}
+		assert.Error(t, err)
+		assert.Equal(t, streamFlushEvery, *written)
+	})
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestStreamLimit(t *testing.T) {
+	assert.Equal(t, 10000, streamLimit(0, 10000))
+	assert.Equal(t, 10000, streamLimit(-5, 10000))
+	assert.Equal(t, 500, streamLimit(500, 10000))
+	assert.Equal(t, 10000, streamLimit(50000, 10000))
+}
+
+func TestExportCommentsAfterID(t *testing.T) {
+	db := unreachableDB(t)
+	u := &CommentUsecase{
+		commentRepo: repository.NewCommentRepository(db),
+		cfg:         &config.Config{Export: config.ExportConfig{PIIMode: PIIModeMasked, MaxComments: 100}},
+	}
+
+	var buf bytes.Buffer
+	err := u.ExportComments(context.Background(), models.ExportCommentsRequest{TenantID: "t1", AfterID: "not-an-id"}, &buf)
+	assert.EqualError(t, err, "invalid comment ID")
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HelpfulVoteUsecase handles "was this helpful?" voting, independent of reactions
+type HelpfulVoteUsecase struct {
+	commentRepo *repository.CommentRepository
+	voteRepo    *repository.HelpfulVoteRepository
+	cache       Cache
+}
+
+// NewHelpfulVoteUsecase creates a new helpful vote usecase
+func NewHelpfulVoteUsecase(
+	commentRepo *repository.CommentRepository,
+	voteRepo *repository.HelpfulVoteRepository,
+	cache Cache,
+) *HelpfulVoteUsecase {
+	return &HelpfulVoteUsecase{
+		commentRepo: commentRepo,
+		voteRepo:    voteRepo,
+		cache:       cache,
+	}
+}
+
+// Vote adds or changes a user's helpfulness vote on a comment
+func (u *HelpfulVoteUsecase) Vote(ctx context.Context, commentID string, voteType models.VoteType, userID string) error {
+	if !isValidVoteType(voteType) {
+		return fmt.Errorf("invalid vote type")
+	}
+
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return err
+	}
+	if comment == nil {
+		return fmt.Errorf("comment not found")
+	}
+	if err := checkCanVote(comment, userID); err != nil {
+		return err
+	}
+
+	vote := &models.HelpfulVote{
+		CommentID: oid,
+		UserID:    userID,
+		Type:      voteType,
+	}
+	if err := u.voteRepo.Upsert(ctx, vote); err != nil {
+		return fmt.Errorf("failed to add vote: %w", err)
+	}
+
+	if err := u.updateHelpfulCounts(ctx, comment); err != nil {
+		log.Printf("Failed to update helpful counts: %v", err)
+	}
+
+	return nil
+}
+
+// Unvote removes a user's helpfulness vote from a comment
+func (u *HelpfulVoteUsecase) Unvote(ctx context.Context, commentID string, userID string) error {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return err
+	}
+	if comment == nil {
+		return fmt.Errorf("comment not found")
+	}
+
+	if err := u.voteRepo.Delete(ctx, userID, oid); err != nil {
+		return fmt.Errorf("failed to remove vote: %w", err)
+	}
+
+	if err := u.updateHelpfulCounts(ctx, comment); err != nil {
+		log.Printf("Failed to update helpful counts: %v", err)
+	}
+
+	return nil
+}
+
+// GetUserVote gets the current user's helpfulness vote on a comment
+func (u *HelpfulVoteUsecase) GetUserVote(ctx context.Context, commentID string, userID string) (*models.VoteType, error) {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	vote, err := u.voteRepo.GetByUserAndComment(ctx, userID, oid)
+	if err != nil {
+		return nil, err
+	}
+	if vote == nil {
+		return nil, nil
+	}
+
+	return &vote.Type, nil
+}
+
+// isValidVoteType checks if a vote type is valid
+func isValidVoteType(vt models.VoteType) bool {
+	return vt == models.VoteHelpful || vt == models.VoteNotHelpful
+}
+
+// checkCanVote rejects votes on deleted comments and on the voter's own comment
+func checkCanVote(comment *models.Comment, userID string) error {
+	if comment.IsDeleted {
+		return fmt.Errorf("cannot vote on deleted comment")
+	}
+	if comment.AuthorID == userID {
+		return fmt.Errorf("you cannot vote on your own comment")
+	}
+	return nil
+}
+
+// updateHelpfulCounts updates the helpfulness counts on a comment and drops
+// the cached reads that show them
+func (u *HelpfulVoteUsecase) updateHelpfulCounts(ctx context.Context, comment *models.Comment) error {
+	helpfulCount, notHelpfulCount, err := u.voteRepo.GetVoteCounts(ctx, comment.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.commentRepo.UpdateHelpfulCounts(ctx, comment.ID, helpfulCount, notHelpfulCount); err != nil {
+		return err
+	}
+	invalidateCache(ctx, u.cache, comment)
+	return nil
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/database"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestIsValidVoteType(t *testing.T) {
+	assert.True(t, isValidVoteType(models.VoteHelpful))
+	assert.True(t, isValidVoteType(models.VoteNotHelpful))
+	assert.False(t, isValidVoteType(models.VoteType("like")))
+	assert.False(t, isValidVoteType(""))
+}
+
+func TestCheckCanVote(t *testing.T) {
+	assert.NoError(t, checkCanVote(&models.Comment{AuthorID: "alice"}, "bob"))
+
+	// Reaction locks don't apply to helpfulness votes
+	assert.NoError(t, checkCanVote(&models.Comment{AuthorID: "alice", ReactionsLocked: true}, "bob"))
+
+	err := checkCanVote(&models.Comment{AuthorID: "alice", IsDeleted: true}, "bob")
+	assert.EqualError(t, err, "cannot vote on deleted comment")
+
+	err = checkCanVote(&models.Comment{AuthorID: "alice"}, "alice")
+	assert.EqualError(t, err, "you cannot vote on your own comment")
+}
+
+func TestListCommentsByHelpfulness(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("Most Helpful First", func(mt *mtest.T) {
+		db := &database.MongoDB{Client: mt.Client, Database: mt.DB}
+		u := &CommentUsecase{
+			commentRepo:  repository.NewCommentRepository(db),
+			settingsRepo: repository.NewSettingsRepository(db, config.ModerationConfig{}),
+			cfg:          &config.Config{},
+		}
+
+		ns := mt.DB.Name() + ".comments"
+		comment := func(helpful, notHelpful int) bson.D {
+			return bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "tenant_id", Value: "t1"},
+				{Key: "resource_type", Value: "review"},
+				{Key: "resource_id", Value: "r1"},
+				{Key: "status", Value: models.StatusApproved},
+				{Key: "helpful_count", Value: helpful},
+				{Key: "not_helpful_count", Value: notHelpful},
+			}
+		}
+		mt.AddMockResponses(
+			// Count, then the page in the order MongoDB sorted it, then the
+			// settings lookup for the replies flag
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: int32(3)}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, comment(5, 0), comment(5, 2), comment(1, 0)),
+			mtest.CreateCursorResponse(0, mt.DB.Name()+".settings", mtest.FirstBatch),
+		)
+
+		resp, err := u.ListComments(context.Background(), models.ListCommentsRequest{
+			TenantID:     "t1",
+			ResourceType: "review",
+			ResourceID:   "r1",
+			SortBy:       "helpful_count",
+			SortOrder:    "desc",
+		}, "", false)
+		require.NoError(t, err)
+
+		var find bson.Raw
+		for _, started := range mt.GetAllStartedEvents() {
+			if started.CommandName == "find" && started.Command.Lookup("find").StringValue() == "comments" {
+				find = started.Command
+			}
+		}
+		require.NotNil(t, find, "the listing queried the comments collection")
+
+		var sort bson.D
+		require.NoError(t, find.Lookup("sort").Unmarshal(&sort))
+		keys := make([]string, len(sort))
+		for i, e := range sort {
+			keys[i] = e.Key
+		}
+		assert.Equal(t, []string{"sort_weight", "is_pinned", "helpful_count", "not_helpful_count", "_id"}, keys)
+		assert.EqualValues(t, -1, sort[2].Value, "most helpful first")
+		assert.EqualValues(t, 1, sort[3].Value, "ties go to fewer not-helpful votes")
+
+		assert.Equal(t, "approved", find.Lookup("filter", "status").StringValue(), "anonymous callers only see approved comments")
+
+		assert.EqualValues(t, 3, resp.Total)
+		require.Len(t, resp.Comments, 3)
+		assert.Equal(t, []int{5, 5, 1}, []int{resp.Comments[0].HelpfulCount, resp.Comments[1].HelpfulCount, resp.Comments[2].HelpfulCount})
+		assert.Equal(t, 2, resp.Comments[1].NotHelpfulCount)
+		assert.False(t, resp.RepliesDisabled)
+	})
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+)
+
+// KillSwitchUsecase handles the switches operators use to stop comment creation
+type KillSwitchUsecase struct {
+	killSwitchRepo *repository.KillSwitchRepository
+	operatorIDs    []string
+}
+
+// NewKillSwitchUsecase creates a new kill switch usecase. Only operatorIDs
+// may flip the global switch.
+func NewKillSwitchUsecase(killSwitchRepo *repository.KillSwitchRepository, operatorIDs []string) *KillSwitchUsecase {
+	return &KillSwitchUsecase{
+		killSwitchRepo: killSwitchRepo,
+		operatorIDs:    operatorIDs,
+	}
+}
+
+// GetKillSwitches retrieves the switches that apply to a tenant
+func (u *KillSwitchUsecase) GetKillSwitches(ctx context.Context, tenantID string) ([]*models.KillSwitch, error) {
+	switches, err := u.killSwitchRepo.GetForTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if switches == nil {
+		switches = []*models.KillSwitch{}
+	}
+	return switches, nil
+}
+
+// SetTenantKillSwitch turns comment creation off or back on for one tenant
+func (u *KillSwitchUsecase) SetTenantKillSwitch(ctx context.Context, tenantID string, req models.KillSwitchRequest, userID string) (*models.KillSwitch, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID is required")
+	}
+	return u.killSwitchRepo.Set(ctx, models.KillSwitchTenant, tenantID, req.Enabled, req.Reason, userID)
+}
+
+// SetGlobalKillSwitch turns comment creation off or back on for every tenant
+func (u *KillSwitchUsecase) SetGlobalKillSwitch(ctx context.Context, req models.KillSwitchRequest, userID string) (*models.KillSwitch, error) {
+	if err := checkOperator(userID, u.operatorIDs); err != nil {
+		return nil, err
+	}
+	return u.killSwitchRepo.Set(ctx, models.KillSwitchGlobal, "", req.Enabled, req.Reason, userID)
+}
+
+// checkOperator rejects callers not on the operator list
+func checkOperator(userID string, operatorIDs []string) error {
+	if userID == "" || !slices.Contains(operatorIDs, userID) {
+		return fmt.Errorf("only operators can change the global kill switch")
+	}
+	return nil
+}
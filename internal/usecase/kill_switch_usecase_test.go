@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeKillSwitches reports commenting disabled for the listed tenants, or
+// for everyone when global is set
+type fakeKillSwitches struct {
+	global  bool
+	tenants map[string]bool
+	err     error
+}
+
+func (f *fakeKillSwitches) CommentingDisabled(_ context.Context, tenantID string) (bool, error) {
+	return f.global || f.tenants[tenantID], f.err
+}
+
+func TestCreateCommentKillSwitch(t *testing.T) {
+	req := models.CreateCommentRequest{TenantID: "shop", ResourceType: "product", ResourceID: "123", Content: "Hello"}
+
+	t.Run("Tenant", func(t *testing.T) {
+		u := &CommentUsecase{killSwitches: &fakeKillSwitches{tenants: map[string]bool{"shop": true}}}
+
+		_, err := u.CreateComment(context.Background(), req, "alice", "Alice", "", "", "", "", false, false)
+		assert.EqualError(t, err, "commenting temporarily disabled")
+	})
+
+	t.Run("Global", func(t *testing.T) {
+		u := &CommentUsecase{killSwitches: &fakeKillSwitches{global: true}}
+
+		_, err := u.CreateComment(context.Background(), req, "alice", "Alice", "", "", "", "", false, false)
+		assert.EqualError(t, err, "commenting temporarily disabled")
+	})
+
+	t.Run("Reply Into Switched-Off Tenant", func(t *testing.T) {
+		// The reply names an open tenant but is written to its parent's, in
+		// the order CreateComment resolves them
+		switches := &fakeKillSwitches{tenants: map[string]bool{"blog": true}}
+		reply := req
+		reply.ParentID = primitive.NewObjectID().Hex()
+		parent := &models.Comment{TenantID: "blog", ResourceType: "article", ResourceID: "a1"}
+
+		require.NoError(t, checkKillSwitch(context.Background(), switches, reply.TenantID))
+		inheritParentResource(&reply, parent)
+		assert.EqualError(t, checkKillSwitch(context.Background(), switches, reply.TenantID), "commenting temporarily disabled")
+	})
+
+	t.Run("Other Tenant", func(t *testing.T) {
+		assert.NoError(t, checkKillSwitch(context.Background(), &fakeKillSwitches{tenants: map[string]bool{"blog": true}}, "shop"))
+	})
+
+	t.Run("Lookup Failure", func(t *testing.T) {
+		assert.NoError(t, checkKillSwitch(context.Background(), &fakeKillSwitches{global: true, err: errors.New("timeout")}, "shop"), "fails open")
+		assert.NoError(t, checkKillSwitch(context.Background(), nil, "shop"))
+	})
+}
+
+func TestCheckOperator(t *testing.T) {
+	operators := []string{"ops-1"}
+
+	assert.NoError(t, checkOperator("ops-1", operators))
+	assert.EqualError(t, checkOperator("tenant-admin", operators), "only operators can change the global kill switch")
+	assert.Error(t, checkOperator("", []string{""}))
+	assert.Error(t, checkOperator("ops-1", nil), "no operators configured")
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/minisource/comment/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// AutoCloseModerator is recorded as the moderator of auto-closed comments
+	AutoCloseModerator = "system"
+	// AutoCloseRejectionReason is the reason given to authors of auto-rejected comments
+	AutoCloseRejectionReason = "Not reviewed in time"
+
+	// pendingSweepBatchSize caps how many comments one policy closes per sweep
+	pendingSweepBatchSize = 500
+)
+
+// RunPendingSweep closes stale pending comments every interval until ctx is done
+func (u *CommentUsecase) RunPendingSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			closed, err := u.ClosePendingComments(ctx)
+			if err != nil {
+				log.Printf("Pending comment sweep failed: %v", err)
+			}
+			if closed > 0 {
+				log.Printf("Pending comment sweep closed %d comments", closed)
+			}
+		}
+	}
+}
+
+// ClosePendingComments applies each tenant's auto-close policy to pending
+// comments older than its configured age. Comments with unresolved reports
+// are left for a moderator.
+func (u *CommentUsecase) ClosePendingComments(ctx context.Context) (int, error) {
+	policies, err := u.settingsRepo.GetWithPendingAutoClose(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get auto-close settings: %w", err)
+	}
+
+	closed := 0
+	now := models.Now()
+	for _, settings := range policies {
+		n, err := u.closeStalePending(ctx, settings, now)
+		closed += n
+		if err != nil {
+			return closed, err
+		}
+	}
+
+	return closed, nil
+}
+
+// closeStalePending closes up to pendingSweepBatchSize of one policy's stale
+// pending comments. Reported comments stay pending, so it pages past them
+// rather than fetching the same oldest ones every sweep.
+func (u *CommentUsecase) closeStalePending(ctx context.Context, settings *models.CommentSettings, now time.Time) (int, error) {
+	before := now.Add(-time.Duration(settings.PendingAutoCloseHours) * time.Hour)
+
+	closed := 0
+	var (
+		after         *models.Comment
+		notifications []NotificationRequest
+	)
+	defer func() { go u.sendNotifications(notifications) }()
+
+	for closed < pendingSweepBatchSize {
+		comments, err := u.commentRepo.GetStalePending(ctx, settings.TenantID, settings.ResourceType, before, after, pendingSweepBatchSize)
+		if err != nil {
+			return closed, fmt.Errorf("failed to get stale pending comments: %w", err)
+		}
+		if len(comments) == 0 {
+			break
+		}
+		after = comments[len(comments)-1]
+
+		ids := make([]primitive.ObjectID, len(comments))
+		for i, comment := range comments {
+			ids[i] = comment.ID
+		}
+		reported, err := u.reportRepo.GetCommentsWithPendingReports(ctx, ids)
+		if err != nil {
+			return closed, fmt.Errorf("failed to check reports: %w", err)
+		}
+
+		for _, comment := range comments {
+			if closed == pendingSweepBatchSize {
+				break
+			}
+			if reported[comment.ID] || !autoClosePending(comment, settings, now) {
+				continue
+			}
+			if err := u.commentRepo.Update(ctx, comment); err != nil {
+				return closed, fmt.Errorf("failed to auto-close comment: %w", err)
+			}
+			closed++
+			u.audit(ctx, AuditCommentModerated, comment, AutoCloseModerator)
+			invalidateCache(ctx, u.cache, comment)
+			dispatchWebhook(u.webhooks, u.settingsRepo.GetEffective, u.cfg.Notifier.MetadataKeys, WebhookCommentModerated, comment)
+			notifications = append(notifications, u.moderatedNotifications(comment, false)...)
+		}
+
+		if len(comments) < pendingSweepBatchSize {
+			break
+		}
+	}
+
+	return closed, nil
+}
+
+// autoClosePending resolves a pending comment that has waited longer than
+// the settings allow, approving or rejecting it per the policy. Comments
+// still waiting for a second approval count as pending; the policy closes
+// them like any other. It reports whether the comment was changed.
+func autoClosePending(comment *models.Comment, settings *models.CommentSettings, now time.Time) bool {
+	if settings.PendingAutoCloseHours <= 0 || !isPending(comment.Status) {
+		return false
+	}
+	if now.Sub(comment.CreatedAt) < time.Duration(settings.PendingAutoCloseHours)*time.Hour {
+		return false
+	}
+
+	comment.ModeratedBy = AutoCloseModerator
+	comment.ModeratedAt = &now
+	if settings.PendingAutoCloseAction == "approve" {
+		comment.Status = models.StatusApproved
+		comment.EditsSinceApproval = 0
+	} else {
+		comment.Status = models.StatusRejected
+		comment.RejectionReason = AutoCloseRejectionReason
+		comment.ModerationApprovals = nil
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAutoClosePending(t *testing.T) {
+	now := time.Now()
+	aged := func() *models.Comment {
+		return &models.Comment{Status: models.StatusPending, CreatedAt: now.Add(-49 * time.Hour)}
+	}
+
+	t.Run("Auto Approve", func(t *testing.T) {
+		comment := aged()
+		settings := &models.CommentSettings{PendingAutoCloseHours: 48, PendingAutoCloseAction: "approve"}
+
+		assert.True(t, autoClosePending(comment, settings, now))
+		assert.Equal(t, models.StatusApproved, comment.Status)
+		assert.Equal(t, AutoCloseModerator, comment.ModeratedBy)
+		assert.Empty(t, comment.RejectionReason)
+	})
+
+	t.Run("Auto Reject", func(t *testing.T) {
+		comment := aged()
+		settings := &models.CommentSettings{PendingAutoCloseHours: 48, PendingAutoCloseAction: "reject"}
+
+		assert.True(t, autoClosePending(comment, settings, now))
+		assert.Equal(t, models.StatusRejected, comment.Status)
+		assert.Equal(t, AutoCloseRejectionReason, comment.RejectionReason)
+		assert.NotNil(t, comment.ModeratedAt)
+	})
+
+	t.Run("Awaiting Second Approval", func(t *testing.T) {
+		comment := aged()
+		comment.Status = models.StatusPendingSecondApproval
+		comment.ModerationApprovals = []string{"mod-1"}
+		settings := &models.CommentSettings{PendingAutoCloseHours: 48, PendingAutoCloseAction: "reject"}
+
+		assert.True(t, autoClosePending(comment, settings, now))
+		assert.Equal(t, models.StatusRejected, comment.Status)
+		assert.Empty(t, comment.ModerationApprovals)
+	})
+
+	t.Run("Not Yet Stale", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusPending, CreatedAt: now.Add(-time.Hour)}
+		settings := &models.CommentSettings{PendingAutoCloseHours: 48, PendingAutoCloseAction: "approve"}
+
+		assert.False(t, autoClosePending(comment, settings, now))
+		assert.Equal(t, models.StatusPending, comment.Status)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		comment := aged()
+		assert.False(t, autoClosePending(comment, &models.CommentSettings{}, now))
+		assert.Equal(t, models.StatusPending, comment.Status)
+	})
+}
+
+func TestAutoApprovalNotifications(t *testing.T) {
+	u := &CommentUsecase{cfg: &config.Config{}}
+	comment := &models.Comment{
+		ID:        primitive.NewObjectID(),
+		AuthorID:  "alice",
+		Status:    models.StatusPending,
+		Mentions:  []string{"carol"},
+		CreatedAt: time.Now().Add(-49 * time.Hour),
+	}
+	settings := &models.CommentSettings{PendingAutoCloseHours: 48, PendingAutoCloseAction: "approve"}
+
+	// What the sweep sends for a comment it closes
+	require.True(t, autoClosePending(comment, settings, time.Now()))
+	notifications := u.moderatedNotifications(comment, false)
+
+	require.Len(t, notifications, 2)
+	assert.Equal(t, "comment.moderated", notifications[0].Type)
+	assert.Equal(t, []string{"alice"}, notifications[0].Recipients)
+	assert.Equal(t, "comment.mention", notifications[1].Type, "mentioned users hear once it becomes visible")
+	assert.Equal(t, []string{"carol"}, notifications[1].Recipients)
+
+	// Auto-rejected comments never become visible
+	comment.Status = models.StatusPending
+	settings.PendingAutoCloseAction = "reject"
+	require.True(t, autoClosePending(comment, settings, time.Now()))
+	notifications = u.moderatedNotifications(comment, false)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "comment.moderated", notifications[0].Type)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/minisource/comment/internal/models"
 	"github.com/minisource/comment/internal/repository"
@@ -14,38 +17,60 @@ import (
 type ReactionUsecase struct {
 	commentRepo  *repository.CommentRepository
 	reactionRepo *repository.ReactionRepository
+	settingsRepo *repository.SettingsRepository
+	cache        Cache
+	webhooks     WebhookDispatcher
+	// stale collects comments whose stored counts await a background
+	// refresh; nil when counts are updated on every reaction
+	stale *staleCountSet
 }
 
-// NewReactionUsecase creates a new reaction usecase
+// NewReactionUsecase creates a new reaction usecase. A non-zero
+// refreshInterval defers count updates on comments to a background refresher.
 func NewReactionUsecase(
 	commentRepo *repository.CommentRepository,
 	reactionRepo *repository.ReactionRepository,
+	settingsRepo *repository.SettingsRepository,
+	cache Cache,
+	webhooks WebhookDispatcher,
+	refreshInterval time.Duration,
 ) *ReactionUsecase {
-	return &ReactionUsecase{
+	u := &ReactionUsecase{
 		commentRepo:  commentRepo,
 		reactionRepo: reactionRepo,
+		settingsRepo: settingsRepo,
+		cache:        cache,
+		webhooks:     webhooks,
 	}
+	if refreshInterval > 0 {
+		u.stale = newStaleCountSet()
+		go runCountRefresher(context.Background(), refreshInterval, u.stale, u.refreshReactionCounts)
+	}
+	return u
 }
 
-// AddReaction adds or updates a reaction to a comment
-func (u *ReactionUsecase) AddReaction(ctx context.Context, commentID string, reactionType models.ReactionType, userID string) error {
+// AddReaction adds or updates a reaction to a comment and returns the new counts
+func (u *ReactionUsecase) AddReaction(ctx context.Context, commentID string, reactionType models.ReactionType, userID string) (*models.ReactionSummary, error) {
 	oid, err := primitive.ObjectIDFromHex(commentID)
 	if err != nil {
-		return fmt.Errorf("invalid comment ID")
+		return nil, fmt.Errorf("invalid comment ID")
 	}
 
 	// Check if comment exists
 	comment, err := u.commentRepo.GetByID(ctx, oid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if comment == nil {
-		return fmt.Errorf("comment not found")
+		return nil, fmt.Errorf("comment not found")
 	}
 
-	// Cannot react to deleted comments
-	if comment.IsDeleted {
-		return fmt.Errorf("cannot react to deleted comment")
+	if err := checkCanReact(comment); err != nil {
+		return nil, err
+	}
+
+	if err := u.checkReactionAllowed(ctx, comment, reactionType, userID); err != nil {
+		return nil, err
 	}
 
 	// Upsert reaction
@@ -56,34 +81,126 @@ func (u *ReactionUsecase) AddReaction(ctx context.Context, commentID string, rea
 	}
 
 	if err := u.reactionRepo.Upsert(ctx, reaction); err != nil {
-		return fmt.Errorf("failed to add reaction: %w", err)
+		return nil, fmt.Errorf("failed to add reaction: %w", err)
 	}
+	u.dispatchReactionAdded(comment, userID, reactionType)
 
 	// Update reaction counts
-	if err := u.updateReactionCounts(ctx, oid); err != nil {
-		log.Printf("Failed to update reaction counts: %v", err)
+	summary, err := u.reactionCounts(ctx, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reaction counts: %w", err)
 	}
+	summary.UserReaction = &reactionType
 
-	return nil
+	return summary, nil
 }
 
-// RemoveReaction removes a reaction from a comment
-func (u *ReactionUsecase) RemoveReaction(ctx context.Context, commentID string, userID string) error {
+// RemoveReaction removes a reaction from a comment and returns the new counts
+func (u *ReactionUsecase) RemoveReaction(ctx context.Context, commentID string, userID string) (*models.ReactionSummary, error) {
 	oid, err := primitive.ObjectIDFromHex(commentID)
 	if err != nil {
-		return fmt.Errorf("invalid comment ID")
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if comment.ReactionsLocked {
+		return nil, fmt.Errorf("reactions are locked on this comment")
 	}
 
 	if err := u.reactionRepo.Delete(ctx, userID, oid); err != nil {
-		return fmt.Errorf("failed to remove reaction: %w", err)
+		return nil, fmt.Errorf("failed to remove reaction: %w", err)
 	}
 
 	// Update reaction counts
-	if err := u.updateReactionCounts(ctx, oid); err != nil {
-		log.Printf("Failed to update reaction counts: %v", err)
+	summary, err := u.reactionCounts(ctx, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reaction counts: %w", err)
 	}
 
-	return nil
+	return summary, nil
+}
+
+// ToggleReaction removes the user's reaction if it is already reactionType,
+// and otherwise adds it or switches to it. It returns the new counts with
+// the user's resulting reaction.
+func (u *ReactionUsecase) ToggleReaction(ctx context.Context, commentID string, reactionType models.ReactionType, userID string) (*models.ReactionSummary, error) {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	if err := checkCanReact(comment); err != nil {
+		return nil, err
+	}
+
+	existing, err := u.reactionRepo.GetByUserAndComment(ctx, userID, oid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reaction: %w", err)
+	}
+
+	result := toggledReaction(existing, reactionType)
+	if result == nil {
+		if err := u.reactionRepo.Delete(ctx, userID, oid); err != nil {
+			return nil, fmt.Errorf("failed to remove reaction: %w", err)
+		}
+	} else {
+		if err := u.checkReactionAllowed(ctx, comment, *result, userID); err != nil {
+			return nil, err
+		}
+		reaction := &models.Reaction{
+			CommentID: oid,
+			UserID:    userID,
+			Type:      *result,
+		}
+		if err := u.reactionRepo.Upsert(ctx, reaction); err != nil {
+			return nil, fmt.Errorf("failed to add reaction: %w", err)
+		}
+		u.dispatchReactionAdded(comment, userID, *result)
+	}
+
+	// Update reaction counts
+	summary, err := u.reactionCounts(ctx, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reaction counts: %w", err)
+	}
+	summary.UserReaction = result
+
+	return summary, nil
+}
+
+// dispatchReactionAdded sends the reaction.added webhook
+func (u *ReactionUsecase) dispatchReactionAdded(comment *models.Comment, userID string, reactionType models.ReactionType) {
+	sendWebhook(u.webhooks, u.settingsRepo.GetEffective, WebhookReactionAdded, comment.TenantID, comment.ResourceType, ReactionWebhookData{
+		CommentID:    comment.ID.Hex(),
+		TenantID:     comment.TenantID,
+		ResourceType: comment.ResourceType,
+		ResourceID:   comment.ResourceID,
+		UserID:       userID,
+		Type:         reactionType,
+	})
+}
+
+// toggledReaction returns the user's reaction after toggling requested: nil
+// when it undoes the same reaction, requested otherwise
+func toggledReaction(existing *models.Reaction, requested models.ReactionType) *models.ReactionType {
+	if existing != nil && existing.Type == requested {
+		return nil
+	}
+	return &requested
 }
 
 // GetUserReaction gets the current user's reaction to a comment
@@ -104,8 +221,16 @@ func (u *ReactionUsecase) GetUserReaction(ctx context.Context, commentID string,
 	return &reaction.Type, nil
 }
 
-// GetUserReactionsForComments gets user reactions for multiple comments
+// MaxReactionLookupIDs caps how many comments one batch reaction lookup may cover
+const MaxReactionLookupIDs = 200
+
+// GetUserReactionsForComments gets user reactions for multiple comments. Every
+// valid ID is in the result, with nil where the user hasn't reacted.
 func (u *ReactionUsecase) GetUserReactionsForComments(ctx context.Context, commentIDs []string, userID string) (map[string]*models.ReactionType, error) {
+	if len(commentIDs) > MaxReactionLookupIDs {
+		return nil, fmt.Errorf("at most %d comment IDs can be looked up at once", MaxReactionLookupIDs)
+	}
+
 	oids := make([]primitive.ObjectID, 0, len(commentIDs))
 	for _, id := range commentIDs {
 		oid, err := primitive.ObjectIDFromHex(id)
@@ -120,20 +245,190 @@ func (u *ReactionUsecase) GetUserReactionsForComments(ctx context.Context, comme
 		return nil, err
 	}
 
-	result := make(map[string]*models.ReactionType)
-	for oid, reaction := range reactions {
-		result[oid.Hex()] = reaction
+	return userReactionsByID(oids, reactions), nil
+}
+
+// userReactionsByID keys the user's reactions by comment ID, including a nil
+// entry for each comment without one
+func userReactionsByID(oids []primitive.ObjectID, reactions map[primitive.ObjectID]*models.ReactionType) map[string]*models.ReactionType {
+	result := make(map[string]*models.ReactionType, len(oids))
+	for _, oid := range oids {
+		result[oid.Hex()] = reactions[oid]
+	}
+	return result
+}
+
+// checkCanReact rejects reactions on deleted or reaction-locked comments
+func checkCanReact(comment *models.Comment) error {
+	if comment.IsDeleted {
+		return fmt.Errorf("cannot react to deleted comment")
+	}
+	if comment.ReactionsLocked {
+		return fmt.Errorf("reactions are locked on this comment")
+	}
+	return nil
+}
+
+// checkReactionAllowed applies the resource's reaction settings to a new reaction
+func (u *ReactionUsecase) checkReactionAllowed(ctx context.Context, comment *models.Comment, reactionType models.ReactionType, userID string) error {
+	settings, err := u.settingsRepo.GetOrCreate(ctx, comment.TenantID, comment.ResourceType)
+	if err != nil {
+		return fmt.Errorf("failed to get settings: %w", err)
+	}
+	if err := checkReactionSettings(settings, reactionType); err != nil {
+		return err
+	}
+	return checkSelfReaction(settings, comment, userID)
+}
+
+// checkSelfReaction rejects authors reacting to their own comment when the
+// settings don't allow it
+func checkSelfReaction(settings *models.CommentSettings, comment *models.Comment, userID string) error {
+	if !settings.AllowSelfReaction && userID != "" && userID == comment.AuthorID {
+		return fmt.Errorf("you can't react to your own comment")
+	}
+	return nil
+}
+
+// selfReactionExclusion returns the user whose reactions are left out of a
+// comment's counts: its author when self-reactions aren't allowed, so ones
+// added before the setting changed stop counting
+func selfReactionExclusion(settings *models.CommentSettings, comment *models.Comment) string {
+	if settings.AllowSelfReaction {
+		return ""
+	}
+	return comment.AuthorID
+}
+
+// countedReactions loads a comment's reaction counts under its settings
+func countedReactions(ctx context.Context, reactionRepo *repository.ReactionRepository, settingsRepo *repository.SettingsRepository, comment *models.Comment) (map[string]int, int, int, error) {
+	settings, err := settingsRepo.GetOrCreate(ctx, comment.TenantID, comment.ResourceType)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return reactionRepo.GetReactionCounts(ctx, comment.ID, selfReactionExclusion(settings, comment))
+}
+
+// checkReactionSettings rejects reactions when they are disabled or the type
+// isn't one of AllowedReactions. An empty list, as on settings saved before
+// the field existed, allows every type.
+func checkReactionSettings(settings *models.CommentSettings, reactionType models.ReactionType) error {
+	if !settings.AllowReactions {
+		return fmt.Errorf("reactions are disabled for this resource")
+	}
+	if len(settings.AllowedReactions) > 0 && !slices.Contains(settings.AllowedReactions, reactionType) {
+		return fmt.Errorf("reaction type %s is not allowed for this resource", reactionType)
+	}
+	return nil
+}
+
+// reactionCounts recomputes a comment's reaction counts after a change. The
+// counts stored on the comment are updated now, or by the background
+// refresher when one is running.
+func (u *ReactionUsecase) reactionCounts(ctx context.Context, comment *models.Comment) (*models.ReactionSummary, error) {
+	counts, likeCount, dislikeCount, err := countedReactions(ctx, u.reactionRepo, u.settingsRepo, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.stale != nil {
+		u.stale.add(comment.ID)
+	} else if err := u.commentRepo.UpdateReactionCounts(ctx, comment.ID, likeCount, dislikeCount, counts); err != nil {
+		return nil, err
+	} else {
+		invalidateCache(ctx, u.cache, comment)
 	}
 
-	return result, nil
+	return newReactionSummary(comment.ID, counts, likeCount, dislikeCount), nil
 }
 
-// updateReactionCounts updates the reaction counts on a comment
-func (u *ReactionUsecase) updateReactionCounts(ctx context.Context, commentID primitive.ObjectID) error {
-	counts, likeCount, dislikeCount, err := u.reactionRepo.GetReactionCounts(ctx, commentID)
+// refreshReactionCounts stores a comment's current reaction counts. Only the
+// comment's own cache entry is dropped; cached listings already trail
+// deferred counts and catch up when they expire.
+func (u *ReactionUsecase) refreshReactionCounts(ctx context.Context, commentID primitive.ObjectID) error {
+	comment, err := u.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment == nil {
+		return nil
+	}
+
+	counts, likeCount, dislikeCount, err := countedReactions(ctx, u.reactionRepo, u.settingsRepo, comment)
 	if err != nil {
 		return err
 	}
+	if err := u.commentRepo.UpdateReactionCounts(ctx, commentID, likeCount, dislikeCount, counts); err != nil {
+		return err
+	}
+	if u.cache != nil {
+		u.cache.Delete(ctx, commentCacheKey(commentID.Hex()))
+	}
+	return nil
+}
+
+// staleCountSet tracks comments with reaction activity since their counts
+// were last stored
+type staleCountSet struct {
+	mu  sync.Mutex
+	ids map[primitive.ObjectID]struct{}
+}
+
+func newStaleCountSet() *staleCountSet {
+	return &staleCountSet{ids: make(map[primitive.ObjectID]struct{})}
+}
+
+// add marks a comment's counts as stale
+func (s *staleCountSet) add(id primitive.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[id] = struct{}{}
+}
+
+// drain returns the stale comments and clears the set
+func (s *staleCountSet) drain() []primitive.ObjectID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]primitive.ObjectID, 0, len(s.ids))
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	s.ids = make(map[primitive.ObjectID]struct{})
+	return ids
+}
+
+// runCountRefresher refreshes stale comments every interval until ctx is done.
+// Comments that fail to refresh are retried on the next tick.
+func runCountRefresher(ctx context.Context, interval time.Duration, stale *staleCountSet, refresh func(context.Context, primitive.ObjectID) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, id := range stale.drain() {
+				if err := refresh(ctx, id); err != nil {
+					log.Printf("Failed to refresh reaction counts for %s: %v", id.Hex(), err)
+					stale.add(id)
+				}
+			}
+		}
+	}
+}
 
-	return u.commentRepo.UpdateReactionCounts(ctx, commentID, likeCount, dislikeCount, counts)
+// newReactionSummary builds the response for a reaction change. The caller's
+// own reaction is left unset for the caller to fill in.
+func newReactionSummary(commentID primitive.ObjectID, counts map[string]int, likeCount, dislikeCount int) *models.ReactionSummary {
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	return &models.ReactionSummary{
+		CommentID:      commentID.Hex(),
+		LikeCount:      likeCount,
+		DislikeCount:   dislikeCount,
+		ReactionCounts: counts,
+	}
 }
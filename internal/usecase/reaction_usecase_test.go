@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckCanReact(t *testing.T) {
+	assert.NoError(t, checkCanReact(&models.Comment{}))
+
+	err := checkCanReact(&models.Comment{ReactionsLocked: true})
+	assert.EqualError(t, err, "reactions are locked on this comment")
+
+	err = checkCanReact(&models.Comment{IsDeleted: true})
+	assert.EqualError(t, err, "cannot react to deleted comment")
+}
+
+func TestNewReactionSummary(t *testing.T) {
+	commentID := primitive.NewObjectID()
+
+	// Counts as recomputed after a user switched a like to love
+	summary := newReactionSummary(commentID, map[string]int{"like": 2, "love": 1}, 2, 0)
+	love := models.ReactionLove
+	summary.UserReaction = &love
+
+	assert.Equal(t, commentID.Hex(), summary.CommentID)
+	assert.Equal(t, 2, summary.LikeCount)
+	assert.Equal(t, 0, summary.DislikeCount)
+	assert.Equal(t, map[string]int{"like": 2, "love": 1}, summary.ReactionCounts)
+	assert.Equal(t, models.ReactionLove, *summary.UserReaction)
+
+	// Last reaction removed
+	summary = newReactionSummary(commentID, nil, 0, 0)
+	assert.NotNil(t, summary.ReactionCounts, "empty counts serialize as {} rather than null")
+	assert.Empty(t, summary.ReactionCounts)
+	assert.Nil(t, summary.UserReaction)
+}
+
+func TestCountRefresherConverges(t *testing.T) {
+	var mu sync.Mutex
+	live := map[primitive.ObjectID]int{}   // counts in the reactions collection
+	stored := map[primitive.ObjectID]int{} // denormalized counts on comments
+
+	refresh := func(_ context.Context, id primitive.ObjectID) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stored[id] = live[id]
+		return nil
+	}
+
+	stale := newStaleCountSet()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runCountRefresher(ctx, 10*time.Millisecond, stale, refresh)
+
+	hot, quiet := primitive.NewObjectID(), primitive.NewObjectID()
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		live[hot]++
+		mu.Unlock()
+		stale.add(hot)
+	}
+	mu.Lock()
+	live[quiet] = 1
+	mu.Unlock()
+	stale.add(quiet)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored[hot] == 100 && stored[quiet] == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, stale.drain())
+}
+
+func TestToggledReaction(t *testing.T) {
+	// No reaction yet: add it
+	got := toggledReaction(nil, models.ReactionLike)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, models.ReactionLike, *got)
+	}
+
+	// Same reaction: remove it
+	assert.Nil(t, toggledReaction(&models.Reaction{Type: models.ReactionLike}, models.ReactionLike))
+
+	// Different reaction: switch to it
+	got = toggledReaction(&models.Reaction{Type: models.ReactionLike}, models.ReactionLove)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, models.ReactionLove, *got)
+	}
+}
+
+func TestCheckReactionSettings(t *testing.T) {
+	// A tenant that only allows likes and loves
+	settings := &models.CommentSettings{
+		AllowReactions:   true,
+		AllowedReactions: []models.ReactionType{models.ReactionLike, models.ReactionLove},
+	}
+	assert.NoError(t, checkReactionSettings(settings, models.ReactionLike))
+	assert.NoError(t, checkReactionSettings(settings, models.ReactionLove))
+	assert.EqualError(t, checkReactionSettings(settings, models.ReactionAngry), "reaction type angry is not allowed for this resource")
+
+	settings.AllowReactions = false
+	assert.EqualError(t, checkReactionSettings(settings, models.ReactionLike), "reactions are disabled for this resource")
+
+	// No list restricts nothing
+	assert.NoError(t, checkReactionSettings(&models.CommentSettings{AllowReactions: true}, models.ReactionWow))
+}
+
+func TestSelfReaction(t *testing.T) {
+	comment := &models.Comment{AuthorID: "alice"}
+
+	t.Run("Allowed", func(t *testing.T) {
+		settings := &models.CommentSettings{AllowReactions: true, AllowSelfReaction: true}
+		assert.NoError(t, checkSelfReaction(settings, comment, "alice"))
+		assert.Empty(t, selfReactionExclusion(settings, comment))
+	})
+
+	t.Run("Not Allowed", func(t *testing.T) {
+		settings := &models.CommentSettings{AllowReactions: true}
+		assert.EqualError(t, checkSelfReaction(settings, comment, "alice"), "you can't react to your own comment")
+		assert.NoError(t, checkSelfReaction(settings, comment, "bob"), "others still can")
+		assert.Equal(t, "alice", selfReactionExclusion(settings, comment), "earlier self-reactions stop counting")
+	})
+}
+
+func TestUserReactionsByID(t *testing.T) {
+	liked, unreacted := primitive.NewObjectID(), primitive.NewObjectID()
+	like := models.ReactionLike
+
+	result := userReactionsByID([]primitive.ObjectID{liked, unreacted}, map[primitive.ObjectID]*models.ReactionType{liked: &like})
+
+	assert.Len(t, result, 2)
+	if assert.NotNil(t, result[liked.Hex()]) {
+		assert.Equal(t, models.ReactionLike, *result[liked.Hex()])
+	}
+	v, ok := result[unreacted.Hex()]
+	assert.True(t, ok)
+	assert.Nil(t, v)
+}
+
+func TestGetUserReactionsForCommentsCap(t *testing.T) {
+	ids := make([]string, MaxReactionLookupIDs+1)
+	_, err := (&ReactionUsecase{}).GetUserReactionsForComments(context.Background(), ids, "alice")
+	assert.EqualError(t, err, "at most 200 comment IDs can be looked up at once")
+}
@@ -0,0 +1,377 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minisource/comment/config"
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AutoHideModerator is recorded as the moderator of comments hidden by reports
+const AutoHideModerator = "system"
+
+// AuditCommentAutoHidden is the audit action for comments hidden by reports
+const AuditCommentAutoHidden = "comment.auto_hidden"
+
+// AuditReportReopened is the audit action for reports reopened by a moderator
+const AuditReportReopened = "report.reopened"
+
+// ReportUsecase handles report business logic
+type ReportUsecase struct {
+	commentRepo  *repository.CommentRepository
+	reportRepo   *repository.ReportRepository
+	settingsRepo *repository.SettingsRepository
+	auditRepo    *repository.AuditRepository
+	notifier     NotifierClient
+	cache        Cache
+	cfg          *config.Config
+	alerts       *reportAlertThrottle
+}
+
+// NewReportUsecase creates a new report usecase
+func NewReportUsecase(
+	commentRepo *repository.CommentRepository,
+	reportRepo *repository.ReportRepository,
+	settingsRepo *repository.SettingsRepository,
+	auditRepo *repository.AuditRepository,
+	notifier NotifierClient,
+	cache Cache,
+	cfg *config.Config,
+) *ReportUsecase {
+	return &ReportUsecase{
+		commentRepo:  commentRepo,
+		reportRepo:   reportRepo,
+		settingsRepo: settingsRepo,
+		auditRepo:    auditRepo,
+		notifier:     notifier,
+		cache:        cache,
+		cfg:          cfg,
+		alerts:       newReportAlertThrottle(cfg.Notifier.ReportAlertWindow),
+	}
+}
+
+// ReportComment files a user report against a comment
+func (u *ReportUsecase) ReportComment(ctx context.Context, commentID, reporterID string, req models.ReportRequest) (*models.Report, error) {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+	if !isValidReportReason(req.Reason) {
+		return nil, fmt.Errorf("invalid report reason")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if comment.IsDeleted {
+		return nil, fmt.Errorf("cannot report deleted comment")
+	}
+
+	report := &models.Report{
+		CommentID:   oid,
+		ReporterID:  reporterID,
+		Reason:      req.Reason,
+		Description: req.Description,
+	}
+
+	if err := u.reportRepo.Create(ctx, report); err != nil {
+		return nil, err
+	}
+
+	reportCount, err := u.commentRepo.IncrementReportCount(ctx, oid)
+	if err != nil {
+		log.Printf("Failed to increment report count: %v", err)
+	} else {
+		u.autoHide(ctx, comment, reportCount)
+	}
+
+	if u.alerts.allow(commentID, time.Now()) {
+		go u.sendReportNotification(comment)
+	}
+
+	return report, nil
+}
+
+// autoHide sends a comment back to the moderation queue once its report
+// count reaches the tenant's threshold. Failures are logged; the report
+// itself has already been recorded.
+func (u *ReportUsecase) autoHide(ctx context.Context, comment *models.Comment, reportCount int) {
+	settings, err := u.settingsRepo.GetOrCreate(ctx, comment.TenantID, comment.ResourceType)
+	if err != nil {
+		log.Printf("Failed to get settings for auto-hide: %v", err)
+		return
+	}
+	if !autoHideReported(comment, reportCount, settings, models.Now()) {
+		return
+	}
+
+	if err := u.commentRepo.Update(ctx, comment); err != nil {
+		log.Printf("Failed to auto-hide comment: %v", err)
+		return
+	}
+	invalidateCache(ctx, u.cache, comment)
+
+	entry := &models.AuditEntry{
+		TenantID:  comment.TenantID,
+		Action:    AuditCommentAutoHidden,
+		CommentID: comment.ID,
+		ActorID:   AutoHideModerator,
+		Status:    comment.Status,
+	}
+	if err := u.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to record audit entry: %v", err)
+	}
+}
+
+// autoHideReported moves an approved comment to pending, hiding it from
+// public listings while keeping it in the moderation queue, once its report
+// count reaches the threshold. It reports whether the comment was changed.
+func autoHideReported(comment *models.Comment, reportCount int, settings *models.CommentSettings, now time.Time) bool {
+	comment.ReportCount = reportCount
+	if settings.AutoHideReportThreshold <= 0 || reportCount < settings.AutoHideReportThreshold {
+		return false
+	}
+	if comment.Status != models.StatusApproved {
+		return false
+	}
+
+	comment.Status = models.StatusPending
+	comment.ModeratedBy = AutoHideModerator
+	comment.ModeratedAt = &now
+	return true
+}
+
+// ReopenReport sets a reviewed or dismissed report back to pending, records
+// the reopen in the audit log and alerts moderators again. With
+// requeueComment, an approved comment also goes back to the moderation queue.
+func (u *ReportUsecase) ReopenReport(ctx context.Context, reportID, moderatorID string, requeueComment bool) (*models.Report, error) {
+	oid, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report ID")
+	}
+
+	report, err := u.reportRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, fmt.Errorf("report not found")
+	}
+	if err := reopenReport(report); err != nil {
+		return nil, err
+	}
+
+	reopened, err := u.reportRepo.Reopen(ctx, oid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen report: %w", err)
+	}
+	if !reopened {
+		// Reopened by someone else since we read it
+		return nil, fmt.Errorf("only reviewed or dismissed reports can be reopened")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, report.CommentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		// The report is open again; there is just nothing left to requeue
+		return report, nil
+	}
+
+	if requeueComment && requeueReported(comment, moderatorID, models.Now()) {
+		if err := u.commentRepo.Update(ctx, comment); err != nil {
+			return nil, fmt.Errorf("failed to requeue comment: %w", err)
+		}
+		invalidateCache(ctx, u.cache, comment)
+	}
+
+	entry := &models.AuditEntry{
+		TenantID:  comment.TenantID,
+		Action:    AuditReportReopened,
+		CommentID: comment.ID,
+		ActorID:   moderatorID,
+		Status:    comment.Status,
+	}
+	if err := u.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to record audit entry: %v", err)
+	}
+
+	go u.sendReportNotification(comment)
+
+	return report, nil
+}
+
+// reopenReport resets a resolved report to pending in memory, clearing its review
+func reopenReport(report *models.Report) error {
+	if report.Status != models.ReportReviewed && report.Status != models.ReportDismissed {
+		return fmt.Errorf("only reviewed or dismissed reports can be reopened")
+	}
+	report.Status = models.ReportPending
+	report.ReviewedBy = ""
+	report.ReviewedAt = nil
+	return nil
+}
+
+// requeueReported moves an approved comment back to pending for another
+// look, recording the moderator who asked for it. It reports whether the
+// comment was changed.
+func requeueReported(comment *models.Comment, moderatorID string, now time.Time) bool {
+	if comment.Status != models.StatusApproved || comment.IsDeleted {
+		return false
+	}
+	comment.Status = models.StatusPending
+	comment.ModeratedBy = moderatorID
+	comment.ModeratedAt = &now
+	return true
+}
+
+// isValidReportReason checks if a report reason is one of the accepted values
+func isValidReportReason(reason string) bool {
+	switch reason {
+	case "spam", "inappropriate", "harassment", "hate_speech", "misinformation", "other":
+		return true
+	}
+	return false
+}
+
+// GetCommentReports retrieves a page of a comment's reports with a breakdown
+// by reason. Reporter IDs are only included for admins.
+func (u *ReportUsecase) GetCommentReports(ctx context.Context, commentID string, page, pageSize int, sortOrder string, isAdmin bool) (*models.CommentReportsResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID")
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	reports, total, err := u.reportRepo.GetByCommentID(ctx, oid, page, pageSize, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reports: %w", err)
+	}
+
+	breakdown, err := u.reportRepo.GetReasonBreakdown(ctx, oid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report breakdown: %w", err)
+	}
+
+	return newCommentReportsResponse(reports, total, page, pageSize, breakdown, isAdmin), nil
+}
+
+// newCommentReportsResponse assembles a report page, applying the same page
+// defaults as the repository and hiding reporters from non-admins
+func newCommentReportsResponse(reports []*models.Report, total int64, page, pageSize int, breakdown map[string]int64, isAdmin bool) *models.CommentReportsResponse {
+	pagination := models.NewPagination(total, page, pageSize)
+
+	if reports == nil {
+		reports = []*models.Report{}
+	}
+	if !isAdmin {
+		for _, report := range reports {
+			report.ReporterID = ""
+		}
+	}
+
+	return &models.CommentReportsResponse{
+		Reports:         reports,
+		Total:           pagination.Total,
+		Page:            pagination.Page,
+		PageSize:        pagination.PageSize,
+		TotalPages:      pagination.TotalPages,
+		ReasonBreakdown: breakdown,
+	}
+}
+
+// sendReportNotification alerts moderators about a reported comment with the current report summary
+func (u *ReportUsecase) sendReportNotification(comment *models.Comment) {
+	if u.notifier == nil || !u.cfg.Notifier.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	breakdown, err := u.reportRepo.GetReasonBreakdown(ctx, comment.ID)
+	if err != nil {
+		log.Printf("Failed to get report breakdown: %v", err)
+		return
+	}
+
+	var total int64
+	data := map[string]string{
+		"comment_id":    comment.ID.Hex(),
+		"tenant_id":     comment.TenantID,
+		"resource_type": comment.ResourceType,
+		"resource_id":   comment.ResourceID,
+	}
+	for reason, count := range breakdown {
+		total += count
+		data["reason_"+reason] = strconv.FormatInt(count, 10)
+	}
+	data["report_count"] = strconv.FormatInt(total, 10)
+	addNotificationMetadata(data, comment.Metadata, u.cfg.Notifier.MetadataKeys)
+
+	notification := NotificationRequest{
+		Type:       "comment.reported",
+		Recipients: []string{"moderators"},
+		Title:      "Comment Reported",
+		Body:       fmt.Sprintf("A comment has been reported %d time(s): %s", total, truncateString(comment.Content, 100)),
+		Data:       data,
+	}
+
+	if err := dispatchNotification(ctx, u.notifier, u.cfg.Notifier, notification); err != nil {
+		log.Printf("Failed to send report notification: %v", err)
+	}
+}
+
+// reportAlertThrottle lets the first report on a comment alert moderators
+// immediately and suppresses further alerts for that comment within the window
+type reportAlertThrottle struct {
+	mu     sync.Mutex
+	window time.Duration
+	last   map[string]time.Time
+}
+
+func newReportAlertThrottle(window time.Duration) *reportAlertThrottle {
+	return &reportAlertThrottle{
+		window: window,
+		last:   make(map[string]time.Time),
+	}
+}
+
+// allow reports whether an alert for the comment should be sent now
+func (t *reportAlertThrottle) allow(commentID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Drop expired entries so the map doesn't grow unbounded
+	for id, at := range t.last {
+		if now.Sub(at) >= t.window {
+			delete(t.last, id)
+		}
+	}
+
+	if _, seen := t.last[commentID]; seen {
+		return false
+	}
+
+	t.last[commentID] = now
+	return true
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportAlertThrottle(t *testing.T) {
+	throttle := newReportAlertThrottle(15 * time.Minute)
+	start := time.Now()
+
+	t.Run("Burst On Same Comment", func(t *testing.T) {
+		alerts := 0
+		for i := 0; i < 10; i++ {
+			if throttle.allow("comment-1", start.Add(time.Duration(i)*time.Second)) {
+				alerts++
+			}
+		}
+		assert.Equal(t, 1, alerts)
+	})
+
+	t.Run("Other Comment Alerts Independently", func(t *testing.T) {
+		assert.True(t, throttle.allow("comment-2", start.Add(time.Minute)))
+	})
+
+	t.Run("Alerts Again After Window", func(t *testing.T) {
+		assert.True(t, throttle.allow("comment-1", start.Add(16*time.Minute)))
+	})
+}
+
+func TestNewCommentReportsResponse(t *testing.T) {
+	// Second page of a comment with 45 reports
+	page := make([]*models.Report, 20)
+	for i := range page {
+		page[i] = &models.Report{ReporterID: fmt.Sprintf("user-%d", 20+i), Reason: "spam"}
+	}
+	breakdown := map[string]int64{"spam": 30, "harassment": 10, "other": 5}
+
+	resp := newCommentReportsResponse(page, 45, 2, 20, breakdown, true)
+
+	assert.Len(t, resp.Reports, 20)
+	assert.Equal(t, int64(45), resp.Total)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 20, resp.PageSize)
+	assert.Equal(t, int64(3), resp.TotalPages)
+	assert.Equal(t, breakdown, resp.ReasonBreakdown)
+	assert.Equal(t, "user-20", resp.Reports[0].ReporterID)
+
+	t.Run("Reporters Hidden From Non-Admins", func(t *testing.T) {
+		resp := newCommentReportsResponse(page, 45, 2, 20, breakdown, false)
+		for _, report := range resp.Reports {
+			assert.Empty(t, report.ReporterID)
+		}
+	})
+
+	t.Run("Page Defaults", func(t *testing.T) {
+		resp := newCommentReportsResponse(nil, 45, 0, 500, breakdown, true)
+		assert.Equal(t, 1, resp.Page)
+		assert.Equal(t, 20, resp.PageSize)
+		assert.Equal(t, int64(3), resp.TotalPages)
+		assert.NotNil(t, resp.Reports)
+	})
+}
+
+func TestIsValidReportReason(t *testing.T) {
+	for _, reason := range []string{"spam", "inappropriate", "harassment", "hate_speech", "misinformation", "other"} {
+		assert.True(t, isValidReportReason(reason), reason)
+	}
+	assert.False(t, isValidReportReason(""))
+	assert.False(t, isValidReportReason("boring"))
+	assert.False(t, isValidReportReason("SPAM"))
+}
+
+func TestAutoHideReported(t *testing.T) {
+	settings := &models.CommentSettings{AutoHideReportThreshold: 5}
+	now := time.Now()
+
+	t.Run("Below Threshold", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusApproved}
+		assert.False(t, autoHideReported(comment, 4, settings, now))
+		assert.Equal(t, models.StatusApproved, comment.Status)
+		assert.Equal(t, 4, comment.ReportCount)
+	})
+
+	t.Run("Threshold Reached", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusApproved}
+		assert.True(t, autoHideReported(comment, 5, settings, now))
+		assert.Equal(t, models.StatusPending, comment.Status)
+		assert.Equal(t, AutoHideModerator, comment.ModeratedBy)
+		assert.Equal(t, &now, comment.ModeratedAt)
+		assert.Equal(t, 5, comment.ReportCount)
+	})
+
+	t.Run("Already Hidden Or Rejected", func(t *testing.T) {
+		for _, status := range []models.CommentStatus{models.StatusPending, models.StatusRejected} {
+			comment := &models.Comment{Status: status}
+			assert.False(t, autoHideReported(comment, 9, settings, now), status)
+			assert.Equal(t, status, comment.Status)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		comment := &models.Comment{Status: models.StatusApproved}
+		assert.False(t, autoHideReported(comment, 100, &models.CommentSettings{}, now))
+	})
+}
+
+func TestReopenReport(t *testing.T) {
+	reviewedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Dismissed", func(t *testing.T) {
+		report := &models.Report{Status: models.ReportDismissed, ReviewedBy: "mod-1", ReviewedAt: &reviewedAt}
+		assert.NoError(t, reopenReport(report))
+		assert.Equal(t, models.ReportPending, report.Status)
+		assert.Empty(t, report.ReviewedBy)
+		assert.Nil(t, report.ReviewedAt)
+	})
+
+	t.Run("Reviewed", func(t *testing.T) {
+		report := &models.Report{Status: models.ReportReviewed, ReviewedBy: "mod-1", ReviewedAt: &reviewedAt}
+		assert.NoError(t, reopenReport(report))
+		assert.Equal(t, models.ReportPending, report.Status)
+	})
+
+	t.Run("Still Pending", func(t *testing.T) {
+		report := &models.Report{Status: models.ReportPending}
+		assert.EqualError(t, reopenReport(report), "only reviewed or dismissed reports can be reopened")
+	})
+}
+
+func TestRequeueReported(t *testing.T) {
+	now := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	comment := &models.Comment{Status: models.StatusApproved}
+	assert.True(t, requeueReported(comment, "mod-2", now))
+	assert.Equal(t, models.StatusPending, comment.Status)
+	assert.Equal(t, "mod-2", comment.ModeratedBy)
+	assert.Equal(t, now, *comment.ModeratedAt)
+
+	rejected := &models.Comment{Status: models.StatusRejected}
+	assert.False(t, requeueReported(rejected, "mod-2", now))
+	assert.Equal(t, models.StatusRejected, rejected.Status)
+
+	deleted := &models.Comment{Status: models.StatusApproved, IsDeleted: true}
+	assert.False(t, requeueReported(deleted, "mod-2", now))
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/minisource/comment/internal/models"
+)
+
+// adjustResourceCount moves a comment's resource counter by delta. A resource
+// without a counter is seeded from a fresh count when a comment is added, and
+// left alone otherwise; readers fall back to counting until it exists.
+// Failures are logged, since RecountResource can repair a drifted counter.
+func (u *CommentUsecase) adjustResourceCount(ctx context.Context, comment *models.Comment, delta int64) {
+	found, err := u.counterRepo.Increment(ctx, comment.TenantID, comment.ResourceType, comment.ResourceID, delta)
+	if err != nil {
+		log.Printf("Failed to update resource counter: %v", err)
+		return
+	}
+	if found || delta < 0 {
+		return
+	}
+
+	count, err := u.commentRepo.CountResource(ctx, comment.TenantID, comment.ResourceType, comment.ResourceID)
+	if err != nil {
+		log.Printf("Failed to count resource comments: %v", err)
+		return
+	}
+	if err := u.counterRepo.Seed(ctx, comment.TenantID, comment.ResourceType, comment.ResourceID, count); err != nil {
+		log.Printf("Failed to seed resource counter: %v", err)
+	}
+}
+
+// resourceCount reads a resource's live comment count from its counter; ok is
+// false when there is no counter or it can't be read
+func (u *CommentUsecase) resourceCount(ctx context.Context, tenantID, resourceType, resourceID string) (int64, bool) {
+	count, ok, err := u.counterRepo.Get(ctx, tenantID, resourceType, resourceID)
+	if err != nil {
+		log.Printf("Failed to read resource counter: %v", err)
+		return 0, false
+	}
+	return count, ok
+}
+
+// RecountResource rebuilds a resource's counter from its comments
+func (u *CommentUsecase) RecountResource(ctx context.Context, tenantID string, req models.RecountResourceRequest) (*models.ResourceCounter, error) {
+	if req.ResourceType == "" || req.ResourceID == "" {
+		return nil, fmt.Errorf("resource type and resource ID are required")
+	}
+
+	count, err := u.commentRepo.CountResource(ctx, tenantID, req.ResourceType, req.ResourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+	if err := u.counterRepo.Set(ctx, tenantID, req.ResourceType, req.ResourceID, count); err != nil {
+		return nil, fmt.Errorf("failed to update counter: %w", err)
+	}
+
+	return &models.ResourceCounter{
+		TenantID:     tenantID,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		Count:        count,
+		UpdatedAt:    models.Now(),
+	}, nil
+}
+
+// list runs a listing, taking the total from the resource's counter instead
+// of counting when the listing covers every live comment on the resource
+func (u *CommentUsecase) list(ctx context.Context, req models.ListCommentsRequest) ([]*models.Comment, int64, error) {
+	if countsWholeResource(req) {
+		if total, ok := u.resourceCount(ctx, req.TenantID, req.ResourceType, req.ResourceID); ok {
+			comments, _, err := u.commentRepo.List(ctx, req, false)
+			return comments, total, err
+		}
+	}
+	return u.commentRepo.List(ctx, req, true)
+}
+
+// countsWholeResource reports whether a listing's total is the resource's
+// live comment count: roots and replies in the flat view, with no filter
+// beyond the resource. Status filters still count, since moderation doesn't
+// move the counter.
+func countsWholeResource(req models.ListCommentsRequest) bool {
+	return req.TenantID != "" && req.ResourceType != "" && req.ResourceID != "" &&
+		req.View == models.ViewFlat && req.ParentID == "" &&
+		req.Status == "" && req.PendingFor == "" &&
+		req.AuthorID == "" && len(req.AuthorIDs) == 0 &&
+		req.IsPinned == nil && req.Label == "" && !req.IncludeDeleted &&
+		req.CreatedAfter == nil && req.CreatedBefore == nil
+}
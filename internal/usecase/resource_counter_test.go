@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountsWholeResource(t *testing.T) {
+	whole := models.ListCommentsRequest{TenantID: "shop", ResourceType: "product", ResourceID: "p1", View: models.ViewFlat}
+	pinned := true
+
+	tests := []struct {
+		name   string
+		modify func(*models.ListCommentsRequest)
+		want   bool
+	}{
+		{"Whole Resource", func(*models.ListCommentsRequest) {}, true},
+		{"Roots Only", func(r *models.ListCommentsRequest) { r.View = "" }, false},
+		{"Status Filter", func(r *models.ListCommentsRequest) { r.Status = models.StatusApproved }, false},
+		{"Own Pending", func(r *models.ListCommentsRequest) { r.PendingFor = "alice" }, false},
+		{"Author", func(r *models.ListCommentsRequest) { r.AuthorID = "alice" }, false},
+		{"Authors", func(r *models.ListCommentsRequest) { r.AuthorIDs = []string{"alice"} }, false},
+		{"Pinned", func(r *models.ListCommentsRequest) { r.IsPinned = &pinned }, false},
+		{"Label", func(r *models.ListCommentsRequest) { r.Label = "off-topic" }, false},
+		{"Deleted Included", func(r *models.ListCommentsRequest) { r.IncludeDeleted = true }, false},
+		{"No Resource", func(r *models.ListCommentsRequest) { r.ResourceID = "" }, false},
+		{"Paging Still Counts", func(r *models.ListCommentsRequest) { r.Page, r.SortOrder = 3, "desc" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := whole
+			tt.modify(&req)
+			assert.Equal(t, tt.want, countsWholeResource(req))
+		})
+	}
+}
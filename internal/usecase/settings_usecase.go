@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/minisource/comment/internal/repository"
+)
+
+// SettingsUsecase handles per-tenant comment settings
+type SettingsUsecase struct {
+	settingsRepo *repository.SettingsRepository
+}
+
+// NewSettingsUsecase creates a new settings usecase
+func NewSettingsUsecase(settingsRepo *repository.SettingsRepository) *SettingsUsecase {
+	return &SettingsUsecase{
+		settingsRepo: settingsRepo,
+	}
+}
+
+// GetSettings retrieves a resource type's settings, creating the defaults if needed
+func (u *SettingsUsecase) GetSettings(ctx context.Context, tenantID, resourceType string) (*models.CommentSettings, error) {
+	if resourceType == "" {
+		return nil, fmt.Errorf("resource type is required")
+	}
+	return u.settingsRepo.GetOrCreate(ctx, tenantID, resourceType)
+}
+
+// GetPublicSettings retrieves the widget-facing subset of a resource type's
+// effective settings
+func (u *SettingsUsecase) GetPublicSettings(ctx context.Context, tenantID, resourceType string) (*models.PublicSettings, error) {
+	if resourceType == "" {
+		return nil, fmt.Errorf("resource type is required")
+	}
+	settings, err := u.settingsRepo.GetEffective(ctx, tenantID, resourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return newPublicSettings(settings), nil
+}
+
+// newPublicSettings copies only the fields a public widget may see
+func newPublicSettings(settings *models.CommentSettings) *models.PublicSettings {
+	allowed := settings.AllowedReactions
+	if allowed == nil {
+		allowed = []models.ReactionType{}
+	}
+	return &models.PublicSettings{
+		ResourceType:     settings.ResourceType,
+		CommentsEnabled:  settings.CommentsEnabled,
+		RequireApproval:  settings.RequireApproval,
+		AllowReplies:     settings.AllowReplies,
+		AllowReactions:   settings.AllowReactions,
+		AllowedReactions: allowed,
+		AllowAttachments: settings.AllowAttachments,
+		MaxCommentLength: settings.MaxCommentLength,
+	}
+}
+
+// ListSettings retrieves every resource type's settings for a tenant
+func (u *SettingsUsecase) ListSettings(ctx context.Context, tenantID string) ([]*models.CommentSettings, error) {
+	settings, err := u.settingsRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = []*models.CommentSettings{}
+	}
+	return settings, nil
+}
+
+// UpdateSettings applies a partial update to a resource type's settings
+func (u *SettingsUsecase) UpdateSettings(ctx context.Context, tenantID, resourceType string, req models.SettingsRequest) (*models.CommentSettings, error) {
+	if resourceType == "" {
+		return nil, fmt.Errorf("resource type is required")
+	}
+	if err := validateSettingsRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Start from the defaults so an update never leaves the other fields unset
+	if _, err := u.settingsRepo.GetOrCreate(ctx, tenantID, resourceType); err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	settings, err := u.settingsRepo.Update(ctx, tenantID, resourceType, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update settings: %w", err)
+	}
+	return settings, nil
+}
+
+// validateSettingsRequest rejects negative limits before they are persisted
+func validateSettingsRequest(req models.SettingsRequest) error {
+	if req.MaxReplyDepth != nil && *req.MaxReplyDepth < 0 {
+		return fmt.Errorf("maxReplyDepth cannot be negative")
+	}
+	if req.MaxAttachments != nil && *req.MaxAttachments < 0 {
+		return fmt.Errorf("maxAttachments cannot be negative")
+	}
+	if req.MaxCommentLength != nil && *req.MaxCommentLength < 0 {
+		return fmt.Errorf("maxCommentLength cannot be negative")
+	}
+	if req.Webhook != nil {
+		return validateWebhookSettings(req.Webhook)
+	}
+	return nil
+}
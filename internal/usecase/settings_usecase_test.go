@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSettingsRequest(t *testing.T) {
+	negative, zero, positive := -1, 0, 10
+
+	assert.NoError(t, validateSettingsRequest(models.SettingsRequest{}))
+	assert.NoError(t, validateSettingsRequest(models.SettingsRequest{
+		MaxReplyDepth:    &zero,
+		MaxAttachments:   &zero,
+		MaxCommentLength: &positive,
+	}))
+
+	assert.EqualError(t, validateSettingsRequest(models.SettingsRequest{MaxReplyDepth: &negative}), "maxReplyDepth cannot be negative")
+	assert.EqualError(t, validateSettingsRequest(models.SettingsRequest{MaxAttachments: &negative}), "maxAttachments cannot be negative")
+	assert.EqualError(t, validateSettingsRequest(models.SettingsRequest{MaxCommentLength: &negative}), "maxCommentLength cannot be negative")
+}
+
+func TestNewPublicSettings(t *testing.T) {
+	settings := &models.CommentSettings{
+		TenantID:         "tenant-1",
+		ResourceType:     "product",
+		CommentsEnabled:  true,
+		RequireApproval:  true,
+		AllowReplies:     true,
+		AllowReactions:   true,
+		AllowedReactions: []models.ReactionType{models.ReactionLike},
+		MaxCommentLength: 2000,
+		BadWordsFilter:   true,
+		CustomBadWords:   []string{"secretword"},
+		ModerationLabels: []string{"off-topic"},
+		AllowedCountries: []string{"DE"},
+	}
+
+	data, err := json.Marshal(newPublicSettings(settings))
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.ElementsMatch(t, []string{
+		"resourceType", "commentsEnabled", "requireApproval", "allowReplies",
+		"allowReactions", "allowedReactions", "allowAttachments", "maxCommentLength",
+	}, slices.Collect(maps.Keys(fields)))
+	assert.NotContains(t, string(data), "secretword")
+	assert.NotContains(t, string(data), "tenant-1")
+	assert.NotContains(t, string(data), "off-topic")
+	assert.Equal(t, float64(2000), fields["maxCommentLength"])
+}
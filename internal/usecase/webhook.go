@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minisource/comment/internal/models"
+)
+
+// Webhook events
+const (
+	WebhookCommentCreated   = "comment.created"
+	WebhookCommentUpdated   = "comment.updated"
+	WebhookCommentDeleted   = "comment.deleted"
+	WebhookCommentModerated = "comment.moderated"
+	WebhookReactionAdded    = "reaction.added"
+)
+
+// webhookEvents lists every event a tenant may subscribe to
+var webhookEvents = []string{
+	WebhookCommentCreated, WebhookCommentUpdated, WebhookCommentDeleted,
+	WebhookCommentModerated, WebhookReactionAdded,
+}
+
+// webhookTimeout bounds a delivery including its retries
+const webhookTimeout = 2 * time.Minute
+
+// WebhookDispatcher interface for delivering comment events to an
+// integrator's endpoint
+type WebhookDispatcher interface {
+	SendWebhook(ctx context.Context, event string, data any) error
+}
+
+// ReactionWebhookData is the data of a reaction.added event
+type ReactionWebhookData struct {
+	CommentID    string              `json:"commentId"`
+	TenantID     string              `json:"tenantId"`
+	ResourceType string              `json:"resourceType"`
+	ResourceID   string              `json:"resourceId"`
+	UserID       string              `json:"userId"`
+	Type         models.ReactionType `json:"type"`
+}
+
+// webhookTemplateFields are the event data fields a payload template may
+// map: comment fields, plus the ones reaction events carry
+var webhookTemplateFields = map[string]bool{
+	"id": true, "tenantId": true, "resourceType": true, "resourceId": true,
+	"parentId": true, "rootId": true, "authorId": true, "authorName": true,
+	"authorAvatar": true, "isAnonymous": true, "content": true, "contentHtml": true,
+	"snippet": true, "language": true, "status": true, "moderatedBy": true,
+	"moderatedAt": true, "rejectionReason": true, "labels": true, "isPinned": true,
+	"isEdited": true, "replyCount": true, "likeCount": true, "dislikeCount": true,
+	"totalReactions": true, "helpfulCount": true, "notHelpfulCount": true,
+	"metadata": true, "createdAt": true, "updatedAt": true, "deletedAt": true,
+	"isDeleted": true, "depth": true,
+	"commentId": true, "userId": true, "type": true,
+}
+
+// settingsLoader loads a tenant's effective settings for a resource type
+type settingsLoader func(ctx context.Context, tenantID, resourceType string) (*models.CommentSettings, error)
+
+// dispatchWebhook delivers a comment event in the background. The comment is
+// copied first, since callers keep changing it after the event fires, and
+// only the allowlisted metadata keys (NOTIFIER_METADATA_KEYS) leave with it.
+func dispatchWebhook(dispatcher WebhookDispatcher, loadSettings settingsLoader, metadataKeys []string, event string, comment *models.Comment) {
+	if dispatcher == nil {
+		return
+	}
+	snapshot := *comment
+	snapshot.Metadata = allowedMetadata(comment.Metadata, metadataKeys)
+	sendWebhook(dispatcher, loadSettings, event, comment.TenantID, comment.ResourceType, &snapshot)
+}
+
+// allowedMetadata copies the allowlisted keys of a comment's metadata
+func allowedMetadata(metadata map[string]any, allowlist []string) map[string]any {
+	var allowed map[string]any
+	for _, key := range allowlist {
+		if value, ok := metadata[key]; ok {
+			if allowed == nil {
+				allowed = make(map[string]any, len(allowlist))
+			}
+			allowed[key] = value
+		}
+	}
+	return allowed
+}
+
+// sendWebhook delivers an event in the background, filtered and shaped by the
+// tenant's webhook settings, logging failures
+func sendWebhook(dispatcher WebhookDispatcher, loadSettings settingsLoader, event, tenantID, resourceType string, data any) {
+	if dispatcher == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		defer cancel()
+
+		data, ok := tenantWebhookData(ctx, loadSettings, event, tenantID, resourceType, data)
+		if !ok {
+			return
+		}
+		if err := dispatcher.SendWebhook(ctx, event, data); err != nil {
+			log.Printf("Failed to send %s webhook: %v", event, err)
+		}
+	}()
+}
+
+// tenantWebhookData applies the tenant's webhook settings to an event,
+// reporting false when the tenant filtered it out. Settings that fail to load
+// leave the event as is.
+func tenantWebhookData(ctx context.Context, loadSettings settingsLoader, event, tenantID, resourceType string, data any) (any, bool) {
+	if loadSettings == nil {
+		return data, true
+	}
+	settings, err := loadSettings(ctx, tenantID, resourceType)
+	if err != nil {
+		log.Printf("Failed to get webhook settings: %v", err)
+		return data, true
+	}
+	if settings.Webhook == nil {
+		return data, true
+	}
+	if len(settings.Webhook.Events) > 0 && !slices.Contains(settings.Webhook.Events, event) {
+		return nil, false
+	}
+
+	shaped, err := shapeWebhookData(data, settings.Webhook.Template)
+	if err != nil {
+		log.Printf("Failed to apply %s webhook template: %v", event, err)
+		return data, true
+	}
+	return shaped, true
+}
+
+// shapeWebhookData builds a payload from a template mapping payload fields to
+// event data fields. Unmapped fields are dropped; fields the event doesn't
+// carry are null.
+func shapeWebhookData(data any, template map[string]string) (any, error) {
+	if len(template) == 0 {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	shaped := make(map[string]any, len(template))
+	for payloadField, dataField := range template {
+		shaped[payloadField] = fields[dataField]
+	}
+	return shaped, nil
+}
+
+// validateWebhookSettings rejects unknown events and templates that map
+// anything but known event data fields
+func validateWebhookSettings(settings *models.WebhookSettings) error {
+	for _, event := range settings.Events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("unknown webhook event %q", event)
+		}
+	}
+	for payloadField, dataField := range settings.Template {
+		if strings.TrimSpace(payloadField) == "" {
+			return fmt.Errorf("webhook template fields cannot be empty")
+		}
+		if !webhookTemplateFields[dataField] {
+			return fmt.Errorf("webhook template field %q maps unknown field %q", payloadField, dataField)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minisource/comment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordingDispatcher hands every event it is sent to a channel
+type recordingDispatcher chan any
+
+func (r recordingDispatcher) SendWebhook(_ context.Context, _ string, data any) error {
+	r <- data
+	return nil
+}
+
+func TestDispatchWebhook(t *testing.T) {
+	dispatcher := make(recordingDispatcher, 1)
+	comment := &models.Comment{Content: "first", Status: models.StatusPending}
+
+	dispatchWebhook(dispatcher, nil, nil, WebhookCommentCreated, comment)
+	comment.Content = "changed after the event"
+
+	select {
+	case data := <-dispatcher:
+		sent, ok := data.(*models.Comment)
+		require.True(t, ok)
+		assert.Equal(t, "first", sent.Content, "the event carries the comment as it was")
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not sent")
+	}
+
+	// Without a dispatcher nothing is sent
+	dispatchWebhook(nil, nil, nil, WebhookCommentCreated, comment)
+}
+
+func TestDispatchWebhookMetadata(t *testing.T) {
+	dispatcher := make(recordingDispatcher, 1)
+	comment := &models.Comment{Metadata: map[string]any{"orderId": "o-42", "internalScore": 0.93}}
+
+	dispatchWebhook(dispatcher, nil, []string{"orderId", "campaign"}, WebhookCommentCreated, comment)
+
+	select {
+	case data := <-dispatcher:
+		sent := data.(*models.Comment)
+		assert.Equal(t, map[string]any{"orderId": "o-42"}, sent.Metadata, "only allowlisted keys leave the service")
+		assert.Len(t, comment.Metadata, 2, "the comment itself is untouched")
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not sent")
+	}
+
+	assert.Nil(t, allowedMetadata(comment.Metadata, nil), "no allowlist sends no metadata")
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	settings := &models.CommentSettings{Webhook: &models.WebhookSettings{
+		Events:   []string{WebhookCommentCreated},
+		Template: map[string]string{"external_id": "id", "body": "content", "reviewer": "moderatedBy"},
+	}}
+	loadSettings := func(context.Context, string, string) (*models.CommentSettings, error) {
+		return settings, nil
+	}
+	comment := &models.Comment{ID: primitive.NewObjectID(), TenantID: "shop", Content: "Great product", AuthorID: "alice"}
+
+	t.Run("Reshapes Payload", func(t *testing.T) {
+		dispatcher := make(recordingDispatcher, 1)
+		dispatchWebhook(dispatcher, loadSettings, nil, WebhookCommentCreated, comment)
+
+		select {
+		case data := <-dispatcher:
+			assert.Equal(t, map[string]any{
+				"external_id": comment.ID.Hex(),
+				"body":        "Great product",
+				"reviewer":    nil,
+			}, data, "mapped fields are renamed and everything else is dropped")
+		case <-time.After(time.Second):
+			t.Fatal("webhook was not sent")
+		}
+	})
+
+	t.Run("Filters Events", func(t *testing.T) {
+		data, ok := tenantWebhookData(context.Background(), loadSettings, WebhookCommentDeleted, "shop", "product", comment)
+		assert.False(t, ok)
+		assert.Nil(t, data)
+	})
+
+	t.Run("Settings Unavailable", func(t *testing.T) {
+		failing := func(context.Context, string, string) (*models.CommentSettings, error) {
+			return nil, errors.New("connection refused")
+		}
+		data, ok := tenantWebhookData(context.Background(), failing, WebhookCommentDeleted, "shop", "product", comment)
+		assert.True(t, ok)
+		assert.Same(t, comment, data, "sent as is")
+	})
+}
+
+func TestValidateWebhookSettings(t *testing.T) {
+	assert.NoError(t, validateWebhookSettings(&models.WebhookSettings{
+		Events:   []string{WebhookCommentCreated, WebhookReactionAdded},
+		Template: map[string]string{"external_id": "id", "reaction": "type"},
+	}))
+	assert.NoError(t, validateWebhookSettings(&models.WebhookSettings{}))
+
+	assert.EqualError(t, validateWebhookSettings(&models.WebhookSettings{Events: []string{"comment.liked"}}),
+		`unknown webhook event "comment.liked"`)
+	assert.EqualError(t, validateWebhookSettings(&models.WebhookSettings{Template: map[string]string{"ip": "ipAddress"}}),
+		`webhook template field "ip" maps unknown field "ipAddress"`)
+	assert.EqualError(t, validateWebhookSettings(&models.WebhookSettings{Template: map[string]string{" ": "id"}}),
+		"webhook template fields cannot be empty")
+}